@@ -35,7 +35,7 @@ import (
 
 var (
 	historyFilePath = filepath.Join(os.TempDir(), ".ecoclient_history")
-	commandName     = []string{"contract", "transfer", "wallet", "query", "attach"}
+	commandName     = []string{"contract", "transfer", "action", "wallet", "query", "attach"}
 )
 
 func newClientApp() *cli.App {
@@ -57,6 +57,8 @@ func newClientApp() *cli.App {
 	app.Commands = []cli.Command{
 		commands.ContractCommands,
 		commands.TransferCommands,
+		commands.ActionCommands,
+		commands.TokenCommands,
 		commands.WalletCommands,
 		commands.QueryCommands,
 		commands.AttachCommands,
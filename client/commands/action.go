@@ -0,0 +1,93 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ecoball/go-ecoball/client/rpc"
+
+	"github.com/urfave/cli"
+)
+
+var (
+	ActionCommands = cli.Command{
+		Name:        "action",
+		Usage:       "send actions in one transaction",
+		Category:    "Action",
+		Description: "With ecoclient action, you could send transfer, deploy and invoke actions which are executed atomically",
+		ArgsUsage:   "[args]",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "from, f",
+				Usage: "sender name, it pays for the transaction",
+			},
+			cli.StringFlag{
+				Name:  "actions, a",
+				Usage: `json array of actions, e.g. [{"type":"transfer","from":"root","to":"worker","value":10},{"type":"invoke","from":"worker","to":"delegate","method":"pledge","params":["worker","worker","10","10"]}]`,
+			},
+			cli.StringFlag{
+				Name:  "path, p",
+				Usage: "json file of actions",
+			},
+		},
+		Action: sendActions,
+		OnUsageError: func(c *cli.Context, err error, isSubcommand bool) error {
+			return cli.NewExitError("", 1)
+		},
+	}
+)
+
+func sendActions(c *cli.Context) error {
+	//Check the number of flags
+	if c.NumFlags() == 0 {
+		cli.ShowSubcommandHelp(c)
+		return nil
+	}
+
+	from := c.String("from")
+	if from == "" {
+		fmt.Println("Invalid sender name: ", from)
+		return errors.New("Invalid sender name")
+	}
+
+	actions := c.String("actions")
+	if path := c.String("path"); path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Println("read file failed:", err)
+			return errors.New("read file failed: " + path)
+		}
+		actions = string(data)
+	}
+	if actions == "" {
+		fmt.Println("Invalid actions")
+		return errors.New("Invalid actions")
+	}
+
+	resp, err := rpc.Call("sendActions", []interface{}{from, actions})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return err
+	}
+
+	//result
+	return rpc.EchoResult(resp)
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/ecoball/go-ecoball/client/rpc"
 	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/types"
 	"github.com/urfave/cli"
 )
 
@@ -52,6 +53,10 @@ var (
 						Name:  "description, d",
 						Usage: "contract description",
 					},
+					cli.StringFlag{
+						Name:  "abi, a",
+						Usage: "contract abi file path",
+					},
 				},
 			},
 			{
@@ -69,10 +74,30 @@ var (
 					},
 					cli.StringFlag{
 						Name:  "param, p",
-						Usage: "method parameters",
+						Usage: "method parameters, separated by space or a json array of strings",
+					},
+				},
+			},
+			{
+				Name:   "call",
+				Usage:  "execute contract on the head state without sending a transaction, the state is not changed",
+				Action: callContract,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "name, n",
+						Usage: "contract name",
+					},
+					cli.StringFlag{
+						Name:  "method, m",
+						Usage: "contract method",
+					},
+					cli.StringFlag{
+						Name:  "param, p",
+						Usage: "method parameters, separated by space or a json array of strings",
 					},
 				},
 			},
+			debugCommand,
 		},
 	}
 )
@@ -119,8 +144,23 @@ func setContract(c *cli.Context) error {
 		return errors.New("Invalid contract description")
 	}
 
+	//contract abi
+	var abi string
+	if abiFile := c.String("abi"); abiFile != "" {
+		abiData, err := ioutil.ReadFile(abiFile)
+		if err != nil {
+			fmt.Println("read contract abi file err: ", err.Error())
+			return err
+		}
+		if _, err := types.NewAbi(abiData); err != nil {
+			fmt.Println("Invalid contract abi: ", err.Error())
+			return err
+		}
+		abi = string(abiData)
+	}
+
 	//rpc call
-	resp, err := rpc.Call("setContract", []interface{}{common.ToHex(data), contractName, description})
+	resp, err := rpc.Call("setContract", []interface{}{common.ToHex(data), contractName, description, abi})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		return err
@@ -173,3 +213,33 @@ func invokeContract(c *cli.Context) error {
 	//result
 	return rpc.EchoResult(resp)
 }
+
+func callContract(c *cli.Context) error {
+	//Check the number of flags
+	if c.NumFlags() == 0 {
+		cli.ShowSubcommandHelp(c)
+		return nil
+	}
+
+	contractName := c.String("name")
+	if contractName == "" {
+		fmt.Println("Invalid contract name: ", contractName)
+		return errors.New("Invalid contract name")
+	}
+
+	contractMethod := c.String("method")
+	if contractMethod == "" {
+		fmt.Println("Invalid contract method: ", contractMethod)
+		return errors.New("Invalid contract method")
+	}
+
+	//rpc call
+	resp, err := rpc.Call("queryContract", []interface{}{contractName, contractMethod, c.String("param")})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return err
+	}
+
+	//result
+	return rpc.EchoResult(resp)
+}
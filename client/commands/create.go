@@ -4,9 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
-	"github.com/ecoball/go-ecoball/client/rpc"
 	innercommon "github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/types"
 	"github.com/urfave/cli"
 )
 
@@ -37,6 +38,10 @@ var (
 						Name:  "active, a",
 						Usage: "active public key",
 					},
+					cli.StringFlag{
+						Name:  "key, k",
+						Usage: "public key of the open wallet signing the creation, the first key if it is empty",
+					},
 				},
 			},
 		},
@@ -82,13 +87,33 @@ func newAccount(c *cli.Context) error {
 		active = owner
 	}
 
-	//rpc call
-	resp, err := rpc.Call("createAccount", []interface{}{creator, name, owner, active})
+	key, err := walletKey(c.String("key"))
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	//the creation is built and signed by the client for the chain of node
+	creatorAccount := innercommon.NameToIndex(creator)
+	nonce, err := queryNonce(creator)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return err
+	}
+	invoke, err := types.NewInvokeContract(creatorAccount, creatorAccount, "owner", "new_account",
+		[]string{name, innercommon.AddressFromPubKey(innercommon.FromHex(owner)).HexString()}, nonce, time.Now().Unix())
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	hash, err := signAndSend(invoke, key)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		return err
 	}
 
 	//result
-	return rpc.EchoResult(resp)
+	fmt.Println("success!")
+	fmt.Println(hash)
+	return nil
 }
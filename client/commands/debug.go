@@ -0,0 +1,283 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/ledgerimpl/transaction"
+	"github.com/ecoball/go-ecoball/core/store"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/disasm"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/exec"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/wasm"
+	ops "github.com/ecoball/go-ecoball/vm/wasmvm/wasm/operators"
+	"github.com/urfave/cli"
+)
+
+var debugCommand = cli.Command{
+	Name:   "debug",
+	Usage:  "replay a stored transaction offline and debug its contracts, the node must be stopped",
+	Action: debugContract,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "tx, t",
+			Usage: "transaction hash",
+		},
+		cli.StringFlag{
+			Name:  "db",
+			Value: store.PathBlock,
+			Usage: "block chain database path of node",
+		},
+		cli.StringSliceFlag{
+			Name:  "break, b",
+			Usage: "breakpoint on a function index like 3, or on a byte offset of function body like 3:0x1a",
+		},
+		cli.BoolFlag{
+			Name:  "trace",
+			Usage: "print every executed instruction",
+		},
+	},
+}
+
+func debugContract(c *cli.Context) error {
+	hash := c.String("tx")
+	if hash == "" {
+		fmt.Println("Invalid transaction hash: ", hash)
+		return errors.New("Invalid transaction hash")
+	}
+	d, err := newDebugger(c.StringSlice("break"), c.Bool("trace"))
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	chain, err := transaction.NewTransactionChain(c.String("db"), nil)
+	if err != nil {
+		fmt.Println("open block chain database failed:", err)
+		return err
+	}
+	receipt, err := chain.ReplayTransaction(common.HexToHash(hash), d)
+	if d.quit {
+		fmt.Println("debugging is quit")
+		return nil
+	}
+	if err != nil {
+		fmt.Println("replay transaction failed:", err)
+		return err
+	}
+	fmt.Println("receipt:", receipt.JsonString())
+	return nil
+}
+
+//errDebugQuit traps the execution when debugging is quit, as the vm traps with exec.ErrOutOfGas
+var errDebugQuit = errors.New("debugging is quit")
+
+//breakpoint on the entry of a function if offset is -1, else on the instruction at the byte offset of function body
+type breakpoint struct {
+	function int64
+	offset   int
+}
+
+type debugger struct {
+	breakpoints []breakpoint
+	trace       bool
+	step        bool
+	quit        bool
+	input       *bufio.Reader
+	code        map[int64]map[int]disasm.Instr
+}
+
+func newDebugger(breaks []string, trace bool) (*debugger, error) {
+	d := &debugger{trace: trace, input: bufio.NewReader(os.Stdin), code: make(map[int64]map[int]disasm.Instr)}
+	for _, b := range breaks {
+		bp := breakpoint{offset: -1}
+		fields := strings.SplitN(b, ":", 2)
+		fn, err := strconv.ParseInt(fields[0], 0, 64)
+		if err != nil {
+			return nil, errors.New("invalid breakpoint: " + b)
+		}
+		bp.function = fn
+		if len(fields) == 2 {
+			offset, err := strconv.ParseInt(fields[1], 0, 32)
+			if err != nil {
+				return nil, errors.New("invalid breakpoint: " + b)
+			}
+			bp.offset = int(offset)
+		}
+		d.breakpoints = append(d.breakpoints, bp)
+	}
+	return d, nil
+}
+
+func (d *debugger) hit(vm *exec.VM) bool {
+	for _, bp := range d.breakpoints {
+		if bp.function != vm.CurrentFunction() {
+			continue
+		}
+		if (bp.offset < 0 && vm.PC() == 0) || (bp.offset >= 0 && bp.offset == vm.Offset()) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ *  @brief the instruction at the byte offset of function body, the function is disassembled on first use
+ */
+func (d *debugger) instruction(vm *exec.VM, fn int64, offset int) (disasm.Instr, bool) {
+	code, ok := d.code[fn]
+	if !ok {
+		code = make(map[int]disasm.Instr)
+		module := vm.Module()
+		if int(fn) < len(module.FunctionIndexSpace) {
+			if dis, err := disasm.Disassemble(module.FunctionIndexSpace[fn], module); err == nil {
+				for _, instr := range dis.Code {
+					code[instr.Offset] = instr
+				}
+			}
+		}
+		d.code[fn] = code
+	}
+	instr, ok := code[offset]
+	return instr, ok
+}
+
+func (d *debugger) show(vm *exec.VM, op byte) {
+	fn, offset := vm.CurrentFunction(), vm.Offset()
+	if instr, ok := d.instruction(vm, fn, offset); ok {
+		fmt.Printf("func %d @0x%04x  %s %v\n", fn, offset, instr.Op.Name, instr.Immediates)
+		return
+	}
+	name := fmt.Sprintf("0x%02x", op)
+	if o, err := ops.New(op); err == nil {
+		name = o.Name
+	}
+	fmt.Printf("func %d pc %d  %s\n", fn, vm.PC(), name)
+}
+
+func (d *debugger) CaptureOp(vm *exec.VM, op byte) {
+	stop := d.step || d.hit(vm)
+	if !stop && !d.trace {
+		return
+	}
+	d.show(vm, op)
+	if !stop {
+		return
+	}
+	fmt.Println("  stack: ", vm.Stack())
+	fmt.Println("  locals:", vm.Locals())
+	for {
+		fmt.Print("(debug) ")
+		line, err := d.input.ReadString('\n')
+		if err != nil {
+			d.step = false
+			return
+		}
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			args = []string{"s"}
+		}
+		switch args[0] {
+		case "s", "step":
+			d.step = true
+			return
+		case "c", "continue":
+			d.step = false
+			return
+		case "m", "memory":
+			d.showMemory(vm, args[1:])
+		case "l", "list":
+			d.list(vm)
+		case "q", "quit":
+			d.quit = true
+			panic(errDebugQuit)
+		default:
+			fmt.Println("s(tep) | c(ontinue) | m(emory) <offset> <length> | l(ist) | q(uit)")
+		}
+	}
+}
+
+func (d *debugger) showMemory(vm *exec.VM, args []string) {
+	if len(args) != 2 {
+		fmt.Println("usage: m <offset> <length>")
+		return
+	}
+	offset, err1 := strconv.ParseUint(args[0], 0, 32)
+	length, err2 := strconv.ParseUint(args[1], 0, 32)
+	memory := vm.Memory()
+	if err1 != nil || err2 != nil || offset+length > uint64(len(memory)) {
+		fmt.Println("invalid memory range, the size of memory is", len(memory))
+		return
+	}
+	fmt.Printf("  mem[0x%x]: %x\n", offset, memory[offset:offset+length])
+}
+
+//list the instructions of current function, the current one is marked with '>'
+func (d *debugger) list(vm *exec.VM) {
+	fn, offset := vm.CurrentFunction(), vm.Offset()
+	module := vm.Module()
+	if int(fn) >= len(module.FunctionIndexSpace) {
+		return
+	}
+	dis, err := disasm.Disassemble(module.FunctionIndexSpace[fn], module)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, instr := range dis.Code {
+		mark := " "
+		if instr.Offset == offset {
+			mark = ">"
+		}
+		fmt.Printf("%s 0x%04x  %s %v\n", mark, instr.Offset, instr.Op.Name, instr.Immediates)
+	}
+}
+
+func (d *debugger) CaptureMemoryWrite(vm *exec.VM, offset uint32, data []byte) {
+	if d.trace || d.step {
+		fmt.Printf("  mem[0x%x] <- %x\n", offset, data)
+	}
+}
+
+func (d *debugger) CaptureHostCall(vm *exec.VM, index int64, args, rets []uint64) {
+	if d.trace || d.step {
+		fmt.Printf("  host %s%v -> %v\n", hostName(vm.Module(), index), args, rets)
+	}
+}
+
+//the imported functions are at the start of the function index space in the order of import entries
+func hostName(module *wasm.Module, index int64) string {
+	if module.Import != nil {
+		var i int64
+		for _, entry := range module.Import.Entries {
+			if entry.Kind != wasm.ExternalFunction {
+				continue
+			}
+			if i == index {
+				return entry.FieldName
+			}
+			i++
+		}
+	}
+	return fmt.Sprintf("func %d", index)
+}
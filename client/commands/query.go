@@ -17,6 +17,7 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -41,6 +42,22 @@ var (
 					},
 				},
 			},
+			{
+				Name:   "chain",
+				Usage:  "query the chain ID and head block which new transactions are bound to",
+				Action: queryChain,
+			},
+			{
+				Name:   "tx",
+				Usage:  "query a packed transaction with its block, receipt and merkle proof",
+				Action: queryTransaction,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "hash",
+						Usage: "transaction hash",
+					},
+				},
+			},
 		},
 	}
 )
@@ -68,3 +85,33 @@ func queryBalance(c *cli.Context) error {
 	//result
 	return rpc.EchoResult(resp)
 }
+
+func queryChain(c *cli.Context) error {
+	//rpc call
+	resp, err := rpc.Call("getChainInfo", []interface{}{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return err
+	}
+
+	//result
+	return rpc.EchoResult(resp)
+}
+
+func queryTransaction(c *cli.Context) error {
+	hash := c.String("hash")
+	if hash == "" {
+		fmt.Println("Invalid transaction hash: ", hash)
+		return errors.New("Invalid transaction hash")
+	}
+
+	//rpc call
+	resp, err := rpc.Call("getTransaction", []interface{}{hash})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return err
+	}
+
+	//result
+	return rpc.EchoResult(resp)
+}
@@ -0,0 +1,112 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/ecoball/go-ecoball/account"
+	"github.com/ecoball/go-ecoball/client/rpc"
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/types"
+	innerCommon "github.com/ecoball/go-ecoball/http/common"
+)
+
+//call the method of node and return the result, error if the node fails
+func callResult(method string, params []interface{}) (interface{}, error) {
+	resp, err := rpc.Call(method, params)
+	if err != nil {
+		return nil, err
+	}
+	if code, ok := resp["errorCode"].(float64); !ok || int64(code) != int64(innerCommon.SUCCESS) {
+		desc, _ := resp["desc"].(string)
+		return nil, errors.New(method + " failed: " + desc)
+	}
+	return resp["result"], nil
+}
+
+//the nonce of the next transaction of account queried from node
+func queryNonce(name string) (uint64, error) {
+	result, err := callResult("getNonce", []interface{}{name})
+	if err != nil {
+		return 0, err
+	}
+	nonce, ok := result.(float64)
+	if !ok {
+		return 0, errors.New("invalid nonce")
+	}
+	return uint64(nonce), nil
+}
+
+/**
+ *  @brief the key of the open wallet to sign transactions
+ *  @param publicKey - the hex string of public key, the first key of wallet if it is empty
+ */
+func walletKey(publicKey string) (*account.Account, error) {
+	if account.Wallet == nil {
+		return nil, errors.New("The wallet has not been opened!")
+	}
+	if account.Wallet.CheckLocked() {
+		return nil, errors.New("The wallet has been locked!")
+	}
+	for i, ac := range account.Wallet.Accounts {
+		if publicKey == "" || bytes.Equal(ac.PublicKey, common.FromHex(publicKey)) {
+			return &account.Wallet.Accounts[i], nil
+		}
+	}
+	return nil, errors.New("the key is not in the wallet")
+}
+
+/**
+ *  @brief bind the transaction to the chain ID and head block queried from node, sign it by the key and send it
+ *  to the node, so the signature is only valid on the chain of node
+ *  @return the hash of transaction
+ */
+func signAndSend(tx *types.Transaction, key *account.Account) (string, error) {
+	result, err := callResult("getChainInfo", []interface{}{})
+	if err != nil {
+		return "", err
+	}
+	info, ok := result.(map[string]interface{})
+	if !ok {
+		return "", errors.New("invalid chain info")
+	}
+	chainID, _ := info["chainId"].(string)
+	headHash, _ := info["headHash"].(string)
+	height, ok := info["height"].(float64)
+	if !ok || chainID == "" || headHash == "" {
+		return "", errors.New("invalid chain info")
+	}
+	if err := tx.SetChainReference(common.HexToHash(chainID), uint64(height), common.HexToHash(headHash), time.Now().Unix()+types.DefaultTxLifetime); err != nil {
+		return "", err
+	}
+	if err := tx.SetSignature(key); err != nil {
+		return "", err
+	}
+	data, err := tx.Serialize()
+	if err != nil {
+		return "", err
+	}
+	result, err = callResult("sendRawTransaction", []interface{}{common.ToHex(data)})
+	if err != nil {
+		return "", err
+	}
+	hash, _ := result.(string)
+	return hash, nil
+}
@@ -0,0 +1,262 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ecoball/go-ecoball/client/rpc"
+	"github.com/urfave/cli"
+)
+
+var (
+	symbolFlag = cli.StringFlag{
+		Name:  "symbol, s",
+		Usage: "token symbol",
+	}
+	amountFlag = cli.Uint64Flag{
+		Name:  "amount, a",
+		Usage: "token amount in the smallest unit",
+	}
+
+	TokenCommands = cli.Command{
+		Name:        "token",
+		Usage:       "token operate",
+		Category:    "Token",
+		Description: "With ecoclient token, you could create, issue, transfer and retire tokens by the token contract",
+		ArgsUsage:   "[args]",
+		Subcommands: []cli.Command{
+			{
+				Name:   "create",
+				Usage:  "register a new token",
+				Action: createToken,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "issuer, i",
+						Usage: "the account which can issue the token",
+					},
+					symbolFlag,
+					cli.Uint64Flag{
+						Name:  "precision, p",
+						Usage: "the number of decimal places",
+					},
+					cli.Uint64Flag{
+						Name:  "max, m",
+						Usage: "the maximum supply in the smallest unit",
+					},
+				},
+			},
+			{
+				Name:   "issue",
+				Usage:  "issue token to an account",
+				Action: issueToken,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "issuer, i",
+						Usage: "the issuer of token",
+					},
+					symbolFlag,
+					cli.StringFlag{
+						Name:  "to, t",
+						Usage: "the account receives the token",
+					},
+					amountFlag,
+				},
+			},
+			{
+				Name:   "transfer",
+				Usage:  "transfer token to another account",
+				Action: transferToken,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "from, f",
+						Usage: "sender account",
+					},
+					cli.StringFlag{
+						Name:  "to, t",
+						Usage: "receiver account",
+					},
+					symbolFlag,
+					amountFlag,
+				},
+			},
+			{
+				Name:   "retire",
+				Usage:  "destroy token of an account, the supply is reduced",
+				Action: retireToken,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "owner, o",
+						Usage: "the account holds the token",
+					},
+					symbolFlag,
+					amountFlag,
+				},
+			},
+			{
+				Name:   "close",
+				Usage:  "remove the token of zero balance from an account",
+				Action: closeBalance,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "owner, o",
+						Usage: "the account holds the token",
+					},
+					symbolFlag,
+				},
+			},
+			{
+				Name:   "info",
+				Usage:  "show the issuer, precision and supply of token",
+				Action: tokenInfo,
+				Flags: []cli.Flag{
+					symbolFlag,
+				},
+			},
+		},
+	}
+)
+
+//invoke an action of the token contract, the arguments are sent in a json array
+func invokeToken(method string, args ...string) error {
+	param, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	resp, err := rpc.Call("invokeContract", []interface{}{"token", method, string(param)})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return err
+	}
+
+	//result
+	return rpc.EchoResult(resp)
+}
+
+//check the string flags are set
+func requireFlags(c *cli.Context, names ...string) error {
+	for _, name := range names {
+		if c.String(name) == "" {
+			fmt.Println("Invalid " + name + ": " + c.String(name))
+			return errors.New("Invalid " + name)
+		}
+	}
+	return nil
+}
+
+func requireAmount(c *cli.Context) (string, error) {
+	amount := c.Uint64("amount")
+	if amount == 0 {
+		fmt.Println("Invalid token amount: ", amount)
+		return "", errors.New("Invalid token amount")
+	}
+	return strconv.FormatUint(amount, 10), nil
+}
+
+func createToken(c *cli.Context) error {
+	if c.NumFlags() == 0 {
+		cli.ShowSubcommandHelp(c)
+		return nil
+	}
+	if err := requireFlags(c, "issuer", "symbol"); err != nil {
+		return err
+	}
+	max := c.Uint64("max")
+	if max == 0 {
+		fmt.Println("Invalid maximum supply: ", max)
+		return errors.New("Invalid maximum supply")
+	}
+	return invokeToken("create", c.String("issuer"), c.String("symbol"), strconv.FormatUint(c.Uint64("precision"), 10), strconv.FormatUint(max, 10))
+}
+
+func issueToken(c *cli.Context) error {
+	if c.NumFlags() == 0 {
+		cli.ShowSubcommandHelp(c)
+		return nil
+	}
+	if err := requireFlags(c, "issuer", "symbol", "to"); err != nil {
+		return err
+	}
+	amount, err := requireAmount(c)
+	if err != nil {
+		return err
+	}
+	return invokeToken("issue", c.String("issuer"), c.String("symbol"), c.String("to"), amount)
+}
+
+func transferToken(c *cli.Context) error {
+	if c.NumFlags() == 0 {
+		cli.ShowSubcommandHelp(c)
+		return nil
+	}
+	if err := requireFlags(c, "from", "to", "symbol"); err != nil {
+		return err
+	}
+	amount, err := requireAmount(c)
+	if err != nil {
+		return err
+	}
+	return invokeToken("transfer", c.String("from"), c.String("to"), c.String("symbol"), amount)
+}
+
+func retireToken(c *cli.Context) error {
+	if c.NumFlags() == 0 {
+		cli.ShowSubcommandHelp(c)
+		return nil
+	}
+	if err := requireFlags(c, "owner", "symbol"); err != nil {
+		return err
+	}
+	amount, err := requireAmount(c)
+	if err != nil {
+		return err
+	}
+	return invokeToken("retire", c.String("owner"), c.String("symbol"), amount)
+}
+
+func closeBalance(c *cli.Context) error {
+	if c.NumFlags() == 0 {
+		cli.ShowSubcommandHelp(c)
+		return nil
+	}
+	if err := requireFlags(c, "owner", "symbol"); err != nil {
+		return err
+	}
+	return invokeToken("close", c.String("owner"), c.String("symbol"))
+}
+
+func tokenInfo(c *cli.Context) error {
+	if c.NumFlags() == 0 {
+		cli.ShowSubcommandHelp(c)
+		return nil
+	}
+	if err := requireFlags(c, "symbol"); err != nil {
+		return err
+	}
+	resp, err := rpc.Call("getTokenInfo", []interface{}{c.String("symbol")})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return err
+	}
+
+	//result
+	return rpc.EchoResult(resp)
+}
@@ -19,9 +19,12 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"os"
+	"time"
 
-	"github.com/ecoball/go-ecoball/client/rpc"
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/types"
 
 	"github.com/urfave/cli"
 )
@@ -46,6 +49,10 @@ var (
 				Name:  "value, v",
 				Usage: "ABA amount",
 			},
+			cli.StringFlag{
+				Name:  "key, k",
+				Usage: "public key of the open wallet signing the transfer, the first key if it is empty",
+			},
 		},
 		Action: transferAction,
 		OnUsageError: func(c *cli.Context, err error, isSubcommand bool) error {
@@ -79,12 +86,31 @@ func transferAction(c *cli.Context) error {
 		return errors.New("Invalid aba amount")
 	}
 
-	resp, err := rpc.Call("transfer", []interface{}{from, to, value})
+	key, err := walletKey(c.String("key"))
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	//the transfer is built and signed by the client for the chain of node
+	nonce, err := queryNonce(from)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return err
+	}
+	tx, err := types.NewTransfer(common.NameToIndex(from), common.NameToIndex(to), "active", big.NewInt(value), nonce, time.Now().Unix())
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	hash, err := signAndSend(tx, key)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		return err
 	}
 
 	//result
-	return rpc.EchoResult(resp)
+	fmt.Println("success!")
+	fmt.Println(hash)
+	return nil
 }
@@ -18,9 +18,6 @@ package common
 
 import (
 	"encoding/hex"
-	"fmt"
-	"strconv"
-	"unsafe"
 	"encoding/binary"
 )
 
@@ -122,19 +119,6 @@ func LeftPadBytes(slice []byte, l int) []byte {
 	return padded
 }
 
-func StringToPointer(str string) (uint64, error) {
-	strPointerInt := fmt.Sprintf("%d", unsafe.Pointer(&str))
-	return strconv.ParseUint(strPointerInt, 10, 0)
-}
-
-func PointerToString(pointer uint64) string {
-	var s *string
-	s = *(**string)(unsafe.Pointer(&pointer))
-	str := *(*string)(unsafe.Pointer(s))
-	b := CopyBytes([]byte(str))
-	return string(b)
-}
-
 func Uint64ToBytes(value uint64) []byte {
 	b := make([]byte, 8)
 	binary.BigEndian.PutUint64(b, value)
@@ -37,6 +37,7 @@ const (
 	StringBlock     = "/Block"
 	StringHeader    = "/Header"
 	StringTxs       = "/Txs"
+	StringReceipts  = "/Receipts"
 	StringContract  = "/Contract"
 	StringState     = "/State"
 	StringConsensus = "/Consensus"
@@ -79,6 +80,10 @@ delegate_pubkey  = "0x04945b2581d8f912688a9a9aad151660211ac1828b611631f14a837aea
 peer_list = [ "120202c924ed1a67fd1719020ce599d723d09d48362376836e04b0be72dfe825e24d810000", 
               "120202935fb8d28b70706de6014a937402a30ae74a56987ed951abbe1ac9eeda56f0160000" ]
 peer_index = [ "1", "2" ]
+
+wasm_module_cache = 128      # the number of compiled contract modules kept in memory
+
+state_retention = 0          # the number of recent block states kept on disk, 0 keeps all of them (archive)
 `
 
 var (
@@ -93,6 +98,8 @@ var (
 	Worker1            account.Account
 	Worker2            account.Account
 	Worker3            account.Account
+	WasmModuleCache    int
+	StateRetention     int
 )
 
 type Config struct {
@@ -175,4 +182,10 @@ func initVariable() {
 	Delegate = account.Account{PrivateKey: common.FromHex(viper.GetString("delegate_privkey")), PublicKey: common.FromHex(viper.GetString("delegate_pubkey")), Alg: 0}
 	PeerList = viper.GetStringSlice(ListPeers)
 	PeerIndex = viper.GetStringSlice(IndexPeers)
+
+	viper.SetDefault("wasm_module_cache", 128)
+	WasmModuleCache = viper.GetInt("wasm_module_cache")
+
+	viper.SetDefault("state_retention", 0)
+	StateRetention = viper.GetInt("state_retention")
 }
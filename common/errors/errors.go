@@ -28,6 +28,12 @@ const (
 	ErrNoAccount            ErrCode = 45014
 	ErrRetryExhausted       ErrCode = 45015
 	ErrTxPoolFull           ErrCode = 45016
+	ErrTxExpired            ErrCode = 45017
+	ErrInvalidRefBlock      ErrCode = 45018
+	ErrNonceTooLow          ErrCode = 45019
+	ErrChainIdMismatch      ErrCode = 45020
+	ErrTxNotReferenced      ErrCode = 45021
+	ErrTxChargeFailed       ErrCode = 45022
 )
 
 func (err ErrCode) ErrorInfo() string {
@@ -66,6 +72,18 @@ func (err ErrCode) ErrorInfo() string {
 		return "retry exhausted"
 	case ErrTxPoolFull:
 		return "tx pool full"
+	case ErrTxExpired:
+		return "transaction expired"
+	case ErrInvalidRefBlock:
+		return "reference block is not in the chain"
+	case ErrNonceTooLow:
+		return "nonce too low"
+	case ErrChainIdMismatch:
+		return "chain id mismatch"
+	case ErrTxNotReferenced:
+		return "transaction has no reference block"
+	case ErrTxChargeFailed:
+		return "resources of transaction can't be charged"
 	}
 
 	return fmt.Sprintf("Unknown error? Error code = %d", err)
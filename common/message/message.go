@@ -1,5 +1,7 @@
 package message
 
+import "github.com/ecoball/go-ecoball/common"
+
 type GetTxs struct{}
 
 type GetCurrentHeader struct{}
@@ -7,3 +9,19 @@ type GetCurrentHeader struct{}
 type GetTransaction struct {
 	Key []byte
 }
+
+type GetContract struct {
+	Index common.AccountName
+}
+
+type GetReceipt struct {
+	Key []byte
+}
+
+type GetNonce struct {
+	Index common.AccountName
+}
+
+type GetTxInclusion struct {
+	Hash common.Hash
+}
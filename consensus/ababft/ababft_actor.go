@@ -472,7 +472,7 @@ func (actor_c *Actor_ababft) Receive(ctx actor.Context) {
 					// 3. check the txs
 					txs_in := blockfirst_received.Transactions
 					for index1,tx_in := range txs_in {
-						err = actor_c.service_ababft.ledger.CheckTransaction(tx_in)
+						err = actor_c.service_ababft.ledger.CheckBlockTransaction(tx_in, blockfirst_received.Header.TimeStamp)
 						if err != nil {
 							println("wrong tx, index:", index1)
 							return
@@ -951,8 +951,8 @@ func (actor_c *Actor_ababft) verify_header(block_in *types.Block, current_round_
 		return false,nil
 	}
 	// check Hash common.Hash
-	header_cal,err1 := types.NewHeader(header_in.Version, header_in.Height, header_in.PrevHash,
-		header_in.MerkleHash, header_in.StateHash, header_in.ConsensusData, header_in.Bloom, header_in.TimeStamp)
+	header_cal,err1 := types.NewHeader(header_in.Version, header_in.ChainID, header_in.Height, header_in.PrevHash,
+		header_in.MerkleHash, header_in.StateHash, header_in.ReceiptHash, header_in.ConsensusData, header_in.Bloom, header_in.TimeStamp)
 	if ok := bytes.Equal(header_cal.Hash.Bytes(),header_in.Hash.Bytes()); ok != true {
 		println("Hash is wrong")
 		return false,err1
@@ -974,8 +974,8 @@ func (actor_c *Actor_ababft) update_block(block_first types.Block, condata types
 	var block_second types.Block
 	var err error
 	header_in := block_first.Header
-	header, _ := types.NewHeader(header_in.Version, header_in.Height, header_in.PrevHash, header_in.MerkleHash,
-		header_in.StateHash, condata, header_in.Bloom, header_in.TimeStamp)
+	header, _ := types.NewHeader(header_in.Version, header_in.ChainID, header_in.Height, header_in.PrevHash, header_in.MerkleHash,
+		header_in.StateHash, header_in.ReceiptHash, condata, header_in.Bloom, header_in.TimeStamp)
 	block_second = types.Block{header, uint32(len(block_first.Transactions)), block_first.Transactions}
 	return block_second,err
 }
@@ -1040,8 +1040,8 @@ func (actor_c *Actor_ababft) verify_signatures(data_blks_received *types.AbaBftD
 	num_verified = 0
 	// calculate firstround block header hash for the check of the first-round block signatures
 	conData := types.ConsensusData{Type: types.ConABFT, Payload: &types.AbaBftData{uint32(current_round_num),sign_blks_preblk}}
-	header_recal, _ := types.NewHeader(curheader.Version, curheader.Height, curheader.PrevHash, curheader.MerkleHash,
-		curheader.StateHash, conData, curheader.Bloom, curheader.TimeStamp)
+	header_recal, _ := types.NewHeader(curheader.Version, curheader.ChainID, curheader.Height, curheader.PrevHash, curheader.MerkleHash,
+		curheader.StateHash, curheader.ReceiptHash, conData, curheader.Bloom, curheader.TimeStamp)
 	blkFhash := header_recal.Hash
 	for index,sign_curblk := range sign_blks_curblk {
 		// 3a. check the peers in the peer list
@@ -1087,8 +1087,8 @@ func (actor_c *Actor_ababft) verify_signatures(data_blks_received *types.AbaBftD
 	num_verified = 0
 	// calculate firstround block header hash for the check of the first-round block signatures
 	conData := types.ConsensusData{Type: types.ConABFT, Payload: &types.AbaBftData{uint32(current_round_num),sign_blks_preblk}}
-	header_recal, _ := types.NewHeader(curheader.Version, curheader.Height, curheader.PrevHash, curheader.MerkleHash,
-		curheader.StateHash, conData, curheader.Bloom, curheader.TimeStamp)
+	header_recal, _ := types.NewHeader(curheader.Version, curheader.ChainID, curheader.Height, curheader.PrevHash, curheader.MerkleHash,
+		curheader.StateHash, curheader.ReceiptHash, conData, curheader.Bloom, curheader.TimeStamp)
 	blkFhash := header_recal.Hash
 	for _,sign_curblk := range sign_blks_curblk {
 		// 4b. verify the correctness of the signature
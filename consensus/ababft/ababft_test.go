@@ -97,7 +97,8 @@ func TestABABFTPros(t *testing.T) {
 	event.Send(event.ActorConsensus,event.ActorConsensus,ABABFTStart{})
 	// 5a. create a tx for the block generation later
 	// add 1000ABA to worker1 from worker2
-	transfer_t, err := types.NewTransfer(worker2, worker1, "owner", new(big.Int).SetUint64(800), 400, time.Now().Unix())
+	transfer_t, err := types.NewTransfer(worker2, worker1, "owner", new(big.Int).SetUint64(800), nextNonce(l, worker2, t), time.Now().Unix())
+	reference(l, transfer_t, t)
 	transfer_t.SetSignature(&config.Worker2)
 	event.Send(event.ActorNil, event.ActorTxPool,transfer_t)
 	log.Debug("create one tx for tx pool",transfer_t)
@@ -173,11 +174,28 @@ func ShowAccountInfo(l ledger.Ledger, t *testing.T) {
 	acc.Show()
 }
 
+//the nonce of the next transaction of account, the transactions of a sender in one block follow it in order
+func nextNonce(l ledger.Ledger, index common.AccountName, t *testing.T) uint64 {
+	nonce, err := l.StateDB().GetNonce(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nonce
+}
+
+//bind the transaction to the head of chain, it must be called before signing
+func reference(l ledger.Ledger, tx *types.Transaction, t *testing.T) {
+	if err := tx.SetReference(l.GetCurrentHeader(), time.Now().Unix()+100); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func CreateAccountBlock(ledger ledger.Ledger, con *types.ConsensusData, t *testing.T) {
 	//TODO
 	var txs []*types.Transaction
 
 	index := common.NameToIndex("root")
+	nonce := nextNonce(ledger, index, t)
 	if err := ledger.AccountAddBalance(index, state.AbaToken, 10000); err != nil {
 		t.Fatal(err)
 	}
@@ -187,27 +205,31 @@ func CreateAccountBlock(ledger ledger.Ledger, con *types.ConsensusData, t *testi
 		t.Fatal(err)
 	}
 	log.Debug("load wasm ok")
-	tokenContract, err := types.NewDeployContract(index, index, state.Active, types.VmWasm, "system control", code, 0, time.Now().Unix())
+	tokenContract, err := types.NewDeployContract(index, index, state.Active, types.VmWasm, "system control", code, nil, nonce, time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, tokenContract, t)
 	if err := tokenContract.SetSignature(&config.Root); err != nil {
 		t.Fatal(err)
 	}
 	txs = append(txs, tokenContract)
 
 	invoke, err := types.NewInvokeContract(index, index, state.Owner,"new_account",
-		[]string{"worker1", common.AddressFromPubKey(config.Worker1.PublicKey).HexString()}, 0, time.Now().Unix())
+		[]string{"worker1", common.AddressFromPubKey(config.Worker1.PublicKey).HexString()}, nonce+1, time.Now().Unix())
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Root)
 	txs = append(txs, invoke)
 
 	invoke, err = types.NewInvokeContract(index, index, state.Owner, "new_account",
-		[]string{"worker2", common.AddressFromPubKey(config.Worker2.PublicKey).HexString()}, 1, time.Now().Unix())
+		[]string{"worker2", common.AddressFromPubKey(config.Worker2.PublicKey).HexString()}, nonce+2, time.Now().Unix())
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Root)
 	txs = append(txs, invoke)
 
 	invoke, err = types.NewInvokeContract(index, index, state.Owner, "new_account",
-		[]string{"worker3", common.AddressFromPubKey(config.Worker3.PublicKey).HexString()}, 2, time.Now().Unix())
+		[]string{"worker3", common.AddressFromPubKey(config.Worker3.PublicKey).HexString()}, nonce+3, time.Now().Unix())
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Root)
 	txs = append(txs, invoke)
 
@@ -216,20 +238,24 @@ func CreateAccountBlock(ledger ledger.Ledger, con *types.ConsensusData, t *testi
 	if err != nil {
 		t.Fatal(err)
 	}
-	invoke, err = types.NewInvokeContract(index, index, state.Active, "set_account", []string{"root", string(param)}, 0, time.Now().Unix())
+	invoke, err = types.NewInvokeContract(index, index, state.Active, "set_account", []string{"root", string(param)}, nonce+4, time.Now().Unix())
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Root)
 	txs = append(txs, invoke)
 
 	// add 1000ABA to worker1 from root
-	transfer1, err := types.NewTransfer(root, worker1, "owner", new(big.Int).SetUint64(1000), 100, time.Now().Unix())
+	transfer1, err := types.NewTransfer(root, worker1, "owner", new(big.Int).SetUint64(1000), nonce+5, time.Now().Unix())
+	reference(ledger, transfer1, t)
 	transfer1.SetSignature(&config.Root)
 	txs = append(txs,transfer1)
 	// add 2000ABA to worker2 from root
-	transfer2, err := types.NewTransfer(root, worker2, "owner", new(big.Int).SetUint64(2000), 200, time.Now().Unix())
+	transfer2, err := types.NewTransfer(root, worker2, "owner", new(big.Int).SetUint64(2000), nonce+6, time.Now().Unix())
+	reference(ledger, transfer2, t)
 	transfer2.SetSignature(&config.Root)
 	txs = append(txs,transfer2)
 	// add 3000ABA to worker3 from root
-	transfer3, err := types.NewTransfer(root, worker3, "owner", new(big.Int).SetUint64(3000), 300, time.Now().Unix())
+	transfer3, err := types.NewTransfer(root, worker3, "owner", new(big.Int).SetUint64(3000), nonce+7, time.Now().Unix())
+	reference(ledger, transfer3, t)
 	transfer3.SetSignature(&config.Root)
 	txs = append(txs,transfer3)
 
@@ -247,7 +273,7 @@ func CreateAccountBlock(ledger ledger.Ledger, con *types.ConsensusData, t *testi
 
 	/*
 	var txs1 []*types.Transaction
-		tokenContract1, err := types.NewDeployContract(delegate, delegate, "active", types.VmNative, "system control", nil, 0, time.Now().Unix())
+		tokenContract1, err := types.NewDeployContract(delegate, delegate, "active", types.VmNative, "system control", nil, nil, 0, time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -294,10 +320,11 @@ func CreateAccountBlock(ledger ledger.Ledger, con *types.ConsensusData, t *testi
 func AddTokenAccount(ledger ledger.Ledger, con *types.ConsensusData, t *testing.T) {
 	var txs []*types.Transaction
 	invoke, err := types.NewInvokeContract(root, root, "owner", "new_account",
-		[]string{"token", common.AddressFromPubKey(config.Worker1.PublicKey).HexString()}, 0, time.Now().Unix())
+		[]string{"token", common.AddressFromPubKey(config.Worker1.PublicKey).HexString()}, nextNonce(ledger, root, t), time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Root)
 	txs = append(txs, invoke)
 
@@ -305,18 +332,20 @@ func AddTokenAccount(ledger ledger.Ledger, con *types.ConsensusData, t *testing.
 	if err != nil {
 		t.Fatal(err)
 	}
-	tokenContract, err := types.NewDeployContract(token, token, "active", types.VmWasm, "system control", code, 0, time.Now().Unix())
+	tokenContract, err := types.NewDeployContract(token, token, "active", types.VmWasm, "system control", code, nil, 0, time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, tokenContract, t)
 	tokenContract.SetSignature(&config.Worker1)
 	txs = append(txs, tokenContract)
 
 	invoke, err = types.NewInvokeContract(token, token, "owner", "create",
-		[]string{"token", "aba", "10000"}, 0, time.Now().Unix())
+		[]string{"token", "aba", "10000"}, 1, time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Worker1)
 	txs = append(txs, invoke)
 
@@ -334,30 +363,35 @@ func AddTokenAccount(ledger ledger.Ledger, con *types.ConsensusData, t *testing.
 }
 func PledgeContract(ledger ledger.Ledger, con *types.ConsensusData, t *testing.T) {
 	var txs []*types.Transaction
-	tokenContract, err := types.NewDeployContract(delegate, delegate, "active", types.VmNative, "system control", nil, 0, time.Now().Unix())
+	nonce := nextNonce(ledger, root, t)
+	tokenContract, err := types.NewDeployContract(delegate, delegate, "active", types.VmNative, "system control", nil, nil, nextNonce(ledger, delegate, t), time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
 	log.Debug("delegate account",config.Delegate)
+	reference(ledger, tokenContract, t)
 	tokenContract.SetSignature(&config.Delegate)
 	txs = append(txs, tokenContract)
 
-	invoke, err := types.NewInvokeContract(root, delegate, "owner", "pledge", []string{"root", "worker1", "100", "100"}, 10, time.Now().Unix())
+	invoke, err := types.NewInvokeContract(root, delegate, "owner", "pledge", []string{"root", "worker1", "100", "100"}, nonce, time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Root)
 	txs = append(txs, invoke)
-	invoke, err = types.NewInvokeContract(root, delegate, "owner", "pledge", []string{"root", "worker2", "200", "200"}, 20, time.Now().Unix())
+	invoke, err = types.NewInvokeContract(root, delegate, "owner", "pledge", []string{"root", "worker2", "200", "200"}, nonce+1, time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Root)
 	txs = append(txs, invoke)
-	invoke, err = types.NewInvokeContract(root, delegate, "owner", "pledge", []string{"root", "worker3", "300", "300"}, 30, time.Now().Unix())
+	invoke, err = types.NewInvokeContract(root, delegate, "owner", "pledge", []string{"root", "worker3", "300", "300"}, nonce+2, time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Root)
 	txs = append(txs, invoke)
 
@@ -376,10 +410,11 @@ func PledgeContract(ledger ledger.Ledger, con *types.ConsensusData, t *testing.T
 func CancelPledgeContract(ledger ledger.Ledger, con *types.ConsensusData, t *testing.T) {
 	var txs []*types.Transaction
 	invoke, err := types.NewInvokeContract(root, delegate, "owner", "cancel_pledge",
-		[]string{"root", "worker2", "10", "10"}, 0, time.Now().Unix())
+		[]string{"root", "worker2", "10", "10"}, nextNonce(ledger, root, t), time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Root)
 	txs = append(txs, invoke)
 	block, err := ledger.NewTxBlock(txs, *con)
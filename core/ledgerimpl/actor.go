@@ -76,6 +76,38 @@ func (l *LedActor) Receive(ctx actor.Context) {
 		} else {
 			ctx.Sender().Tell(tx)
 		}
+	case message.GetContract:
+		contract, err := l.ledger.ChainTx.GetContract(msg.Index)
+		if err != nil {
+			log.Error("Get Contract Failed:", err)
+			ctx.Sender().Tell(err)
+		} else {
+			ctx.Sender().Tell(contract)
+		}
+	case message.GetNonce:
+		nonce, err := l.ledger.ChainTx.StateDB.GetNonce(msg.Index)
+		if err != nil {
+			log.Error("Get Nonce Failed:", err)
+			ctx.Sender().Tell(err)
+		} else {
+			ctx.Sender().Tell(nonce)
+		}
+	case message.GetReceipt:
+		receipt, err := l.ledger.ChainTx.GetReceipt(msg.Key)
+		if err != nil {
+			log.Error("Get Receipt Failed:", err)
+			ctx.Sender().Tell(err)
+		} else {
+			ctx.Sender().Tell(receipt)
+		}
+	case message.GetTxInclusion:
+		inclusion, err := l.ledger.ChainTx.GetTransactionInclusion(msg.Hash)
+		if err != nil {
+			log.Error("Get Transaction Inclusion Failed:", err)
+			ctx.Sender().Tell(err)
+		} else {
+			ctx.Sender().Tell(inclusion)
+		}
 	case *types.Block:
 		if err := l.ledger.ChainTx.SaveBlock(msg); err != nil {
 			log.Error("save block error:", err)
@@ -49,7 +49,7 @@ func GenesisBlockInit(ledger ledger.Ledger, timeStamp int64) (*types.Block, erro
 
 
 	hashState := ledger.StateDB().GetHashRoot()
-	header, err := types.NewHeader(types.VersionHeader, 1, hash, hash, hashState, *conData, bloom.Bloom{}, timeStamp)
+	header, err := types.NewHeader(types.VersionHeader, common.Hash{}, 1, hash, hash, hashState, common.Hash{}, *conData, bloom.Bloom{}, timeStamp)
 	if err != nil {
 		return nil, err
 	}
@@ -61,6 +61,12 @@ func GenesisBlockInit(ledger ledger.Ledger, timeStamp int64) (*types.Block, erro
 	return &block, nil
 }*/
 
+// the maximum supply of ABA token
+const abaMaxSupply = 2100000000
+
+// the account of the native token contract
+var TokenContract = common.NameToIndex("token")
+
 func PresetContract(s *state.State, t int64) ([]*types.Transaction, error) {
 	var txs []*types.Transaction
 	if s == nil {
@@ -73,7 +79,10 @@ func PresetContract(s *state.State, t int64) ([]*types.Transaction, error) {
 	if _, err := s.AddAccount(root, addr); err != nil {
 		return nil, err
 	}
-	if err := s.AccountAddBalance(root, state.AbaToken, new(big.Int).SetUint64(1000)); err != nil {
+	if err := s.CreateToken(state.AbaToken, root, 0, new(big.Int).SetUint64(abaMaxSupply)); err != nil {
+		return nil, err
+	}
+	if err := s.IssueToken(state.AbaToken, root, new(big.Int).SetUint64(1000)); err != nil {
 		return nil, err
 	}
 	fmt.Println("set root account's resource to [cpu:100, net:100]")
@@ -85,7 +94,7 @@ func PresetContract(s *state.State, t int64) ([]*types.Transaction, error) {
 	if _, err := s.AddAccount(delegate, common.AddressFromPubKey(config.Delegate.PublicKey)); err != nil {
 		return nil, err
 	}
-	if err := s.AccountAddBalance(delegate, state.AbaToken, new(big.Int).SetUint64(1000)); err != nil {
+	if err := s.IssueToken(state.AbaToken, delegate, new(big.Int).SetUint64(1000)); err != nil {
 		return nil, err
 	}
 	fmt.Println("set root account's resource to [cpu:100, net:100]")
@@ -94,6 +103,13 @@ func PresetContract(s *state.State, t int64) ([]*types.Transaction, error) {
 		return nil, err
 	}
 
+	fmt.Println("preset the native token contract")
+	if _, err := s.AddAccount(TokenContract, addr); err != nil {
+		return nil, err
+	}
+	if err := s.SetContract(TokenContract, types.VmNative, []byte("system token"), nil, nil); err != nil {
+		return nil, err
+	}
 
 	return txs, nil
 }
@@ -49,10 +49,27 @@ func TestGenesesBlockInit(t *testing.T) {
 	fmt.Println(l.RequireResources(root))
 }
 
+//the nonce of the next transaction of account, the transactions of a sender in one block follow it in order
+func nextNonce(l ledger.Ledger, index common.AccountName, t *testing.T) uint64 {
+	nonce, err := l.StateDB().GetNonce(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nonce
+}
+
+//bind the transaction to the head of chain, it must be called before signing
+func reference(l ledger.Ledger, tx *types.Transaction, t *testing.T) {
+	if err := tx.SetReference(l.GetCurrentHeader(), time.Now().Unix()+100); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func CreateAccountBlock(ledger ledger.Ledger, con *types.ConsensusData, t *testing.T) {
 	//TODO
 	var txs []*types.Transaction
 	index := common.NameToIndex("root")
+	nonce := nextNonce(ledger, index, t)
 	//if err := ledger.AccountAddBalance(index, state.AbaToken, 10000); err != nil {
 	//	t.Fatal(err)
 	//}
@@ -60,27 +77,31 @@ func CreateAccountBlock(ledger ledger.Ledger, con *types.ConsensusData, t *testi
 	if err != nil {
 		t.Fatal(err)
 	}
-	tokenContract, err := types.NewDeployContract(index, index, state.Active, types.VmWasm, "system control", code, 0, time.Now().Unix())
+	tokenContract, err := types.NewDeployContract(index, index, state.Active, types.VmWasm, "system control", code, nil, nonce, time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, tokenContract, t)
 	if err := tokenContract.SetSignature(&config.Root); err != nil {
 		t.Fatal(err)
 	}
 	txs = append(txs, tokenContract)
 
 	invoke, err := types.NewInvokeContract(index, index, state.Owner, "new_account",
-		[]string{"worker1", common.AddressFromPubKey(config.Worker1.PublicKey).HexString()}, 0, time.Now().Unix())
+		[]string{"worker1", common.AddressFromPubKey(config.Worker1.PublicKey).HexString()}, nonce+1, time.Now().Unix())
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Root)
 	txs = append(txs, invoke)
 
 	invoke, err = types.NewInvokeContract(index, index, state.Owner, "new_account",
-		[]string{"worker2", common.AddressFromPubKey(config.Worker2.PublicKey).HexString()}, 1, time.Now().Unix())
+		[]string{"worker2", common.AddressFromPubKey(config.Worker2.PublicKey).HexString()}, nonce+2, time.Now().Unix())
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Root)
 	txs = append(txs, invoke)
 
 	invoke, err = types.NewInvokeContract(index, index, state.Owner, "new_account",
-		[]string{"worker3", common.AddressFromPubKey(config.Worker3.PublicKey).HexString()}, 2, time.Now().Unix())
+		[]string{"worker3", common.AddressFromPubKey(config.Worker3.PublicKey).HexString()}, nonce+3, time.Now().Unix())
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Root)
 	txs = append(txs, invoke)
 
@@ -89,7 +110,8 @@ func CreateAccountBlock(ledger ledger.Ledger, con *types.ConsensusData, t *testi
 	if err != nil {
 		t.Fatal(err)
 	}
-	invoke, err = types.NewInvokeContract(index, index, state.Active, "set_account", []string{"root", string(param)}, 0, time.Now().Unix())
+	invoke, err = types.NewInvokeContract(index, index, state.Active, "set_account", []string{"root", string(param)}, nonce+4, time.Now().Unix())
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Root)
 	txs = append(txs, invoke)
 
@@ -112,9 +134,11 @@ func SetTokenAccountBlock(ledger ledger.Ledger, con *types.ConsensusData, t *tes
 	if err != nil {
 		t.Fatal(err)
 	}
-	invoke, err := types.NewInvokeContract(worker3, root, "owner", "set_account", []string{"root", string(param)}, 0, time.Now().Unix())
+	invoke, err := types.NewInvokeContract(worker3, root, "owner", "set_account", []string{"root", string(param)}, nextNonce(ledger, worker3, t), time.Now().Unix())
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Worker3)
-	transfer, err := types.NewTransfer(root, worker3, "owner", new(big.Int).SetUint64(1000), 100, time.Now().Unix())
+	transfer, err := types.NewTransfer(root, worker3, "owner", new(big.Int).SetUint64(1000), nextNonce(ledger, root, t), time.Now().Unix())
+	reference(ledger, transfer, t)
 	transfer.SetSignature(&config.Root)
 
 	txs := []*types.Transaction{invoke, transfer}
@@ -132,10 +156,11 @@ func SetTokenAccountBlock(ledger ledger.Ledger, con *types.ConsensusData, t *tes
 }
 
 func TokenAccountTransferBlock(ledger ledger.Ledger, con *types.ConsensusData, t *testing.T) {
-	transfer, err := types.NewTransfer(worker3, worker1, "active", new(big.Int).SetUint64(100), 101, time.Now().Unix())
+	transfer, err := types.NewTransfer(worker3, worker1, "active", new(big.Int).SetUint64(100), nextNonce(ledger, worker3, t), time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, transfer, t)
 	if err := transfer.SetSignature(&config.Worker2); err != nil {
 		t.Fatal(err)
 	}
@@ -191,10 +216,11 @@ func ShowAccountInfo(l ledger.Ledger, t *testing.T) {
 func AddTokenAccount(ledger ledger.Ledger, con *types.ConsensusData, t *testing.T) {
 	var txs []*types.Transaction
 	invoke, err := types.NewInvokeContract(root, root, "owner", "new_account",
-		[]string{"token", common.AddressFromPubKey(config.Worker1.PublicKey).HexString()}, 0, time.Now().Unix())
+		[]string{"token", common.AddressFromPubKey(config.Worker1.PublicKey).HexString()}, nextNonce(ledger, root, t), time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Root)
 	txs = append(txs, invoke)
 
@@ -202,18 +228,20 @@ func AddTokenAccount(ledger ledger.Ledger, con *types.ConsensusData, t *testing.
 	if err != nil {
 		t.Fatal(err)
 	}
-	tokenContract, err := types.NewDeployContract(token, token, "active", types.VmWasm, "system control", code, 0, time.Now().Unix())
+	tokenContract, err := types.NewDeployContract(token, token, "active", types.VmWasm, "system control", code, nil, 0, time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, tokenContract, t)
 	tokenContract.SetSignature(&config.Worker1)
 	txs = append(txs, tokenContract)
 
 	invoke, err = types.NewInvokeContract(token, token, "owner", "create",
-		[]string{"token", "aba", "10000"}, 0, time.Now().Unix())
+		[]string{"token", "aba", "10000"}, 1, time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Worker1)
 	txs = append(txs, invoke)
 
@@ -232,30 +260,34 @@ func AddTokenAccount(ledger ledger.Ledger, con *types.ConsensusData, t *testing.
 
 func ContractStore(ledger ledger.Ledger, con *types.ConsensusData, t *testing.T) {
 	var txs []*types.Transaction
+	nonce := nextNonce(ledger, worker3, t)
 	code, err := wasmservice.ReadWasm("../../../test/store/store.wasm")
 	if err != nil {
 		t.Fatal(err)
 	}
-	tokenContract, err := types.NewDeployContract(worker3, worker3, "active", types.VmWasm, "system control", code, 0, time.Now().Unix())
+	tokenContract, err := types.NewDeployContract(worker3, worker3, "active", types.VmWasm, "system control", code, nil, nonce, time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, tokenContract, t)
 	tokenContract.SetSignature(&config.Worker3)
 	txs = append(txs, tokenContract)
 
 	invoke, err := types.NewInvokeContract(worker3, worker3, "owner", "StoreSet",
-		[]string{"pct", "panchangtao"}, 0, time.Now().Unix())
+		[]string{"pct", "panchangtao"}, nonce+1, time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Worker3)
 	txs = append(txs, invoke)
 
 	invoke, err = types.NewInvokeContract(worker3, worker3, "owner", "StoreGet",
-		[]string{"pct"}, 0, time.Now().Unix())
+		[]string{"pct"}, nonce+2, time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Worker3)
 	txs = append(txs, invoke)
 
@@ -274,17 +306,19 @@ func ContractStore(ledger ledger.Ledger, con *types.ConsensusData, t *testing.T)
 
 func PledgeContract(ledger ledger.Ledger, con *types.ConsensusData, t *testing.T) {
 	var txs []*types.Transaction
-	tokenContract, err := types.NewDeployContract(delegate, delegate, "active", types.VmNative, "system control", nil, 0, time.Now().Unix())
+	tokenContract, err := types.NewDeployContract(delegate, delegate, "active", types.VmNative, "system control", nil, nil, nextNonce(ledger, delegate, t), time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, tokenContract, t)
 	tokenContract.SetSignature(&config.Delegate)
 	txs = append(txs, tokenContract)
 
-	invoke, err := types.NewInvokeContract(root, delegate, "owner", "pledge", []string{"root", "worker2", "10", "10"}, 0, time.Now().Unix())
+	invoke, err := types.NewInvokeContract(root, delegate, "owner", "pledge", []string{"root", "worker2", "10", "10"}, nextNonce(ledger, root, t), time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Root)
 	txs = append(txs, invoke)
 
@@ -303,10 +337,11 @@ func PledgeContract(ledger ledger.Ledger, con *types.ConsensusData, t *testing.T
 func CancelPledgeContract(ledger ledger.Ledger, con *types.ConsensusData, t *testing.T) {
 	var txs []*types.Transaction
 	invoke, err := types.NewInvokeContract(root, delegate, "owner", "cancel_pledge",
-		[]string{"root", "worker2", "10", "10"}, 0, time.Now().Unix())
+		[]string{"root", "worker2", "10", "10"}, nextNonce(ledger, root, t), time.Now().Unix())
 	if err != nil {
 		t.Fatal(err)
 	}
+	reference(ledger, invoke, t)
 	invoke.SetSignature(&config.Root)
 	txs = append(txs, invoke)
 	block, err := ledger.NewTxBlock(txs, *con)
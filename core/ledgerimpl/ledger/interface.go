@@ -13,15 +13,20 @@ type Ledger interface {
 	VerifyTxBlock(block *types.Block) error
 	SaveTxBlock(block *types.Block) error
 	GetTxBlockByHeight(height uint64) (*types.Block, error)
+	GetTxBlocksByRange(from, to uint64) ([]*types.Block, error)
 	CheckTransaction(tx *types.Transaction) error
+	CheckBlockTransaction(tx *types.Transaction, timeStamp int64) error
 	GetCurrentHeader() *types.Header
 	GetCurrentHeight() uint64
 	StateDB() *state.State
 	ResetStateDB(hash common.Hash) error
+	VerifyDB() error
 
 	AccountAdd(index common.AccountName, addr common.Address) (*state.Account, error)
-	SetContract(index common.AccountName, t types.VmType, des, code []byte) error
+	SetContract(index common.AccountName, t types.VmType, des, code []byte, abi *types.Abi) error
 	GetContract(index common.AccountName) (*types.DeployInfo, error)
+	QueryState() (*state.State, error)
+	QueryContract(s *state.State, tx *types.Transaction) (*types.Receipt, error)
 	AccountGet(index common.AccountName) (*state.Account, error)
 	AddPermission(index common.AccountName, perm state.Permission) error
 	FindPermission(index common.AccountName, name string) (string, error)
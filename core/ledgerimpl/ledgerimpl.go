@@ -88,6 +88,12 @@ func (l *LedgerImpl) SaveTxBlock(block *types.Block) error {
 func (l *LedgerImpl) GetTxBlockByHeight(height uint64) (*types.Block, error) {
 	return l.ChainTx.GetBlockByHeight(height)
 }
+func (l *LedgerImpl) VerifyDB() error {
+	return l.ChainTx.VerifyDB()
+}
+func (l *LedgerImpl) GetTxBlocksByRange(from, to uint64) ([]*types.Block, error) {
+	return l.ChainTx.GetBlocksByRange(from, to)
+}
 func (l *LedgerImpl) GetCurrentHeader() *types.Header {
 	return l.ChainTx.CurrentHeader
 }
@@ -109,6 +115,9 @@ func (l *LedgerImpl) CheckTransaction(tx *types.Transaction) error {
 	//}
 	return nil
 }
+func (l *LedgerImpl) CheckBlockTransaction(tx *types.Transaction, timeStamp int64) error {
+	return l.ChainTx.CheckBlockTransaction(tx, timeStamp)
+}
 
 func (l *LedgerImpl) AccountGet(index common.AccountName) (*state.Account, error) {
 	return l.ChainTx.StateDB.GetAccountByName(index)
@@ -125,12 +134,18 @@ func (l *LedgerImpl) StoreSet(index common.AccountName, key, value []byte) (err
 func (l *LedgerImpl) StoreGet(index common.AccountName, key []byte) (value []byte, err error) {
 	return l.ChainTx.StateDB.StoreGet(index, key)
 }
-func (l *LedgerImpl) SetContract(index common.AccountName, t types.VmType, des, code []byte) error {
-	return l.ChainTx.StateDB.SetContract(index, t, des, code)
+func (l *LedgerImpl) SetContract(index common.AccountName, t types.VmType, des, code []byte, abi *types.Abi) error {
+	return l.ChainTx.StateDB.SetContract(index, t, des, code, abi)
 }
 func (l *LedgerImpl) GetContract(index common.AccountName) (*types.DeployInfo, error) {
 	return l.ChainTx.StateDB.GetContract(index)
 }
+func (l *LedgerImpl) QueryState() (*state.State, error) {
+	return l.ChainTx.QueryState()
+}
+func (l *LedgerImpl) QueryContract(s *state.State, tx *types.Transaction) (*types.Receipt, error) {
+	return l.ChainTx.QueryContract(s, tx)
+}
 func (l *LedgerImpl) AddPermission(index common.AccountName, perm state.Permission) error {
 	return l.ChainTx.StateDB.AddPermission(index, perm)
 }
@@ -157,7 +172,7 @@ func (l *LedgerImpl) AccountSubBalance(index common.AccountName, token string, v
 	return l.ChainTx.StateDB.AccountSubBalance(index, token, new(big.Int).SetUint64(value))
 }
 func (l *LedgerImpl) TokenCreate(index common.AccountName, token string, maximum uint64) error {
-	return l.ChainTx.StateDB.AccountAddBalance(index, token, new(big.Int).SetUint64(maximum))
+	return l.ChainTx.StateDB.TokenCreate(index, token, new(big.Int).SetUint64(maximum))
 }
 func (l *LedgerImpl) TokenIsExisted(token string) bool {
 	return l.ChainTx.StateDB.TokenExisted(token)
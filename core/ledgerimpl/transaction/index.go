@@ -0,0 +1,224 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package transaction
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/trie"
+	"github.com/ecoball/go-ecoball/core/types"
+)
+
+//the maximum number of blocks returned by a range query
+const MaxBlockRange = 1000
+
+//the keys of block index in BlockStore, the blocks are keyed by their hashes which never collide with them
+var (
+	heightPrefix = []byte("height")
+	headKey      = []byte("head")
+)
+
+//the prefix of transaction location in TxsStore, the transactions are keyed by their hashes
+var locationPrefix = []byte("loc")
+
+func locationKey(hash common.Hash) []byte {
+	return append(common.CopyBytes(locationPrefix), hash.Bytes()...)
+}
+
+func heightKey(height uint64) []byte {
+	key := make([]byte, len(heightPrefix)+8)
+	copy(key, heightPrefix)
+	binary.BigEndian.PutUint64(key[len(heightPrefix):], height)
+	return key
+}
+
+/**
+*  @brief  index the block by height and move the head to it, the index is journaled with the block so they are
+*  committed together
+*  @param  block - the new head block
+ */
+func putBlockIndex(j *journal, block *types.Block) {
+	j.put(journalBlock, heightKey(block.Height), block.Hash.Bytes())
+	j.put(journalBlock, headKey, block.Hash.Bytes())
+}
+
+/**
+*  @brief  get the hash of block by height from the height index
+*  @param  height - the block's height
+ */
+func (c *ChainTx) GetBlockHashByHeight(height uint64) (common.Hash, error) {
+	key := heightKey(height)
+	if has, err := c.BlockStore.Has(key); err != nil {
+		return common.Hash{}, err
+	} else if !has {
+		return common.Hash{}, errors.New(fmt.Sprintf("can't find the block by height:%d", height))
+	}
+	data, err := c.BlockStore.Get(key)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.NewHash(data), nil
+}
+
+/**
+*  @brief  get the blocks of a height range in order
+*  @param  from - the height of first block
+*  @param  to - the height of last block, the range is no more than MaxBlockRange blocks
+ */
+func (c *ChainTx) GetBlocksByRange(from, to uint64) ([]*types.Block, error) {
+	if from > to {
+		return nil, errors.New(fmt.Sprintf("invalid block range:%d-%d", from, to))
+	}
+	if to-from >= MaxBlockRange {
+		return nil, errors.New(fmt.Sprintf("the block range %d-%d exceeds the limit %d", from, to, MaxBlockRange))
+	}
+	var blocks []*types.Block
+	for height := from; height <= to; height++ {
+		block, err := c.GetBlockByHeight(height)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+/**
+*  @brief  get the hash of head block from the persisted head pointer
+*  @return false if the chain has no block
+ */
+func (c *ChainTx) headHash() (common.Hash, bool, error) {
+	if has, err := c.BlockStore.Has(headKey); err != nil || !has {
+		return common.Hash{}, false, err
+	}
+	data, err := c.BlockStore.Get(headKey)
+	if err != nil {
+		return common.Hash{}, false, err
+	}
+	return common.NewHash(data), true, nil
+}
+
+/**
+*  @brief  build the block index of a database created before the index existed, every header is scanned once
+*  @return the hash of head block, false if the database has no block
+ */
+func (c *ChainTx) rebuildBlockIndex() (common.Hash, bool, error) {
+	headers, err := c.HeaderStore.SearchAll()
+	if err != nil {
+		return common.Hash{}, false, err
+	}
+	if len(headers) == 0 {
+		return common.Hash{}, false, nil
+	}
+	log.Notice("build the block index of", len(headers), "headers")
+	var head *types.Header
+	for _, v := range headers {
+		header := new(types.Header)
+		if err := header.Deserialize([]byte(v)); err != nil {
+			return common.Hash{}, false, err
+		}
+		c.BlockStore.BatchPut(heightKey(header.Height), header.Hash.Bytes())
+		if head == nil || header.Height > head.Height {
+			head = header
+		}
+	}
+	c.BlockStore.BatchPut(headKey, head.Hash.Bytes())
+	if err := c.BlockStore.BatchCommit(); err != nil {
+		return common.Hash{}, false, err
+	}
+	return head.Hash, true, nil
+}
+
+/**
+*  @brief  record the block and position of every transaction of block, the locations are journaled with the
+*  transactions
+*  @param  block - the saved block
+ */
+func putTxLocations(j *journal, block *types.Block) error {
+	for i, tx := range block.Transactions {
+		location := &types.TxLocation{BlockHash: block.Hash, Height: block.Height, Index: uint32(i)}
+		data, err := location.Serialize()
+		if err != nil {
+			return err
+		}
+		j.put(journalTxs, locationKey(tx.Hash), data)
+	}
+	return nil
+}
+
+/**
+*  @brief  get the block and position which a transaction is packed in
+*  @param  hash - the hash of transaction
+ */
+func (c *ChainTx) GetTransactionLocation(hash common.Hash) (*types.TxLocation, error) {
+	key := locationKey(hash)
+	if has, err := c.TxsStore.Has(key); err != nil {
+		return nil, err
+	} else if !has {
+		return nil, errors.New(fmt.Sprintf("can't find the location of transaction:%s", hash.HexString()))
+	}
+	data, err := c.TxsStore.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	location := new(types.TxLocation)
+	if err := location.Deserialize(data); err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+/**
+*  @brief  get a packed transaction with its location, receipt and the merkle proof of its inclusion, the proof
+*  is checked against the MerkleHash of block header
+*  @param  hash - the hash of transaction
+ */
+func (c *ChainTx) GetTransactionInclusion(hash common.Hash) (*types.TxInclusion, error) {
+	location, err := c.GetTransactionLocation(hash)
+	if err != nil {
+		return nil, err
+	}
+	block, err := c.GetBlock(location.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	if int(location.Index) >= len(block.Transactions) || !block.Transactions[location.Index].Hash.Equals(&hash) {
+		return nil, errors.New(fmt.Sprintf("the location of transaction:%s is mismatch", hash.HexString()))
+	}
+	receipt, err := c.GetReceipt(hash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	var hashes []common.Hash
+	for _, tx := range block.Transactions {
+		hashes = append(hashes, tx.Hash)
+	}
+	proof, err := trie.NewMerkleTree(hashes).Proof(int(location.Index))
+	if err != nil {
+		return nil, err
+	}
+	return &types.TxInclusion{
+		Transaction: block.Transactions[location.Index],
+		Location:    *location,
+		Receipt:     receipt,
+		MerkleHash:  block.MerkleHash,
+		Proof:       proof,
+	}, nil
+}
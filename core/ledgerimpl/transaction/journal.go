@@ -0,0 +1,208 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package transaction
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/common/config"
+	"github.com/ecoball/go-ecoball/core/pb"
+	"github.com/ecoball/go-ecoball/core/store"
+)
+
+//the stores written by a block
+const (
+	journalBlock uint32 = iota
+	journalHeader
+	journalTxs
+	journalReceipts
+	journalState
+)
+
+//the key of journal in BlockStore, it only exists while a block is being saved
+var journalKey = []byte("journal")
+
+//the writes of a block to all stores of chain, the journal is persisted before any store is written so a crash in
+//the middle is recovered by replaying it on startup
+type journal struct {
+	pb.Journal
+}
+
+func newJournal(hash common.Hash) *journal {
+	return &journal{pb.Journal{BlockHash: hash.Bytes()}}
+}
+
+func (j *journal) put(store uint32, key, value []byte) {
+	j.Entries = append(j.Entries, &pb.JournalEntry{Store: store, Key: key, Value: value})
+}
+
+func (j *journal) delete(store uint32, key []byte) {
+	j.Entries = append(j.Entries, &pb.JournalEntry{Store: store, Key: key, Delete: true})
+}
+
+//the batch journaling the trie nodes of state, it is never flushed by the trie so all nodes are in the journal
+type journalBatch struct {
+	j *journal
+}
+
+func (b *journalBatch) Put(key, value []byte) error {
+	b.j.put(journalState, common.CopyBytes(key), common.CopyBytes(value))
+	return nil
+}
+
+func (b *journalBatch) Delete(key []byte) error {
+	b.j.delete(journalState, common.CopyBytes(key))
+	return nil
+}
+
+func (b *journalBatch) ValueSize() int {
+	return 0
+}
+
+func (b *journalBatch) Write() error {
+	return nil
+}
+
+func (b *journalBatch) Reset() {}
+
+/**
+*  @brief  write the entries of journal into the stores, the block store is written last so the head pointer only
+*  moves after the other stores have the block, writing the same journal again has the same result
+*  @param  stores - the stores of journal entries
+ */
+func (j *journal) apply(stores map[uint32]store.Storage) error {
+	for _, id := range []uint32{journalTxs, journalReceipts, journalHeader, journalState, journalBlock} {
+		s, ok := stores[id]
+		if !ok {
+			return errors.New(fmt.Sprintf("unknown store %d of journal", id))
+		}
+		for _, e := range j.Entries {
+			if e.Store != id {
+				continue
+			}
+			if e.Delete {
+				s.BatchDelete(e.Key)
+			} else {
+				s.BatchPut(e.Key, e.Value)
+			}
+		}
+		if err := s.BatchCommit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ChainTx) journalStores(stateStore store.Storage) map[uint32]store.Storage {
+	return map[uint32]store.Storage{
+		journalBlock:    c.BlockStore,
+		journalHeader:   c.HeaderStore,
+		journalTxs:      c.TxsStore,
+		journalReceipts: c.ReceiptsStore,
+		journalState:    stateStore,
+	}
+}
+
+/**
+*  @brief  persist the journal, write it into all stores and then remove it
+*  @param  j - the writes of a block
+ */
+func (c *ChainTx) commitJournal(j *journal) error {
+	data, err := j.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := c.BlockStore.Put(journalKey, data); err != nil {
+		return err
+	}
+	if err := j.apply(c.journalStores(c.StateDB.Store())); err != nil {
+		return err
+	}
+	return c.BlockStore.Delete(journalKey)
+}
+
+/**
+*  @brief  replay the journal left by a crash while saving a block, it must be called before the head is restored
+*  and the state is opened
+*  @param  path - the path of chain's databases
+ */
+func (c *ChainTx) replayJournal(path string) error {
+	if has, err := c.BlockStore.Has(journalKey); err != nil || !has {
+		return err
+	}
+	data, err := c.BlockStore.Get(journalKey)
+	if err != nil {
+		return err
+	}
+	j := new(journal)
+	if err := j.Unmarshal(data); err != nil {
+		return err
+	}
+	log.Notice("replay the journal of block:", common.NewHash(j.BlockHash).HexString())
+	stateStore, err := store.NewLevelDBStore(path+config.StringState, 0, 0)
+	if err != nil {
+		return err
+	}
+	defer stateStore.Close()
+	if err := j.apply(c.journalStores(stateStore)); err != nil {
+		return err
+	}
+	return c.BlockStore.Delete(journalKey)
+}
+
+/**
+*  @brief  close all databases of chain, the chain can't be used after it
+ */
+func (c *ChainTx) Close() {
+	c.BlockStore.Close()
+	c.HeaderStore.Close()
+	c.TxsStore.Close()
+	c.ReceiptsStore.Close()
+	c.StateDB.Close()
+}
+
+/**
+*  @brief  check the database is consistent, the head block is stored and every node of its state can be loaded
+ */
+func (c *ChainTx) VerifyDB() error {
+	if c.CurrentHeader == nil {
+		return errors.New("the chain has no block")
+	}
+	head := c.CurrentHeader
+	block, err := c.GetBlock(head.Hash)
+	if err != nil {
+		return errors.New(fmt.Sprintf("the head block %d %s is not stored: %s", head.Height, head.Hash.HexString(), err.Error()))
+	}
+	if hash, err := c.GetBlockHashByHeight(block.Height); err != nil || !hash.Equals(&block.Hash) {
+		return errors.New(fmt.Sprintf("the head block %d is not indexed by height", block.Height))
+	}
+	if root := c.StateDB.GetHashRoot(); !root.Equals(&head.StateHash) {
+		return errors.New(fmt.Sprintf("the state root %s is not the root %s of head block", root.HexString(), head.StateHash.HexString()))
+	}
+	s, err := c.StateDB.StateAt(head.StateHash)
+	if err != nil {
+		return errors.New(fmt.Sprintf("the state of head block can't be opened: %s", err.Error()))
+	}
+	nodes, err := s.VerifyTrie()
+	if err != nil {
+		return errors.New(fmt.Sprintf("the state of head block is incomplete: %s", err.Error()))
+	}
+	log.Notice("the database is verified, head:", head.Height, head.Hash.HexString(), "state nodes:", nodes)
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package transaction
+
+import (
+	"errors"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/state"
+	"github.com/ecoball/go-ecoball/core/types"
+)
+
+//the key of the height of last block whose state is released in BlockStore, the states of the blocks above it
+//are kept
+var prunedKey = []byte("pruned")
+
+/**
+*  @brief  get the state root of block by height
+*  @param  height - the block's height
+ */
+func (c *ChainTx) stateRootAt(height uint64) (common.Hash, error) {
+	hash, err := c.GetBlockHashByHeight(height)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	data, err := c.HeaderStore.Get(hash.Bytes())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	header := new(types.Header)
+	if err := header.Deserialize(data); err != nil {
+		return common.Hash{}, err
+	}
+	return header.StateHash, nil
+}
+
+/**
+*  @brief  get the height of last block whose state is released
+*  @return false if no state is released
+ */
+func (c *ChainTx) prunedHeight() (uint64, bool, error) {
+	if has, err := c.BlockStore.Has(prunedKey); err != nil || !has {
+		return 0, false, err
+	}
+	data, err := c.BlockStore.Get(prunedKey)
+	if err != nil {
+		return 0, false, err
+	}
+	return common.Uint64SetBytes(data), true, nil
+}
+
+/**
+*  @brief  get the state roots of the blocks leaving the retention when a block is saved, the released height
+*  is journaled with the block
+*  @param  j - the journal of block
+*  @param  height - the height of saved block
+*  @param  retention - the number of recent block states kept, 0 keeps all of them
+ */
+func (c *ChainTx) expiredStates(j *journal, height, retention uint64) ([]state.ExpiredState, error) {
+	if retention == 0 || height <= retention {
+		return nil, nil
+	}
+	to := height - retention
+	from := to
+	pruned, ok, err := c.prunedHeight()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		from = pruned + 1
+	}
+	//the retention is enlarged, the states are kept until the chain grows out of it
+	if from > to {
+		return nil, nil
+	}
+	var expired []state.ExpiredState
+	for h := from; h <= to; h++ {
+		root, err := c.stateRootAt(h)
+		if err != nil {
+			return nil, err
+		}
+		expired = append(expired, state.ExpiredState{Height: h, Root: root})
+	}
+	j.put(journalBlock, prunedKey, common.Uint64ToBytes(to))
+	return expired, nil
+}
+
+/**
+*  @brief  delete the trie nodes not reachable from the states of recent blocks, and rebuild the reference counts
+*  of the kept nodes so they are pruned online later, the database is compacted afterwards. It must be run offline
+*  @param  retention - the number of recent block states kept
+*  @return the number of deleted trie nodes
+ */
+func (c *ChainTx) PruneState(retention uint64) (int, error) {
+	if retention == 0 {
+		return 0, errors.New("the retention of pruning must be more than 0")
+	}
+	if c.CurrentHeader == nil {
+		return 0, errors.New("the chain has no block")
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	head := c.CurrentHeader.Height
+	from := uint64(1)
+	if head > retention {
+		from = head - retention + 1
+	}
+	var roots []common.Hash
+	for h := from; h <= head; h++ {
+		root, err := c.stateRootAt(h)
+		if err != nil {
+			return 0, err
+		}
+		roots = append(roots, root)
+	}
+	nodes, err := c.StateDB.Prune(from, roots)
+	if err != nil {
+		return 0, err
+	}
+	if head > retention {
+		if err := c.BlockStore.Put(prunedKey, common.Uint64ToBytes(head-retention)); err != nil {
+			return 0, err
+		}
+	}
+	log.Notice("pruned", nodes, "trie nodes, the states of blocks", from, "-", head, "are kept")
+	return nodes, nil
+}
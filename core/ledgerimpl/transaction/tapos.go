@@ -0,0 +1,117 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ecoball/go-ecoball/common"
+	errs "github.com/ecoball/go-ecoball/common/errors"
+	"github.com/ecoball/go-ecoball/core/types"
+)
+
+//the recent blocks of chain referenced by transactions and the transactions not expired yet, a transaction
+//expires no later than MaxTxLifetime after the block packing it, so both are bounded
+type recentIndex struct {
+	blocks map[uint64]common.Hash
+	txs    map[common.Hash]int64
+	mutex  sync.RWMutex
+}
+
+func newRecentIndex() *recentIndex {
+	return &recentIndex{blocks: make(map[uint64]common.Hash), txs: make(map[common.Hash]int64)}
+}
+
+/**
+*  @brief  add a block on the head of chain, the blocks out of RefBlockWindow and the transactions expired at the
+*  time of block are removed
+*  @param  block - the new head block
+ */
+func (r *recentIndex) addBlock(block *types.Block) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.blocks[block.Height] = block.Hash
+	if block.Height > types.RefBlockWindow {
+		delete(r.blocks, block.Height-types.RefBlockWindow)
+	}
+	for _, tx := range block.Transactions {
+		r.txs[tx.Hash] = tx.Expiration
+	}
+	for hash, expiration := range r.txs {
+		if expiration <= block.TimeStamp {
+			delete(r.txs, hash)
+		}
+	}
+}
+
+/**
+*  @brief  check the transaction is not packed in the chain yet, references a recent block of chain and is not
+*  expired at the time
+*  @param  tx - a transaction
+*  @param  now - the time of block packing the transaction
+ */
+func (r *recentIndex) check(tx *types.Transaction, now int64) error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if _, ok := r.txs[tx.Hash]; ok {
+		return errs.ErrDuplicatedTx
+	}
+	//the expiration is set with the reference block by SetReference
+	if tx.Expiration == 0 {
+		return errs.ErrTxNotReferenced
+	}
+	if tx.Expiration <= now {
+		return errs.ErrTxExpired
+	}
+	if tx.Expiration > now+types.MaxTxLifetime {
+		return errors.New(fmt.Sprintf("the expiration %d is more than %d seconds later than %d", tx.Expiration, types.MaxTxLifetime, now))
+	}
+	hash, ok := r.blocks[tx.RefBlockNum]
+	if !ok || types.RefBlockPrefix(hash) != tx.RefBlockPrefix {
+		return errs.ErrInvalidRefBlock
+	}
+	return nil
+}
+
+/**
+*  @brief  rebuild the recent index from the blocks before the head, the index is not persisted
+ */
+func (c *ChainTx) restoreRecentIndex() error {
+	var blocks []*types.Block
+	hash := c.CurrentHeader.Hash
+	for {
+		block, err := c.GetBlock(hash)
+		if err != nil {
+			return err
+		}
+		//the transactions of the blocks older than MaxTxLifetime are expired at the head
+		if c.CurrentHeader.Height-block.Height >= types.RefBlockWindow && block.TimeStamp+types.MaxTxLifetime <= c.CurrentHeader.TimeStamp {
+			break
+		}
+		blocks = append(blocks, block)
+		if block.Height <= 1 {
+			break
+		}
+		hash = block.PrevHash
+	}
+	for i := len(blocks) - 1; i >= 0; i-- {
+		c.recent.addBlock(blocks[i])
+	}
+	return nil
+}
@@ -24,14 +24,17 @@ import (
 	"github.com/ecoball/go-ecoball/common/elog"
 	errs "github.com/ecoball/go-ecoball/common/errors"
 	"github.com/ecoball/go-ecoball/common/event"
-	"github.com/ecoball/go-ecoball/core/bloom"
 	"github.com/ecoball/go-ecoball/core/ledgerimpl/geneses"
 	"github.com/ecoball/go-ecoball/core/ledgerimpl/ledger"
 	"github.com/ecoball/go-ecoball/core/state"
 	"github.com/ecoball/go-ecoball/core/store"
 	"github.com/ecoball/go-ecoball/core/types"
 	"github.com/ecoball/go-ecoball/smartcontract"
+	"github.com/ecoball/go-ecoball/smartcontract/wasmservice"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/exec"
 	"math/big"
+	"sort"
+	"sync"
 	"time"
 )
 
@@ -41,15 +44,20 @@ type ChainTx struct {
 	BlockStore     store.Storage
 	HeaderStore    store.Storage
 	TxsStore       store.Storage
+	ReceiptsStore  store.Storage
 	ConsensusStore store.Storage
 
 	CurrentHeader *types.Header
 	StateDB       *state.State
 	ledger        ledger.Ledger
+	//serialize the changes of StateDB with the queries copying it
+	mutex sync.RWMutex
+	//the recent blocks and transactions for the TaPoS and duplicate checks
+	recent *recentIndex
 }
 
 func NewTransactionChain(path string, ledger ledger.Ledger) (c *ChainTx, err error) {
-	c = &ChainTx{ledger: ledger}
+	c = &ChainTx{ledger: ledger, recent: newRecentIndex()}
 	c.BlockStore, err = store.NewLevelDBStore(path+config.StringBlock, 0, 0)
 	if err != nil {
 		return nil, err
@@ -62,6 +70,13 @@ func NewTransactionChain(path string, ledger ledger.Ledger) (c *ChainTx, err err
 	if err != nil {
 		return nil, err
 	}
+	c.ReceiptsStore, err = store.NewLevelDBStore(path+config.StringReceipts, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.replayJournal(path); err != nil {
+		return nil, err
+	}
 
 	existed, err := c.RestoreCurrentHeader()
 	if err != nil {
@@ -71,17 +86,22 @@ func NewTransactionChain(path string, ledger ledger.Ledger) (c *ChainTx, err err
 		if c.StateDB, err = state.NewState(path+config.StringState, c.CurrentHeader.StateHash); err != nil {
 			return nil, err
 		}
+		if err := c.restoreRecentIndex(); err != nil {
+			return nil, err
+		}
 	} else {
 		if c.StateDB, err = state.NewState(path+config.StringState, common.Hash{}); err != nil {
 			return nil, err
 		}
 	}
+	c.StateDB.SetPruning(config.StateRetention > 0)
 
 	return c, nil
 }
 
 /**
-*  @brief  create a new block, this function will execute the transaction to rebuild mpt trie
+*  @brief  create a new block, this function will execute the transaction to rebuild mpt trie, the transaction whose
+*  resources can't be charged is left out of the block
 *  @param  consensusData - the data of consensus module set
  */
 func (c *ChainTx) NewBlock(ledger ledger.Ledger, txs []*types.Transaction, consensusData types.ConsensusData) (*types.Block, error) {
@@ -93,15 +113,33 @@ func (c *ChainTx) NewBlock(ledger ledger.Ledger, txs []*types.Transaction, conse
 	if err != nil {
 		return nil, err
 	}
+	timeStamp := time.Now().Unix()
+	s.SetBlockInfo(c.CurrentHeader.Height+1, timeStamp)
+	txs, err = c.selectTransactions(s, txs, timeStamp)
+	if err != nil {
+		return nil, err
+	}
+	var receipts []*types.Receipt
 	for i := 0; i < len(txs); i++ {
-		if ret, _, _, err := c.HandleTransaction(s, txs[i]); err != nil {
+		snapshot, err := s.Snapshot()
+		if err != nil {
+			return nil, err
+		}
+		if receipt, err := c.HandleTransaction(s, txs[i]); err == errs.ErrTxChargeFailed {
+			//the accounts billed can't pay for the transaction, it is left out of the block
+			log.Warn("drop transaction", txs[i].Hash.HexString(), err)
+			s.RevertToSnapshot(snapshot)
+			txs = append(txs[:i], txs[i+1:]...)
+			i--
+		} else if err != nil {
 			log.Error("Handle Transaction Error:", err)
 			txs[i].Show()
 			return nil, err
 		} else {
 			//cpu += c
 			//net += n
-			log.Debug("Handle Transaction Result:", ret)
+			log.Debug("Handle Transaction Result:", receipt.Result)
+			receipts = append(receipts, receipt)
 		}
 	}
 	/*if cpu < (state.BlockCpuLimit / 10) {
@@ -115,7 +153,7 @@ func (c *ChainTx) NewBlock(ledger ledger.Ledger, txs []*types.Transaction, conse
 		netFlag = false
 	}
 	c.StateDB.SetBlockLimits(cpuFlag, netFlag)*/
-	return types.NewBlock(c.CurrentHeader, s.GetHashRoot(), consensusData, txs)
+	return types.NewBlock(c.CurrentHeader, s.GetHashRoot(), consensusData, txs, receipts, timeStamp)
 }
 
 /**
@@ -123,6 +161,8 @@ func (c *ChainTx) NewBlock(ledger ledger.Ledger, txs []*types.Transaction, conse
 *  @param  hash - the root hash of mpt trie which need to reset
  */
 func (c *ChainTx) ResetStateDB(hash common.Hash) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	return c.StateDB.Reset(hash)
 }
 
@@ -139,8 +179,16 @@ func (c *ChainTx) VerifyTxBlock(block *types.Block) error {
 	if result == false {
 		return errors.New("block verify signature failed")
 	}
+	if err := c.checkChainID(block.Header); err != nil {
+		return err
+	}
+	hashes := make(map[common.Hash]bool)
 	for _, v := range block.Transactions {
-		if err := c.CheckTransaction(v); err != nil {
+		if hashes[v.Hash] {
+			return errs.ErrDuplicatedTx
+		}
+		hashes[v.Hash] = true
+		if err := c.checkTransaction(v, block.TimeStamp); err != nil {
 			log.Error("Transactions VerifySignature Failed")
 			return err
 		}
@@ -149,69 +197,185 @@ func (c *ChainTx) VerifyTxBlock(block *types.Block) error {
 }
 
 /**
-*  @brief  save a block into levelDB, then push this block to p2p and tx pool module, and commit mpt trie into levelDB
+*  @brief  select the transactions can be packed into a new block, the expired, duplicated and wrongly referenced
+*  ones are removed, the transactions of a sender are sorted by nonce and the ones not following the nonce of
+*  account are left for later blocks, a transaction without reference block fails the new block
+*  @param  s - the state of new block
+*  @param  timeStamp - the time of new block
+ */
+func (c *ChainTx) selectTransactions(s *state.State, txs []*types.Transaction, timeStamp int64) ([]*types.Transaction, error) {
+	var valid []*types.Transaction
+	hashes := make(map[common.Hash]bool)
+	for _, tx := range txs {
+		if hashes[tx.Hash] {
+			continue
+		}
+		hashes[tx.Hash] = true
+		if err := c.recent.check(tx, timeStamp); err == errs.ErrTxNotReferenced {
+			return nil, errors.New(fmt.Sprintf("the transaction %s has no reference block", tx.Hash.HexString()))
+		} else if err != nil {
+			log.Warn("drop transaction", tx.Hash.HexString(), err)
+			continue
+		}
+		valid = append(valid, tx)
+	}
+	sort.SliceStable(valid, func(i, j int) bool {
+		if valid[i].From != valid[j].From {
+			return valid[i].From < valid[j].From
+		}
+		return valid[i].Nonce < valid[j].Nonce
+	})
+	var selected []*types.Transaction
+	nonces := make(map[common.AccountName]uint64)
+	for _, tx := range valid {
+		nonce, ok := nonces[tx.From]
+		if !ok {
+			var err error
+			if nonce, err = s.GetNonce(tx.From); err != nil {
+				log.Warn("drop transaction", tx.Hash.HexString(), err)
+				continue
+			}
+		}
+		if tx.Nonce != nonce {
+			log.Debug("skip transaction", tx.Hash.HexString(), "nonce:", tx.Nonce, "expect:", nonce)
+			nonces[tx.From] = nonce
+			continue
+		}
+		nonces[tx.From] = nonce + 1
+		selected = append(selected, tx)
+	}
+	return selected, nil
+}
+
+/**
+*  @brief  save a block into levelDB, then push this block to p2p and tx pool module, and commit mpt trie into levelDB,
+*  the state is reverted if the block is rejected so the next block is executed on the state of head
 *  @param  block - the block need to save
  */
-func (c *ChainTx) SaveBlock(block *types.Block) error {
+func (c *ChainTx) SaveBlock(block *types.Block) (err error) {
 	if block == nil {
 		return errors.New("block is nil")
 	}
+	if err := c.checkChainID(block.Header); err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	snapshot, err := c.StateDB.Snapshot()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			c.StateDB.RevertToSnapshot(snapshot)
+		}
+	}()
 	var cpu float32
 	cpuFlag := true
 	var net float32
 	netFlag := true
+	c.StateDB.SetBlockInfo(block.Height, block.TimeStamp)
+	var receipts []*types.Receipt
 	for i := 0; i < len(block.Transactions); i++ {
-		if _, c, n, err := c.HandleTransaction(c.StateDB, block.Transactions[i]); err != nil {
+		if receipt, err := c.HandleTransaction(c.StateDB, block.Transactions[i]); err != nil {
 			log.Error("Handle Transaction Error:", err)
 			return err
 		} else {
-			cpu += c
-			net += n
+			cpu += receipt.Cpu
+			net += receipt.Net
+			receipts = append(receipts, receipt)
 		}
 	}
-	if cpu < (state.BlockCpuLimit / 10) {
-		cpuFlag = true
-	} else {
-		cpuFlag = false
+	receiptHash, err := types.ReceiptsRoot(receipts)
+	if err != nil {
+		return err
 	}
-	if net < (state.BlockNetLimit / 10) {
-		netFlag = true
-	} else {
-		netFlag = false
+	if !receiptHash.Equals(&block.ReceiptHash) {
+		log.Error("receipts root mismatch:", receiptHash.HexString(), block.ReceiptHash.HexString())
+		return errors.New("the receipts root of block is mismatch")
 	}
-	c.StateDB.SetBlockLimits(cpuFlag, netFlag)
-	if err := event.Publish(event.ActorLedger, block, event.ActorTxPool, event.ActorP2P); err != nil {
-		log.Warn(err)
+	if root := c.StateDB.GetHashRoot(); !root.Equals(&block.StateHash) {
+		log.Error("state root mismatch:", root.HexString(), block.StateHash.HexString())
+		return errors.New("the state root of block is mismatch")
 	}
+
+	//all writes of block are journaled and committed together
+	j := newJournal(block.Hash)
 	for _, t := range block.Transactions {
 		payload, _ := t.Serialize()
+		//the deploy transaction is also kept by the account for the lookups of contract's transaction
 		if t.Type == types.TxDeploy {
-			c.TxsStore.BatchPut(common.IndexToBytes(t.Addr), payload)
-		} else {
-			c.TxsStore.BatchPut(t.Hash.Bytes(), payload)
+			j.put(journalTxs, common.IndexToBytes(t.Addr), payload)
 		}
+		j.put(journalTxs, t.Hash.Bytes(), payload)
 	}
-	if err := c.TxsStore.BatchCommit(); err != nil {
+	if err := putTxLocations(j, block); err != nil {
 		return err
 	}
-
+	for _, r := range receipts {
+		payload, err := r.Serialize()
+		if err != nil {
+			return err
+		}
+		j.put(journalReceipts, r.TxHash.Bytes(), payload)
+	}
 	payload, err := block.Header.Serialize()
 	if err != nil {
 		return err
 	}
-	if err := c.HeaderStore.Put(block.Header.Hash.Bytes(), payload); err != nil {
+	j.put(journalHeader, block.Header.Hash.Bytes(), payload)
+	//the states out of retention are released with the block
+	expired, err := c.expiredStates(j, block.Height, uint64(config.StateRetention))
+	if err != nil {
+		return err
+	}
+	if err := c.StateDB.CommitToBatch(&journalBatch{j: j}, expired); err != nil {
 		return err
 	}
-	payload, _ = block.Serialize()
-	c.BlockStore.BatchPut(block.Hash.Bytes(), payload)
-	if err := c.BlockStore.BatchCommit(); err != nil {
+	payload, err = block.Serialize()
+	if err != nil {
+		return err
+	}
+	j.put(journalBlock, block.Hash.Bytes(), payload)
+	putBlockIndex(j, block)
+	if err := c.commitJournal(j); err != nil {
 		return err
 	}
-	//c.StateDB.CommitToDB()
+	c.StateDB.ReleaseCommitted()
 	log.Debug("block state:", block.Height, block.StateHash.HexString())
 	log.Debug("state hash:", c.StateDB.GetHashRoot().HexString())
+	//the limits of block only change when the block is saved
+	if cpu < (state.BlockCpuLimit / 10) {
+		cpuFlag = true
+	} else {
+		cpuFlag = false
+	}
+	if net < (state.BlockNetLimit / 10) {
+		netFlag = true
+	} else {
+		netFlag = false
+	}
+	c.StateDB.SetBlockLimits(cpuFlag, netFlag)
 
 	c.CurrentHeader = block.Header
+	c.recent.addBlock(block)
+	if err := event.Publish(event.ActorLedger, block, event.ActorTxPool, event.ActorP2P); err != nil {
+		log.Warn(err)
+	}
+	return nil
+}
+
+/**
+*  @brief  check the block is created for this chain, the geneses block has no chain ID
+*  @param  header - the header of block
+ */
+func (c *ChainTx) checkChainID(header *types.Header) error {
+	if c.CurrentHeader == nil || header.Height <= 1 {
+		return nil
+	}
+	if chain := c.CurrentHeader.Chain(); !header.ChainID.Equals(&chain) {
+		return errs.ErrChainIdMismatch
+	}
 	return nil
 }
 
@@ -244,28 +408,10 @@ func (c *ChainTx) GetBlock(hash common.Hash) (*types.Block, error) {
 *  @param  height - the block's height need to return
  */
 func (c *ChainTx) GetBlockByHeight(height uint64) (*types.Block, error) {
-	headers, err := c.HeaderStore.SearchAll()
+	hash, err := c.GetBlockHashByHeight(height)
 	if err != nil {
 		return nil, err
 	}
-	if len(headers) == 0 {
-		return nil, nil
-	}
-	log.Info("The geneses block is existed:", len(headers))
-	var hash common.Hash
-	for _, v := range headers {
-		header := new(types.Header)
-		if err := header.Deserialize([]byte(v)); err != nil {
-			return nil, err
-		}
-		if header.Height == height {
-			hash = header.Hash
-			break
-		}
-	}
-	if hash.Equals(&common.Hash{}) {
-		return nil, errors.New(fmt.Sprintf("can't find the block by height:%d", height))
-	}
 	return c.GetBlock(hash)
 }
 
@@ -302,14 +448,22 @@ func (c *ChainTx) GenesesBlockInit() error {
 	if err != nil {
 		return err
 	}
+	s.SetBlockInfo(1, timeStamp)
+	var receipts []*types.Receipt
 	for i := 0; i < len(txs); i++ {
-		if _, _, _, err := c.HandleTransaction(s, txs[i]); err != nil {
+		receipt, err := c.HandleTransaction(s, txs[i])
+		if err != nil {
 			log.Error("Handle Transaction Error:", err)
 			return err
 		}
+		receipts = append(receipts, receipt)
 	}
 	hashState := s.GetHashRoot()
-	header, err := types.NewHeader(types.VersionHeader, 1, hash, hash, hashState, *conData, bloom.Bloom{}, timeStamp)
+	receiptHash, err := types.ReceiptsRoot(receipts)
+	if err != nil {
+		return err
+	}
+	header, err := types.NewHeader(types.VersionHeader, common.Hash{}, 1, hash, hash, hashState, receiptHash, *conData, types.CreateBloom(txs, receipts), timeStamp)
 	if err != nil {
 		return err
 	}
@@ -332,30 +486,29 @@ func (c *ChainTx) GenesesBlockInit() error {
 }
 
 /**
-*  @brief  restore the highest block's header from levelDB
+*  @brief  restore the highest block's header from levelDB by the persisted head pointer
 *  @return bool - if can't find block in levelDB, return false, otherwise return true
  */
 func (c *ChainTx) RestoreCurrentHeader() (bool, error) {
-	headers, err := c.HeaderStore.SearchAll()
+	hash, existed, err := c.headHash()
 	if err != nil {
 		return false, err
 	}
-	if len(headers) == 0 {
-		return false, nil
-	}
-	log.Info("The geneses block is existed:", len(headers))
-	var h uint64 = 0
-	for _, v := range headers {
-		header := new(types.Header)
-		if err := header.Deserialize([]byte(v)); err != nil {
+	if !existed {
+		if hash, existed, err = c.rebuildBlockIndex(); err != nil || !existed {
 			return false, err
 		}
-		if header.Height > h {
-			h = header.Height
-			c.CurrentHeader = header
-		}
 	}
-	log.Info("the block height is:", h, "hash:", c.CurrentHeader.Hash.HexString())
+	data, err := c.HeaderStore.Get(hash.Bytes())
+	if err != nil {
+		return false, err
+	}
+	header := new(types.Header)
+	if err := header.Deserialize(data); err != nil {
+		return false, err
+	}
+	c.CurrentHeader = header
+	log.Info("the block height is:", header.Height, "hash:", header.Hash.HexString())
 	return true, nil
 }
 
@@ -376,10 +529,44 @@ func (c *ChainTx) GetTransaction(key []byte) (*types.Transaction, error) {
 }
 
 /**
-*  @brief  validity check of transaction, include signature verify, duplicate check and balance check
+*  @brief  get the receipt of a transaction from levelDB
+*  @param  key - the hash of transaction
+ */
+func (c *ChainTx) GetReceipt(key []byte) (*types.Receipt, error) {
+	data, err := c.ReceiptsStore.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	receipt := new(types.Receipt)
+	if err := receipt.Deserialize(data); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+/**
+*  @brief  validity check of transaction, include signature verify, duplicate check, TaPoS check and balance check
 *  @param  tx - a transaction
  */
 func (c *ChainTx) CheckTransaction(tx *types.Transaction) (err error) {
+	return c.checkTransaction(tx, time.Now().Unix())
+}
+
+/**
+*  @brief  validity check of transaction packed in a received block, the expiration is checked against the time of
+*  block instead of the local time, so every node gets the same result
+*  @param  tx - a transaction of block
+*  @param  timeStamp - the time of block packing the transaction
+ */
+func (c *ChainTx) CheckBlockTransaction(tx *types.Transaction, timeStamp int64) error {
+	return c.checkTransaction(tx, timeStamp)
+}
+
+/**
+*  @brief  validity check of transaction packed in a block
+*  @param  now - the time of block packing the transaction, the transaction must not expire before it
+ */
+func (c *ChainTx) checkTransaction(tx *types.Transaction, now int64) (err error) {
 	result, err := tx.VerifySignature()
 	if err != nil {
 		return err
@@ -389,12 +576,29 @@ func (c *ChainTx) CheckTransaction(tx *types.Transaction) (err error) {
 	if err := c.StateDB.CheckPermission(tx.From, tx.Permission, tx.Signatures); err != nil {
 		return err
 	}
+	if tx.Payer != 0 {
+		if err := c.StateDB.CheckPermission(tx.Payer, tx.PayerPermission, tx.Signatures); err != nil {
+			return errors.New(fmt.Sprintf("payer %s: %s", common.IndexToName(tx.Payer), err.Error()))
+		}
+	}
+	//the transactions of geneses block reference no block
+	if c.CurrentHeader != nil {
+		if err := c.recent.check(tx, now); err != nil {
+			return err
+		}
+		if chain := c.CurrentHeader.Chain(); !tx.ChainID.Equals(&chain) {
+			return errs.ErrChainIdMismatch
+		}
+	}
+	//the transaction of a future nonce is held by tx pool until it is executable
+	if nonce, err := c.StateDB.GetNonce(tx.From); err != nil {
+		return err
+	} else if tx.Nonce < nonce {
+		return errs.ErrNonceTooLow
+	}
 
 	switch tx.Type {
 	case types.TxTransfer:
-		if data, _ := c.TxsStore.Get(tx.Hash.Bytes()); data != nil {
-			return errs.ErrDuplicatedTx
-		}
 		if value, err := c.AccountGetBalance(tx.From, state.AbaToken); err != nil {
 			return err
 		} else if value.Sign() <= 0 {
@@ -402,14 +606,27 @@ func (c *ChainTx) CheckTransaction(tx *types.Transaction) (err error) {
 			return errs.ErrDoubleSpend
 		}
 	case types.TxDeploy:
-		if data, _ := c.TxsStore.Get(common.IndexToBytes(tx.Addr)); data != nil {
-			return errs.ErrDuplicatedTx
+		//upgrade the contract needs the owner permission
+		if upgrade, err := c.StateDB.HasContract(tx.From); err != nil {
+			return err
+		} else if upgrade && tx.Permission != state.Owner {
+			return errors.New("upgrade contract needs the owner permission")
 		}
-		//hash := c.StateDB.GetHashRoot()
-		//c.HandleTransaction(c, tx)
 	case types.TxInvoke:
-		if data, _ := c.TxsStore.Get(tx.Hash.Bytes()); data != nil {
-			return errs.ErrDuplicatedTx
+	case types.TxMulti:
+		payload, ok := tx.Payload.GetObject().(types.ActionList)
+		if !ok || len(payload.Actions) == 0 {
+			return errors.New("transaction type error[multi]")
+		}
+		//every action is authorized by its own account, the accounts created by the former actions are checked
+		//when the transaction is executed
+		for _, action := range payload.Actions {
+			if err := c.StateDB.CheckPermission(action.From, action.Permission, tx.Signatures); err != nil {
+				if _, e := c.StateDB.GetAccountByName(action.From); e != nil {
+					continue
+				}
+				return err
+			}
 		}
 	default:
 		return errors.New("check transaction unknown tx type")
@@ -439,8 +656,8 @@ func (c *ChainTx) StoreGet(index common.AccountName, key []byte) (value []byte,
 //func (c *ChainTx) SetResourceLimits(from, to common.AccountName, cpu, net float32) error {
 //	return c.StateDB.SetResourceLimits(from, to, cpu, net)
 //}
-func (c *ChainTx) SetContract(index common.AccountName, t types.VmType, des, code []byte) error {
-	return c.StateDB.SetContract(index, t, des, code)
+func (c *ChainTx) SetContract(index common.AccountName, t types.VmType, des, code []byte, abi *types.Abi) error {
+	return c.StateDB.SetContract(index, t, des, code, abi)
 }
 func (c *ChainTx) GetContract(index common.AccountName) (*types.DeployInfo, error) {
 	return c.StateDB.GetContract(index)
@@ -480,58 +697,384 @@ func (c *ChainTx) AccountSubBalance(index common.AccountName, token string, valu
 }
 
 /**
-*  @brief  handle transaction with transaction's type
-*  @param  ledger - the interface of ledger impl
+*  @brief  handle transaction with transaction's type, a failed contract execution is reverted and recorded
+*  in the receipt, the resource used is still charged to the payer of transaction or the contracts accepting
+*  to pay for their actions
+*  @param  s - the state which the transaction runs on
 *  @param  tx - a transaction
+*  @return the receipt of transaction, error if the transaction is invalid
+ */
+func (c *ChainTx) HandleTransaction(s *state.State, tx *types.Transaction) (*types.Receipt, error) {
+	return c.handleTransaction(s, tx, nil)
+}
+
+func (c *ChainTx) handleTransaction(s *state.State, tx *types.Transaction, tracer exec.Tracer) (*types.Receipt, error) {
+	if tx.Payer != 0 {
+		if err := s.CheckPermission(tx.Payer, tx.PayerPermission, tx.Signatures); err != nil {
+			return nil, errors.New(fmt.Sprintf("payer %s: %s", common.IndexToName(tx.Payer), err.Error()))
+		}
+	}
+	gasLimit, err := c.gasLimit(s, tx)
+	if err != nil {
+		return nil, err
+	}
+	//the nonce is increased even if the execution fails, the resources are charged
+	if err := s.IncreaseNonce(tx.From, tx.Nonce); err != nil {
+		return nil, err
+	}
+	var result *actionResult
+	if tx.Type == types.TxMulti {
+		result, err = c.handleActions(s, tx, tracer, gasLimit)
+	} else {
+		result, err = c.handleAction(s, tx, tracer, gasLimit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := tx.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	net := float32(len(data))
+	receipt := types.NewReceipt(tx.Hash, result.ret, float32(result.gasUsed)/float32(state.GasPerCpuMs), net, result.events, result.err)
+	//the cpu of actions accepted by contracts is paid by the contracts, the rest is paid by the payer of
+	//transaction, the net is paid by the payer of first action
+	gasUsed := result.gasUsed
+	for _, ch := range result.charges {
+		receipt.AddUsage(ch.payer, float32(ch.gasUsed)/float32(state.GasPerCpuMs), 0)
+		gasUsed -= ch.gasUsed
+	}
+	if gasUsed != 0 {
+		receipt.AddUsage(tx.Billed(), float32(gasUsed)/float32(state.GasPerCpuMs), 0)
+	}
+	if result.payer != 0 {
+		receipt.AddUsage(result.payer, 0, net)
+	} else {
+		receipt.AddUsage(tx.Billed(), 0, net)
+	}
+	for _, u := range receipt.Usage {
+		if err := s.SubResourceLimits(u.Account, u.Cpu, u.Net); err != nil {
+			log.Warn("charge transaction", tx.Hash.HexString(), "failed:", err)
+			return nil, errs.ErrTxChargeFailed
+		}
+	}
+	return receipt, nil
+}
+
+/**
+*  @brief  the gas budget of transaction, the billed account or an invoked contract must have the cpu for the whole
+*  budget before execution, the contracts may accept to pay by AbaAcceptCharge and the charge is checked after
+*  execution
+*  @return the gas limit of transaction, the limit of chain or the most cpu available to the accounts which may pay
+*  if it is 0, error if the limit is larger than them
  */
-func (c *ChainTx) HandleTransaction(s *state.State, tx *types.Transaction) (ret []byte, cpu, net float32, err error) {
-	start := time.Now().UnixNano()
+func (c *ChainTx) gasLimit(s *state.State, tx *types.Transaction) (uint64, error) {
+	if tx.GasLimit > state.TxGasLimit {
+		return 0, errors.New(fmt.Sprintf("the gas limit %d of transaction exceeds the limit %d of chain", tx.GasLimit, state.TxGasLimit))
+	}
+	cpu, _, err := s.RequireResources(tx.Billed())
+	if err != nil {
+		return 0, err
+	}
+	for _, contract := range invokedContracts(tx) {
+		//the contract may be created by a former action of transaction
+		if contractCpu, _, err := s.RequireResources(contract); err == nil && contractCpu > cpu {
+			cpu = contractCpu
+		}
+	}
+	var available uint64
+	if cpu > 0 {
+		available = uint64(cpu * float32(state.GasPerCpuMs))
+	}
+	if available == 0 {
+		return 0, errors.New(fmt.Sprintf("the account:%s cpu amount is not enough", common.IndexToName(tx.Billed())))
+	}
+	if tx.GasLimit == 0 {
+		if available > state.TxGasLimit {
+			return state.TxGasLimit, nil
+		}
+		return available, nil
+	}
+	if tx.GasLimit > available {
+		return 0, errors.New(fmt.Sprintf("the account:%s cpu amount is not enough for the gas limit %d", common.IndexToName(tx.Billed()), tx.GasLimit))
+	}
+	return tx.GasLimit, nil
+}
+
+//the contracts invoked by the transaction or its actions, they can accept to pay for the resources
+func invokedContracts(tx *types.Transaction) []common.AccountName {
+	switch tx.Type {
+	case types.TxInvoke:
+		return []common.AccountName{tx.Addr}
+	case types.TxMulti:
+		payload, ok := tx.Payload.GetObject().(types.ActionList)
+		if !ok {
+			return nil
+		}
+		var contracts []common.AccountName
+		for _, action := range payload.Actions {
+			if action.Type == types.TxInvoke {
+				contracts = append(contracts, action.Addr)
+			}
+		}
+		return contracts
+	default:
+		return nil
+	}
+}
+
+//the gas of an action paid by the contract which accepted to pay for it
+type charge struct {
+	payer   common.AccountName
+	gasUsed uint64
+}
+
+//the result of executing a transaction or an action
+type actionResult struct {
+	ret     []byte
+	gasUsed uint64
+	events  []*types.Event
+	//the contract which accepted to pay for the first action, 0 if the payer of transaction pays
+	payer common.AccountName
+	//the gas paid by the contracts, the rest is paid by the payer of transaction
+	charges []charge
+	//the failure of contract execution, the changes are reverted but the gas used is charged
+	err error
+}
+
+/**
+*  @brief  execute a transfer, deploy or invoke, an invalid transaction returns error, a failed contract
+*  execution is reverted and returned in the result
+*  @param  gasLimit - the gas budget of contract execution
+ */
+func (c *ChainTx) handleAction(s *state.State, tx *types.Transaction, tracer exec.Tracer, gasLimit uint64) (*actionResult, error) {
+	result := new(actionResult)
 	switch tx.Type {
 	case types.TxTransfer:
 		payload, ok := tx.Payload.GetObject().(types.TransferInfo)
 		if !ok {
-			return nil, 0, 0, errors.New("transaction type error[transfer]")
+			return nil, errors.New("transaction type error[transfer]")
 		}
 		if err := s.AccountSubBalance(tx.From, state.AbaToken, payload.Value); err != nil {
-			return nil, 0, 0, err
+			return nil, err
 		}
 		if err := s.AccountAddBalance(tx.Addr, state.AbaToken, payload.Value); err != nil {
-			return nil, 0, 0, err
+			return nil, err
 		}
+		result.gasUsed = state.GasTransfer
 	case types.TxDeploy:
-		if err := s.CheckPermission(tx.From, state.Active, tx.Signatures); err != nil {
-			return nil, 0, 0, err
+		perm := state.Active
+		if upgrade, err := s.HasContract(tx.From); err != nil {
+			return nil, err
+		} else if upgrade {
+			perm = state.Owner
+		}
+		if err := s.CheckPermission(tx.From, perm, tx.Signatures); err != nil {
+			return nil, err
 		}
 		payload, ok := tx.Payload.GetObject().(types.DeployInfo)
 		if !ok {
-			return nil, 0, 0, errors.New("transaction type error[deploy]")
+			return nil, errors.New("transaction type error[deploy]")
+		}
+		if payload.TypeVm == types.VmWasm {
+			if err := wasmservice.VerifyModule(payload.Code); err != nil {
+				return nil, err
+			}
+			if payload.Abi != nil {
+				if err := wasmservice.VerifyAbi(payload.Code, payload.Abi); err != nil {
+					return nil, err
+				}
+			}
 		}
-		if err := s.SetContract(tx.From, payload.TypeVm, payload.Describe, payload.Code); err != nil {
-			return nil, 0, 0, err
+		if err := s.SetContract(tx.From, payload.TypeVm, payload.Describe, payload.Code, payload.Abi); err != nil {
+			return nil, err
 		}
+		result.gasUsed = state.GasDeploy + uint64(len(payload.Code))*state.GasDeployByte
 	case types.TxInvoke:
-		service, err := smartcontract.NewContractService(s, tx)
+		service, err := smartcontract.NewTracedContractService(s, tx, tracer)
 		if err != nil {
-			return nil, 0, 0, err
+			return nil, err
 		}
-		ret, err = service.Execute()
+		snapshot, err := s.Snapshot()
 		if err != nil {
-			return nil, 0, 0, err
+			return nil, err
+		}
+		result.ret, result.gasUsed, result.err = service.Execute(gasLimit)
+		if result.err != nil {
+			log.Warn("invoke failed, revert the state, gas used:", result.gasUsed, result.err)
+			s.RevertToSnapshot(snapshot)
+			result.ret = nil
+		} else {
+			result.events = service.Events()
+			if payer := service.Payer(); payer != 0 {
+				result.payer = payer
+				result.charges = []charge{{payer: payer, gasUsed: result.gasUsed}}
+			}
 		}
 	default:
-		return nil, 0, 0, errors.New("the transaction's type error")
+		return nil, errors.New("the transaction's type error")
+	}
+	return result, nil
+}
+
+/**
+*  @brief  execute the actions of a multi-action transaction in order, the authorization of every action is
+*  checked, all actions are reverted if any of them fails
+*  @return the result of the last action and the events and gas of all actions
+ */
+func (c *ChainTx) handleActions(s *state.State, tx *types.Transaction, tracer exec.Tracer, gasLimit uint64) (*actionResult, error) {
+	payload, ok := tx.Payload.GetObject().(types.ActionList)
+	if !ok || len(payload.Actions) == 0 {
+		return nil, errors.New("transaction type error[multi]")
+	}
+	snapshot, err := s.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	result := new(actionResult)
+	for i, action := range payload.Actions {
+		if err := s.CheckPermission(action.From, action.Permission, tx.Signatures); err != nil {
+			s.RevertToSnapshot(snapshot)
+			return nil, errors.New(fmt.Sprintf("action %d: %s", i, err.Error()))
+		}
+		if result.gasUsed >= gasLimit {
+			s.RevertToSnapshot(snapshot)
+			return &actionResult{gasUsed: result.gasUsed, err: exec.ErrOutOfGas}, nil
+		}
+		r, err := c.handleAction(s, tx.ActionTransaction(action), tracer, gasLimit-result.gasUsed)
+		if err != nil {
+			s.RevertToSnapshot(snapshot)
+			return nil, errors.New(fmt.Sprintf("action %d: %s", i, err.Error()))
+		}
+		result.gasUsed += r.gasUsed
+		if r.err != nil {
+			log.Warn("action", i, "failed, revert all actions:", r.err)
+			s.RevertToSnapshot(snapshot)
+			return &actionResult{gasUsed: result.gasUsed, err: errors.New(fmt.Sprintf("action %d: %s", i, r.err.Error()))}, nil
+		}
+		result.ret = r.ret
+		result.events = append(result.events, r.events...)
+		if len(result.events) > wasmservice.MaxEvents {
+			s.RevertToSnapshot(snapshot)
+			return &actionResult{gasUsed: result.gasUsed, err: errors.New(fmt.Sprintf("the actions emit more than %d events", wasmservice.MaxEvents))}, nil
+		}
+		result.charges = append(result.charges, r.charges...)
+		if i == 0 {
+			result.payer = r.payer
+		}
+	}
+	return result, nil
+}
+
+/**
+*  @brief  take a read only copy of the head state for queries, the copy is not affected by the blocks saved later
+*  and can be read concurrently with the ledger
+ */
+func (c *ChainTx) QueryState() (*state.State, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	s, err := c.StateDB.CopyState()
+	if err != nil {
+		return nil, err
+	}
+	s.SetReadOnly()
+	s.SetBlockInfo(c.CurrentHeader.Height+1, time.Now().Unix())
+	return s, nil
+}
+
+/**
+*  @brief  execute a contract on a read only state returned by QueryState, the query fails if the method tries to
+*  change the state, the execution is not recorded
+*  @param  s - the read only state
+*  @param  tx - the invoke transaction of query, it is not signed
+*  @return the receipt of query, the cpu and net are the resources the transaction would use
+ */
+func (c *ChainTx) QueryContract(s *state.State, tx *types.Transaction) (*types.Receipt, error) {
+	if !s.ReadOnly() {
+		return nil, errors.New("the contract can only be queried on a read only state")
+	}
+	if tx.Type != types.TxInvoke {
+		return nil, errors.New("only the invoke transaction can be queried")
+	}
+	service, err := smartcontract.NewContractService(s, tx)
+	if err != nil {
+		return nil, err
+	}
+	gasLimit := state.TxGasLimit
+	if tx.GasLimit != 0 && tx.GasLimit < gasLimit {
+		gasLimit = tx.GasLimit
+	}
+	ret, gasUsed, execErr := service.Execute(gasLimit)
+	if execErr == state.ErrReadOnly {
+		return nil, errors.New("the contract can't change the state in a query")
+	}
+	var events []*types.Event
+	if execErr == nil {
+		events = service.Events()
+	} else {
+		ret = nil
 	}
-	end := time.Now().UnixNano()
-	cpu = float32(end-start) / 1000000
 	data, err := tx.Serialize()
 	if err != nil {
-		return nil, 0, 0, err
+		return nil, err
+	}
+	return types.NewReceipt(tx.Hash, ret, float32(gasUsed)/float32(state.GasPerCpuMs), float32(len(data)), events, execErr), nil
+}
+
+/**
+*  @brief  replay a stored transaction on the state of its parent block, the transactions before it in the same
+*  block are executed first, every wasm contract executed by the transaction reports to the tracer, the state
+*  of chain is not changed
+*  @param  hash - the hash of transaction
+*  @param  tracer - the tracer of execution
+*  @return the receipt of the replayed transaction
+ */
+func (c *ChainTx) ReplayTransaction(hash common.Hash, tracer exec.Tracer) (*types.Receipt, error) {
+	block, index, err := c.findTransaction(hash)
+	if err != nil {
+		return nil, err
+	}
+	if block.Height <= 1 {
+		return nil, errors.New("the transactions of geneses block can't be replayed")
+	}
+	data, err := c.HeaderStore.Get(block.PrevHash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	prev := new(types.Header)
+	if err := prev.Deserialize(data); err != nil {
+		return nil, err
+	}
+	s, err := c.StateDB.StateAt(prev.StateHash)
+	if err != nil {
+		return nil, err
+	}
+	s.SetBlockInfo(block.Height, block.TimeStamp)
+	for i := 0; i < index; i++ {
+		if _, err := c.HandleTransaction(s, block.Transactions[i]); err != nil {
+			return nil, err
+		}
+	}
+	return c.handleTransaction(s, block.Transactions[index], tracer)
+}
+
+/**
+*  @brief  find the block of transaction by the location index
+*  @return the block and the index of transaction in the block
+ */
+func (c *ChainTx) findTransaction(hash common.Hash) (*types.Block, int, error) {
+	location, err := c.GetTransactionLocation(hash)
+	if err != nil {
+		return nil, 0, err
+	}
+	block, err := c.GetBlock(location.BlockHash)
+	if err != nil {
+		return nil, 0, err
 	}
-	net = float32(len(data))
-	if err := s.SubResourceLimits(tx.From, cpu, net); err != nil {
-		return nil, 0, 0, err
+	if int(location.Index) >= len(block.Transactions) || !block.Transactions[location.Index].Hash.Equals(&hash) {
+		return nil, 0, errors.New(fmt.Sprintf("the location of transaction:%s is mismatch", hash.HexString()))
 	}
-	return ret, cpu, net, nil
+	return block, int(location.Index), nil
 }
 
 func (c *ChainTx) TokenExisted(token string) bool {
@@ -539,7 +1082,7 @@ func (c *ChainTx) TokenExisted(token string) bool {
 }
 
 func (c *ChainTx) TokenAllocation() error {
-	if err := c.StateDB.AccountAddBalance(state.IndexAbaRoot, state.AbaToken, new(big.Int).SetUint64(2100000)); err != nil {
+	if err := c.StateDB.IssueToken(state.AbaToken, state.IndexAbaRoot, new(big.Int).SetUint64(2100000)); err != nil {
 		return err
 	}
 	return nil
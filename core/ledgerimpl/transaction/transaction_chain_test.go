@@ -19,13 +19,19 @@ package transaction_test
 import (
 	"fmt"
 	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/common/config"
+	errs "github.com/ecoball/go-ecoball/common/errors"
 	"github.com/ecoball/go-ecoball/core/ledgerimpl"
 	"github.com/ecoball/go-ecoball/core/ledgerimpl/transaction"
+	"github.com/ecoball/go-ecoball/core/pb"
 	"github.com/ecoball/go-ecoball/core/state"
 	"github.com/ecoball/go-ecoball/core/types"
 	"github.com/ecoball/go-ecoball/smartcontract/wasmservice"
 	"github.com/ecoball/go-ecoball/test/example"
+	"math/big"
+	"os"
 	"testing"
+	"time"
 )
 
 func TestNewTransactionChain(t *testing.T) {
@@ -33,6 +39,9 @@ func TestNewTransactionChain(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	if err := txChain.GenesesBlockInit(); err != nil {
+		t.Fatal(err)
+	}
 	fmt.Println(txChain.CurrentHeader.Hash.HexString())
 	block, err := txChain.GetBlock(txChain.CurrentHeader.Hash)
 	if err != nil {
@@ -60,6 +69,17 @@ func TestBlockAdd(t *testing.T) {
 	}
 }
 
+//bind a transaction of the examples to the head of chain, the examples are signed without a reference block
+func referenceExample(t *testing.T, tx *types.Transaction, head *types.Header) {
+	tx.Signatures = nil
+	if err := tx.SetReference(head, time.Now().Unix()+100); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.SetSignature(&config.Root); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestLedgerTxAdd(t *testing.T) {
 	l, err := ledgerimpl.NewLedger("/tmp/quaker")
 	if err != nil {
@@ -68,7 +88,11 @@ func TestLedgerTxAdd(t *testing.T) {
 	fmt.Println("Start LedgerImpl Module, hash:", l.GetCurrentHeader().Hash.HexString())
 	example.ExampleAddAccount(l.StateDB())
 	tx := example.ExampleTestTx()
+	referenceExample(t, tx, l.GetCurrentHeader())
 	l.AccountAddBalance(tx.From, state.AbaToken, 150)
+	if err := l.StateDB().SetResourceLimits(tx.From, tx.From, 10, 10); err != nil {
+		t.Fatal(err)
+	}
 	var txs []*types.Transaction
 	txs = append(txs, tx)
 	conData := types.ConsensusData{Type: types.ConSolo, Payload: &types.SoloData{}}
@@ -91,35 +115,138 @@ func TestLedgerTxAdd(t *testing.T) {
 	fmt.Println("value:", value)
 }
 
+//the ledger actor is spawned once by a process, so the contract is deployed on a chain of transactions
 func TestLedgerDeployAdd(t *testing.T) {
-	l, err := ledgerimpl.NewLedger("/tmp/deploy")
+	l, err := transaction.NewTransactionChain("/tmp/deploy", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.GenesesBlockInit(); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("Start LedgerImpl Module, hash:", l.CurrentHeader.Hash.HexString())
+	example.ExampleAddAccount(l.StateDB)
+	if err := l.StateDB.AccountAddBalance(common.NameToIndex("from"), state.AbaToken, big.NewInt(100)); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.StateDB.SetResourceLimits(common.NameToIndex("from"), common.NameToIndex("from"), 10, 10); err != nil {
+		t.Fatal(err)
+	}
+	code, err := wasmservice.ReadWasm("../../../test/token/token.wasm")
 	if err != nil {
 		t.Fatal(err)
 	}
-	fmt.Println("Start LedgerImpl Module, hash:", l.GetCurrentHeader().Hash.HexString())
-	code, err := wasmservice.ReadWasm("../../../test/token.wasm")
 	tx := example.ExampleTestDeploy(code)
+	referenceExample(t, tx, l.CurrentHeader)
 	var txs []*types.Transaction
 	txs = append(txs, tx)
 	conData := types.ConsensusData{Type: types.ConSolo, Payload: &types.SoloData{}}
-	block, err := l.NewTxBlock(txs, conData)
+	block, err := l.NewBlock(nil, txs, conData)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := l.SaveTxBlock(block); err != nil {
+	if err := l.SaveBlock(block); err != nil {
 		t.Fatal(err)
 	}
 	//Invoke Contract
 	invoke := example.ExampleTestInvoke("create")
+	referenceExample(t, invoke, l.CurrentHeader)
 	var txs2 []*types.Transaction
 	txs2 = append(txs, invoke)
-	block, err = l.NewTxBlock(txs2, conData)
+	block, err = l.NewBlock(nil, txs2, conData)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := l.SaveTxBlock(block); err != nil {
+	if err := l.SaveBlock(block); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionReference(t *testing.T) {
+	os.RemoveAll("/tmp/tapos")
+	l, err := transaction.NewTransactionChain("/tmp/tapos", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.GenesesBlockInit(); err != nil {
+		t.Fatal(err)
+	}
+	newTransfer := func(value int64, expiration int64) *types.Transaction {
+		tx, err := types.NewTransfer(common.NameToIndex("root"), common.NameToIndex("delegate"), "active", big.NewInt(value), 0, time.Now().Unix())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.SetReference(l.CurrentHeader, expiration); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.SetSignature(&config.Root); err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	now := time.Now().Unix()
+	tx := newTransfer(1, now+100)
+	if err := l.CheckTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.CheckTransaction(newTransfer(2, now-1)); err != errs.ErrTxExpired {
+		t.Fatal("the expired transaction must be rejected:", err)
+	}
+	if err := l.CheckBlockTransaction(newTransfer(2, now-1), now-10); err != nil {
+		t.Fatal("the transaction of block is checked at the time of block:", err)
+	}
+	if err := l.CheckBlockTransaction(newTransfer(2, now+100), now+101); err != errs.ErrTxExpired {
+		t.Fatal("the transaction expired before the block must be rejected:", err)
+	}
+	if err := l.CheckTransaction(newTransfer(3, now+types.MaxTxLifetime+100)); err == nil {
+		t.Fatal("the expiration must be in MaxTxLifetime")
+	}
+	forged := newTransfer(4, now+100)
+	forged.RefBlockPrefix++
+	if err := l.CheckTransaction(forged); err == nil {
+		t.Fatal("the transaction of other fork must be rejected")
+	}
+	testnet := newTransfer(4, now+100)
+	testnet.ChainID = common.SingleHash([]byte("testnet"))
+	if err := l.CheckTransaction(testnet); err != errs.ErrChainIdMismatch {
+		t.Fatal("the transaction of other chain must be rejected:", err)
+	}
+
+	unreferenced, err := types.NewTransfer(common.NameToIndex("root"), common.NameToIndex("delegate"), "active", big.NewInt(6), 0, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unreferenced.SetSignature(&config.Root); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.CheckTransaction(unreferenced); err != errs.ErrTxNotReferenced {
+		t.Fatal("the transaction without reference block must be rejected:", err)
+	}
+
+	conData := types.ConsensusData{Type: types.ConSolo, Payload: &types.SoloData{}}
+	if _, err := l.NewBlock(nil, []*types.Transaction{tx, unreferenced}, conData); err == nil {
+		t.Fatal("the block with a transaction without reference block must fail")
+	}
+	block, err := l.NewBlock(nil, []*types.Transaction{tx, tx, newTransfer(5, now-1)}, conData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Transactions) != 1 {
+		t.Fatal("the duplicated and expired transactions must be dropped")
+	}
+	if err := l.SaveBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.CheckTransaction(tx); err != errs.ErrDuplicatedTx {
+		t.Fatal("the packed transaction must be rejected:", err)
+	}
+	genesis, err := l.GetBlockByHeight(1)
+	if err != nil {
 		t.Fatal(err)
 	}
+	if !block.ChainID.Equals(&genesis.Hash) || !tx.ChainID.Equals(&genesis.Hash) {
+		t.Fatal("the chain ID is the hash of geneses block")
+	}
 }
 
 /*
@@ -154,3 +281,731 @@ func TestLedgerInterfaceTokenCreate(t *testing.T) {
 
 }
 */
+
+func TestTransactionPayer(t *testing.T) {
+	os.RemoveAll("/tmp/payer")
+	c, err := transaction.NewTransactionChain("/tmp/payer", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.GenesesBlockInit(); err != nil {
+		t.Fatal(err)
+	}
+	root := common.NameToIndex("root")
+	delegate := common.NameToIndex("delegate")
+	tx, err := types.NewTransfer(delegate, root, "active", big.NewInt(1), 0, time.Now().Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.SetReference(c.CurrentHeader, time.Now().Unix()+100); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.SetPayer(root, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.SetSignature(&config.Delegate); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CheckTransaction(tx); err == nil {
+		t.Fatal("the payer must authorize the transaction")
+	}
+	if err := tx.SetSignature(&config.Root); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CheckTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.SetPayer(delegate, ""); err == nil {
+		t.Fatal("the payer of a signed transaction can't be changed")
+	}
+
+	data, err := tx.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(types.Transaction)
+	if err := decoded.Deserialize(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Payer != root || decoded.PayerPermission != "active" {
+		t.Fatal("the payer is not serialized")
+	}
+
+	s, err := c.StateDB.CopyState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetBlockInfo(c.CurrentHeader.Height+1, time.Now().Unix())
+	receipt, err := c.HandleTransaction(s, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipt.Usage) != 1 || receipt.Usage[0].Account != root || receipt.Usage[0].Cpu != receipt.Cpu || receipt.Usage[0].Net != receipt.Net {
+		t.Fatal("the resources must be billed to the payer:", receipt.JsonString())
+	}
+	sender, err := s.GetAccountByName(delegate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payer, err := s.GetAccountByName(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender.Cpu.Used != 0 || sender.Net.Used != 0 || payer.Net.Used != receipt.Net {
+		t.Fatal("the sender must not be billed")
+	}
+	data, err = receipt.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := new(types.Receipt)
+	if err := r.Deserialize(data); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Usage) != 1 || r.Usage[0].Account != root {
+		t.Fatal("the resource usage is not serialized")
+	}
+}
+
+func TestBlockIndex(t *testing.T) {
+	os.RemoveAll("/tmp/index")
+	c, err := transaction.NewTransactionChain("/tmp/index", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.GenesesBlockInit(); err != nil {
+		t.Fatal(err)
+	}
+	conData := types.ConsensusData{Type: types.ConSolo, Payload: &types.SoloData{}}
+	for i := 0; i < 3; i++ {
+		block, err := c.NewBlock(nil, nil, conData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.SaveBlock(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	head := c.CurrentHeader
+	block, err := c.GetBlockByHeight(head.Height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !block.Hash.Equals(&head.Hash) {
+		t.Fatal("the height index is mismatch")
+	}
+	if _, err := c.GetBlockByHeight(head.Height + 1); err == nil {
+		t.Fatal("the block above head must not be found")
+	}
+	blocks, err := c.GetBlocksByRange(2, head.Height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != int(head.Height-1) {
+		t.Fatal("the range must include both ends")
+	}
+	for i, b := range blocks {
+		if b.Height != uint64(i+2) {
+			t.Fatal("the blocks of range are not in order")
+		}
+	}
+	if _, err := c.GetBlocksByRange(1, transaction.MaxBlockRange+1); err == nil {
+		t.Fatal("the range must be limited")
+	}
+
+	c.CurrentHeader = nil
+	existed, err := c.RestoreCurrentHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !existed || !c.CurrentHeader.Hash.Equals(&head.Hash) {
+		t.Fatal("the head pointer is not persisted")
+	}
+}
+
+func TestTransactionInclusion(t *testing.T) {
+	os.RemoveAll("/tmp/inclusion")
+	c, err := transaction.NewTransactionChain("/tmp/inclusion", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.GenesesBlockInit(); err != nil {
+		t.Fatal(err)
+	}
+	var txs []*types.Transaction
+	for i := 0; i < 3; i++ {
+		tx, err := types.NewTransfer(common.NameToIndex("root"), common.NameToIndex("delegate"), "active", big.NewInt(1), uint64(i), time.Now().Unix())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.SetReference(c.CurrentHeader, time.Now().Unix()+100); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.SetSignature(&config.Root); err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, tx)
+	}
+	conData := types.ConsensusData{Type: types.ConSolo, Payload: &types.SoloData{}}
+	block, err := c.NewBlock(nil, txs, conData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Transactions) != len(txs) {
+		t.Fatal("the transactions must be packed")
+	}
+	if err := c.SaveBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	for i, tx := range block.Transactions {
+		inclusion, err := c.GetTransactionInclusion(tx.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !inclusion.Location.BlockHash.Equals(&block.Hash) || inclusion.Location.Height != block.Height || inclusion.Location.Index != uint32(i) {
+			t.Fatal("the location is mismatch:", inclusion.Location)
+		}
+		if !inclusion.MerkleHash.Equals(&block.MerkleHash) || !inclusion.Verify() {
+			t.Fatal("the merkle proof is invalid")
+		}
+		if !inclusion.Receipt.TxHash.Equals(&tx.Hash) {
+			t.Fatal("the receipt is mismatch")
+		}
+	}
+	if _, err := c.GetTransactionInclusion(common.SingleHash([]byte("unknown"))); err == nil {
+		t.Fatal("the transaction not packed must not be found")
+	}
+}
+
+func TestAtomicCommit(t *testing.T) {
+	os.RemoveAll("/tmp/journal")
+	c, err := transaction.NewTransactionChain("/tmp/journal", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.GenesesBlockInit(); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := types.NewTransfer(common.NameToIndex("root"), common.NameToIndex("delegate"), "active", big.NewInt(1), 0, time.Now().Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.SetReference(c.CurrentHeader, time.Now().Unix()+100); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.SetSignature(&config.Root); err != nil {
+		t.Fatal(err)
+	}
+	conData := types.ConsensusData{Type: types.ConSolo, Payload: &types.SoloData{}}
+	block, err := c.NewBlock(nil, []*types.Transaction{tx}, conData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SaveBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	balance, err := c.AccountGetBalance(common.NameToIndex("delegate"), state.AbaToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if has, _ := c.BlockStore.Has([]byte("journal")); has {
+		t.Fatal("the journal must be removed after commit")
+	}
+
+	//the state of head block is loaded after restart
+	c.Close()
+	c, err = transaction.NewTransactionChain("/tmp/journal", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.CurrentHeader.Hash.Equals(&block.Hash) {
+		t.Fatal("the head is not restored")
+	}
+	if err := c.VerifyDB(); err != nil {
+		t.Fatal(err)
+	}
+	value, err := c.AccountGetBalance(common.NameToIndex("delegate"), state.AbaToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.Cmp(balance) != 0 {
+		t.Fatal("the state is not persisted, balance:", value)
+	}
+
+	//crash after the journal is persisted and only the transaction store is written, the header and head pointer
+	//are lost and recovered by replaying the journal on startup
+	header, err := c.HeaderStore.Get(block.Hash.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	j := &pb.Journal{BlockHash: block.Hash.Bytes(), Entries: []*pb.JournalEntry{
+		{Store: 1, Key: block.Hash.Bytes(), Value: header},
+		{Store: 0, Key: []byte("head"), Value: block.Hash.Bytes()},
+	}}
+	data, err := j.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.BlockStore.Put([]byte("journal"), data); err != nil {
+		t.Fatal(err)
+	}
+	c.HeaderStore.Delete(block.Hash.Bytes())
+	c.BlockStore.Delete([]byte("head"))
+	c.Close()
+	c, err = transaction.NewTransactionChain("/tmp/journal", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.CurrentHeader.Hash.Equals(&block.Hash) {
+		t.Fatal("the journal is not replayed")
+	}
+	if has, _ := c.BlockStore.Has([]byte("journal")); has {
+		t.Fatal("the journal must be removed after replay")
+	}
+	if err := c.VerifyDB(); err != nil {
+		t.Fatal(err)
+	}
+
+	//a lost state root can't be opened on startup
+	if err := c.StateDB.Store().Delete(block.StateHash.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	if _, err := transaction.NewTransactionChain("/tmp/journal", nil); err == nil {
+		t.Fatal("the lost state must fail the startup")
+	}
+}
+
+func TestStatePruning(t *testing.T) {
+	os.RemoveAll("/tmp/prune")
+	c, err := transaction.NewTransactionChain("/tmp/prune", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.GenesesBlockInit(); err != nil {
+		t.Fatal(err)
+	}
+	conData := types.ConsensusData{Type: types.ConSolo, Payload: &types.SoloData{}}
+	var nonce uint64
+	addBlocks := func(n int) {
+		for i := 0; i < n; i++ {
+			tx, err := types.NewTransfer(common.NameToIndex("root"), common.NameToIndex("delegate"), "active", big.NewInt(1), nonce, time.Now().Unix())
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce++
+			if err := tx.SetReference(c.CurrentHeader, time.Now().Unix()+100); err != nil {
+				t.Fatal(err)
+			}
+			if err := tx.SetSignature(&config.Root); err != nil {
+				t.Fatal(err)
+			}
+			block, err := c.NewBlock(nil, []*types.Transaction{tx}, conData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.SaveBlock(block); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	stateLoaded := func(height uint64) bool {
+		block, err := c.GetBlockByHeight(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := c.StateDB.StateAt(block.StateHash)
+		if err != nil {
+			return false
+		}
+		_, err = s.VerifyTrie()
+		return err == nil
+	}
+
+	//archive keeps all states, then the offline pruning keeps the last one
+	addBlocks(3)
+	head := c.CurrentHeader.Height
+	for h := uint64(1); h <= head; h++ {
+		if !stateLoaded(h) {
+			t.Fatal("the archive must keep the state of block", h)
+		}
+	}
+	nodes, err := c.PruneState(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodes == 0 || stateLoaded(head-1) || !stateLoaded(head) {
+		t.Fatal("only the state of head must be kept, pruned nodes:", nodes)
+	}
+	if err := c.VerifyDB(); err != nil {
+		t.Fatal(err)
+	}
+
+	//the states out of retention are pruned online, the counts rebuilt offline are continued
+	config.StateRetention = 2
+	defer func() { config.StateRetention = 0 }()
+	c.Close()
+	c, err = transaction.NewTransactionChain("/tmp/prune", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addBlocks(3)
+	head = c.CurrentHeader.Height
+	if !stateLoaded(head) || !stateLoaded(head-1) {
+		t.Fatal("the states of retention must be kept")
+	}
+	for h := uint64(1); h <= head-2; h++ {
+		if stateLoaded(h) {
+			t.Fatal("the state of block", h, "must be pruned")
+		}
+	}
+	c.Close()
+	c, err = transaction.NewTransactionChain("/tmp/prune", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.VerifyDB(); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+}
+
+func TestRejectedBlock(t *testing.T) {
+	os.RemoveAll("/tmp/rejected")
+	c, err := transaction.NewTransactionChain("/tmp/rejected", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.GenesesBlockInit(); err != nil {
+		t.Fatal(err)
+	}
+	root := c.StateDB.GetHashRoot()
+	tx, err := types.NewTransfer(common.NameToIndex("root"), common.NameToIndex("delegate"), "active", big.NewInt(1), 0, time.Now().Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.SetReference(c.CurrentHeader, time.Now().Unix()+100); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.SetSignature(&config.Root); err != nil {
+		t.Fatal(err)
+	}
+	conData := types.ConsensusData{Type: types.ConSolo, Payload: &types.SoloData{}}
+	block, err := c.NewBlock(nil, []*types.Transaction{tx}, conData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the transactions of a rejected block must not change the state
+	receiptHash := block.ReceiptHash
+	block.ReceiptHash = common.Hash{}
+	if err := c.SaveBlock(block); err == nil {
+		t.Fatal("the block with wrong receipts root must be rejected")
+	}
+	if current := c.StateDB.GetHashRoot(); !current.Equals(&root) {
+		t.Fatal("the state is not reverted:", current.HexString())
+	}
+	block.ReceiptHash = receiptHash
+	stateHash := block.StateHash
+	block.StateHash = common.Hash{}
+	if err := c.SaveBlock(block); err == nil {
+		t.Fatal("the block with wrong state root must be rejected")
+	}
+	if current := c.StateDB.GetHashRoot(); !current.Equals(&root) {
+		t.Fatal("the state is not reverted:", current.HexString())
+	}
+	block.StateHash = stateHash
+	if err := c.SaveBlock(block); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionGasLimit(t *testing.T) {
+	os.RemoveAll("/tmp/gas_limit")
+	c, err := transaction.NewTransactionChain("/tmp/gas_limit", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.GenesesBlockInit(); err != nil {
+		t.Fatal(err)
+	}
+	root := common.NameToIndex("root")
+	delegate := common.NameToIndex("delegate")
+	s, err := c.StateDB.CopyState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetBlockInfo(c.CurrentHeader.Height+1, time.Now().Unix())
+	newTransfer := func(from common.AccountName, gasLimit uint64) *types.Transaction {
+		tx, err := types.NewTransfer(from, delegate, "active", big.NewInt(1), 0, time.Now().Unix())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.SetReference(c.CurrentHeader, time.Now().Unix()+100); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.SetGasLimit(gasLimit); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.SetSignature(&config.Root); err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+
+	tx := newTransfer(root, state.TxGasLimit+1)
+	if _, err := c.HandleTransaction(s, tx); err == nil {
+		t.Fatal("the gas limit can't exceed the limit of chain")
+	}
+	//an account without staked cpu
+	worker := common.NameToIndex("worker")
+	if _, err := s.AddAccount(worker, common.AddressFromPubKey(config.Worker1.PublicKey)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.HandleTransaction(s, newTransfer(worker, 0)); err == nil {
+		t.Fatal("the billed account must have cpu for the gas limit")
+	}
+	if _, err := c.HandleTransaction(s, newTransfer(worker, state.GasTransfer)); err == nil {
+		t.Fatal("the gas limit can't exceed the cpu available to the billed account")
+	}
+	if err := tx.SetGasLimit(state.GasTransfer); err == nil {
+		t.Fatal("the gas limit of a signed transaction can't be changed")
+	}
+
+	tx = newTransfer(root, state.GasTransfer)
+	data, err := tx.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(types.Transaction)
+	if err := decoded.Deserialize(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.GasLimit != state.GasTransfer || !decoded.Hash.Equals(&tx.Hash) {
+		t.Fatal("the gas limit is not serialized")
+	}
+	if _, err := c.HandleTransaction(s, tx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionCharge(t *testing.T) {
+	os.RemoveAll("/tmp/charge")
+	c, err := transaction.NewTransactionChain("/tmp/charge", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.GenesesBlockInit(); err != nil {
+		t.Fatal(err)
+	}
+	root := common.NameToIndex("root")
+	sponsor := common.NameToIndex("sponsor")
+	worker := common.NameToIndex("worker")
+	//the method pay calls AbaAcceptCharge and free returns 0 without accepting
+	code := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+		0x01, 0x05, 0x01, 0x60, 0x00, 0x01, 0x7f,
+		0x02, 0x17, 0x01, 0x03, 'e', 'n', 'v', 0x0f, 'A', 'b', 'a', 'A', 'c', 'c', 'e', 'p', 't', 'C', 'h', 'a', 'r', 'g', 'e', 0x00, 0x00,
+		0x03, 0x03, 0x02, 0x00, 0x00,
+		0x05, 0x03, 0x01, 0x00, 0x01,
+		0x07, 0x0e, 0x02, 0x03, 'p', 'a', 'y', 0x00, 0x01, 0x04, 'f', 'r', 'e', 'e', 0x00, 0x02,
+		0x0a, 0x0b, 0x02, 0x04, 0x00, 0x10, 0x00, 0x0b, 0x04, 0x00, 0x41, 0x00, 0x0b}
+	if err := wasmservice.VerifyModule(code); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.StateDB.AddAccount(sponsor, common.AddressFromPubKey(config.Delegate.PublicKey)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.StateDB.SetContract(sponsor, types.VmWasm, []byte("sponsor"), code, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.StateDB.SetResourceLimits(root, sponsor, 100, 100); err != nil {
+		t.Fatal(err)
+	}
+	//the sender has no staked cpu, the contract must be able to pay for it
+	if _, err := c.StateDB.AddAccount(worker, common.AddressFromPubKey(config.Worker1.PublicKey)); err != nil {
+		t.Fatal(err)
+	}
+	newInvoke := func(method string, nonce uint64) *types.Transaction {
+		tx, err := types.NewInvokeContract(worker, sponsor, "active", method, nil, nonce, time.Now().Unix())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.SetReference(c.CurrentHeader, time.Now().Unix()+100); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.SetSignature(&config.Worker1); err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	conData := types.ConsensusData{Type: types.ConSolo, Payload: &types.SoloData{}}
+
+	//the sender can't pay and the contract doesn't accept, only the transaction is rejected
+	block, err := c.NewBlock(nil, []*types.Transaction{newInvoke("free", 0)}, conData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Transactions) != 0 {
+		t.Fatal("the transaction which can't be charged must be left out of the block")
+	}
+
+	block, err = c.NewBlock(nil, []*types.Transaction{newInvoke("pay", 0)}, conData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Transactions) != 1 {
+		t.Fatal("the transaction paid by the contract must be in the block")
+	}
+	if err := c.SaveBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := c.GetReceipt(block.Transactions[0].Hash.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipt.Usage) != 1 || receipt.Usage[0].Account != sponsor {
+		t.Fatal("the resources must be billed to the contract:", receipt.JsonString())
+	}
+	sender, err := c.StateDB.GetAccountByName(worker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender.Cpu.Used != 0 || sender.Net.Used != 0 {
+		t.Fatal("the sender must not be billed")
+	}
+}
+
+func TestMultiAction(t *testing.T) {
+	os.RemoveAll("/tmp/multi_action")
+	c, err := transaction.NewTransactionChain("/tmp/multi_action", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.GenesesBlockInit(); err != nil {
+		t.Fatal(err)
+	}
+	root := common.NameToIndex("root")
+	delegate := common.NameToIndex("delegate")
+	s, err := c.StateDB.CopyState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetBlockInfo(c.CurrentHeader.Height+1, time.Now().Unix())
+	newTransfer := func(from, to common.AccountName, value *big.Int) *types.Action {
+		action, err := types.NewAction(types.TxTransfer, from, to, "active", types.NewTransferInfo(value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return action
+	}
+	newMulti := func(actions ...*types.Action) *types.Transaction {
+		nonce, err := s.GetNonce(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx, err := types.NewMultiAction(root, "active", actions, nonce, time.Now().Unix())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.SetReference(c.CurrentHeader, time.Now().Unix()+100); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.SetSignature(&config.Root); err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	balance := func(index common.AccountName) *big.Int {
+		value, err := s.AccountGetBalance(index, state.AbaToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+	rootBalance, delegateBalance := balance(root), balance(delegate)
+
+	//the second action fails, the first one must be rolled back
+	overdraft := new(big.Int).Add(rootBalance, big.NewInt(1))
+	tx := newMulti(newTransfer(root, delegate, big.NewInt(1)), newTransfer(root, delegate, overdraft))
+	if receipt, err := c.HandleTransaction(s, tx); err == nil && receipt.Status != types.ReceiptFailed {
+		t.Fatal("the transaction with a failed action must fail")
+	}
+	if balance(root).Cmp(rootBalance) != 0 || balance(delegate).Cmp(delegateBalance) != 0 {
+		t.Fatal("the first action must be rolled back:", balance(root), balance(delegate))
+	}
+
+	//the second action is authorized by delegate, the signature of root is not enough
+	tx = newMulti(newTransfer(root, delegate, big.NewInt(2)), newTransfer(delegate, root, big.NewInt(1)))
+	if _, err := c.HandleTransaction(s, tx); err == nil {
+		t.Fatal("every action must be authorized by its own account")
+	}
+	if balance(root).Cmp(rootBalance) != 0 || balance(delegate).Cmp(delegateBalance) != 0 {
+		t.Fatal("the unauthorized transaction must not change the balances")
+	}
+	tx = newMulti(newTransfer(root, delegate, big.NewInt(2)), newTransfer(delegate, root, big.NewInt(1)))
+	if err := tx.SetSignature(&config.Delegate); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.HandleTransaction(s, tx); err != nil {
+		t.Fatal(err)
+	}
+	if balance(root).Cmp(new(big.Int).Sub(rootBalance, big.NewInt(1))) != 0 || balance(delegate).Cmp(new(big.Int).Add(delegateBalance, big.NewInt(1))) != 0 {
+		t.Fatal("the actions are not executed:", balance(root), balance(delegate))
+	}
+}
+
+func TestBlockChainID(t *testing.T) {
+	os.RemoveAll("/tmp/chain_id")
+	c, err := transaction.NewTransactionChain("/tmp/chain_id", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.GenesesBlockInit(); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Unix()
+	tx, err := types.NewTransfer(common.NameToIndex("root"), common.NameToIndex("delegate"), "active", big.NewInt(1), 0, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//the client binds the transaction with the chain information queried from node
+	if err := tx.SetChainReference(c.CurrentHeader.Chain(), c.CurrentHeader.Height, c.CurrentHeader.Hash, now+100); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.SetSignature(&config.Root); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CheckTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+	conData := types.ConsensusData{Type: types.ConSolo, Payload: &types.SoloData{}}
+	block, err := c.NewBlock(nil, []*types.Transaction{tx}, conData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the same block signed for another chain
+	foreign := &types.Block{Header: new(types.Header), CountTxs: block.CountTxs, Transactions: block.Transactions}
+	*foreign.Header = *block.Header
+	foreign.ChainID = common.SingleHash([]byte("testnet"))
+	foreign.Signatures = nil
+	if err := foreign.InitializeHash(); err != nil {
+		t.Fatal(err)
+	}
+	if err := foreign.SetSignature(&config.Root); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.VerifyTxBlock(foreign); err != errs.ErrChainIdMismatch {
+		t.Fatal("the block of other chain must be rejected:", err)
+	}
+	if err := c.SaveBlock(foreign); err != errs.ErrChainIdMismatch {
+		t.Fatal("the block of other chain must not be saved:", err)
+	}
+	if err := c.SaveBlock(block); err != nil {
+		t.Fatal(err)
+	}
+}
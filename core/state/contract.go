@@ -0,0 +1,197 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/pb"
+	"github.com/ecoball/go-ecoball/core/types"
+)
+
+// the number of blocks between an upgrade of contract and the height it takes effect, 0 means immediately
+const ParamUpgradeDelay = "contract_upgrade_delay"
+
+// the prefix of the key of contract code in the state trie, the code is keyed by its hash
+var codePrefix = []byte("code")
+
+type ContractVersion struct {
+	Version  uint32       `json:"version"`
+	Height   uint64       `json:"height"`
+	TypeVm   types.VmType `json:"typeVm"`
+	Describe []byte       `json:"describe"`
+	CodeHash common.Hash  `json:"codeHash"`
+	Abi      *types.Abi   `json:"abi"`
+}
+
+func (v *ContractVersion) ProtoBuf() *pb.ContractVersion {
+	return &pb.ContractVersion{
+		Version:  v.Version,
+		Height:   v.Height,
+		TypeVm:   uint32(v.TypeVm),
+		Describe: common.CopyBytes(v.Describe),
+		CodeHash: v.CodeHash.Bytes(),
+		Abi:      v.Abi.ProtoBuf(),
+	}
+}
+
+func NewContractVersionFromProto(p *pb.ContractVersion) ContractVersion {
+	return ContractVersion{
+		Version:  p.Version,
+		Height:   p.Height,
+		TypeVm:   types.VmType(p.TypeVm),
+		Describe: common.CopyBytes(p.Describe),
+		CodeHash: common.NewHash(p.CodeHash),
+		Abi:      types.NewAbiFromProto(p.Abi),
+	}
+}
+
+func codeKey(hash common.Hash) []byte {
+	return append(common.CopyBytes(codePrefix), hash.Bytes()...)
+}
+
+/**
+ *  @brief store the code of contract in the state trie, the same code is only stored once
+ *  @param code - the code of contract
+ *  @return the hash of code
+ */
+func (s *State) SetCode(code []byte) (common.Hash, error) {
+	if len(code) == 0 {
+		return common.Hash{}, nil
+	}
+	if s.readOnly {
+		return common.Hash{}, ErrReadOnly
+	}
+	hash := common.SingleHash(code)
+	data, err := s.trie.TryGet(codeKey(hash))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if data != nil {
+		return hash, nil
+	}
+	if err := s.trie.TryUpdate(codeKey(hash), code); err != nil {
+		return common.Hash{}, err
+	}
+	return hash, nil
+}
+
+/**
+ *  @brief get the code of contract by hash
+ *  @param hash - the hash of code, the empty hash returns nil code
+ */
+func (s *State) GetCode(hash common.Hash) ([]byte, error) {
+	if hash.IsNil() {
+		return nil, nil
+	}
+	code, err := s.trie.TryGet(codeKey(hash))
+	if err != nil {
+		return nil, err
+	}
+	if code == nil {
+		return nil, errors.New(fmt.Sprintf("no contract code of hash:%s", hash.HexString()))
+	}
+	return code, nil
+}
+
+/**
+ *  @brief store the smart contract of account, the first deployment takes effect immediately, an upgrade
+ *  takes effect after the blocks of ParamUpgradeDelay
+ *  @param index - account's index
+ *  @param t - the virtual machine type
+ *  @param des - the description of contract
+ *  @param code - the code of contract
+ *  @param abi - the ABI of contract, nil if not provided
+ */
+func (s *State) SetContract(index common.AccountName, t types.VmType, des, code []byte, abi *types.Abi) error {
+	acc, err := s.GetAccountByName(index)
+	if err != nil {
+		return err
+	}
+	hash, err := s.SetCode(code)
+	if err != nil {
+		return err
+	}
+	height := s.height
+	if len(acc.Versions) != 0 {
+		delay, err := s.GetParam(ParamUpgradeDelay)
+		if err != nil {
+			return err
+		}
+		height += delay
+	}
+	if err := acc.SetContract(t, des, hash, abi, height); err != nil {
+		return err
+	}
+	return s.CommitAccount(acc)
+}
+
+/**
+ *  @brief get the contract of account in effect at the height of state
+ *  @param index - account's index
+ */
+func (s *State) GetContract(index common.AccountName) (*types.DeployInfo, error) {
+	return s.GetContractAt(index, s.height)
+}
+
+/**
+ *  @brief get the contract of account in effect at a block height, explorers use it to find the code
+ *  executed by a past block
+ *  @param index - account's index
+ *  @param height - the block height
+ */
+func (s *State) GetContractAt(index common.AccountName, height uint64) (*types.DeployInfo, error) {
+	acc, err := s.GetAccountByName(index)
+	if err != nil {
+		return nil, err
+	}
+	version, err := acc.ContractAt(height)
+	if err != nil {
+		return nil, err
+	}
+	code, err := s.GetCode(version.CodeHash)
+	if err != nil {
+		return nil, err
+	}
+	return &types.DeployInfo{TypeVm: version.TypeVm, Describe: common.CopyBytes(version.Describe), Code: code, Abi: version.Abi}, nil
+}
+
+/**
+ *  @brief list all versions of contract deployed on account, including the ones not in effect yet
+ *  @param index - account's index
+ */
+func (s *State) ContractVersions(index common.AccountName) ([]ContractVersion, error) {
+	acc, err := s.GetAccountByName(index)
+	if err != nil {
+		return nil, err
+	}
+	return acc.Versions, nil
+}
+
+/**
+ *  @brief check if the account has deployed any contract, an upgrade needs the owner permission
+ *  @param index - account's index
+ */
+func (s *State) HasContract(index common.AccountName) (bool, error) {
+	acc, err := s.GetAccountByName(index)
+	if err != nil {
+		return false, err
+	}
+	return len(acc.Versions) != 0, nil
+}
@@ -35,6 +35,7 @@ const (
 type Database interface {
 	OpenTrie(root common.Hash) (Trie, error)
 	OpenStorageTrie(addrHash, root common.Hash) (Trie, error)
+	OpenOrderedTrie(root common.Hash) (Trie, error)
 	CopyTrie(Trie) Trie
 	ContractCode(addrHash, codeHash common.Hash) ([]byte, error)
 	ContractCodeSize(addrHash, codeHash common.Hash) (int, error)
@@ -104,12 +105,23 @@ func (db *cachingDB) OpenStorageTrie(addrHash, root common.Hash) (Trie, error) {
 	return trie.NewSecure(root, db.db, 0)
 }
 
+//the ordered trie is keyed by the original keys, so the keys of contract storage can be iterated in byte order
+func (db *cachingDB) OpenOrderedTrie(root common.Hash) (Trie, error) {
+	tr, err := trie.New(root, db.db)
+	if err != nil {
+		return nil, err
+	}
+	return orderedTrie{tr}, nil
+}
+
 func (db *cachingDB) CopyTrie(t Trie) Trie {
 	switch t := t.(type) {
 	case cachedTrie:
 		return cachedTrie{t.SecureTrie.Copy(), db}
 	case *trie.SecureTrie:
 		return t.Copy()
+	case orderedTrie:
+		return orderedTrie{t.Trie.Copy()}
 	default:
 		panic(fmt.Errorf("unknown trie type %T", t))
 	}
@@ -147,3 +159,12 @@ func (m cachedTrie) Commit(onLeaf trie.LeafCallback) (common.Hash, error) {
 func (m cachedTrie) Prove(key []byte, fromLevel uint, proofDb store.Putter) error {
 	return m.SecureTrie.Prove(key, fromLevel, proofDb)
 }
+
+//the trie of contract storage keyed by the original keys, the key of an entry is the key itself
+type orderedTrie struct {
+	*trie.Trie
+}
+
+func (t orderedTrie) GetKey(key []byte) []byte {
+	return key
+}
@@ -131,6 +131,24 @@ func (s *State) CheckPermission(index common.AccountName, name string, signature
 	return acc.CheckPermission(s, name, signatures)
 }
 
+/**
+ *  @brief check that the contract is authorized by the permission of account, the actions sent by a contract carry
+ *  no signature, the contract can act for itself or for the accounts which list it in the permission with enough weight
+ *  @param index - the account index
+ *  @param name - the permission name
+ *  @param contract - the contract sending the action
+ */
+func (s *State) CheckContractPermission(index common.AccountName, name string, contract common.AccountName) error {
+	if index == contract {
+		return nil
+	}
+	acc, err := s.GetAccountByName(index)
+	if err != nil {
+		return err
+	}
+	return acc.CheckContractPermission(name, contract)
+}
+
 /**
  *  @brief search the permission by name, return json array string
  *  @param index - the account index
@@ -178,6 +196,27 @@ func (a *Account) CheckPermission(state *State, name string, signatures []common
 	return nil
 }
 
+/**
+ *  @brief check that the contract is listed in the permission or its parent with the weight of threshold
+ *  @param name - the permission name
+ *  @param contract - the contract sending the action
+ */
+func (a *Account) CheckContractPermission(name string, contract common.AccountName) error {
+	perm, ok := a.Permissions[name]
+	if !ok {
+		return errors.New(fmt.Sprintf("can't find this permission in account:%s", name))
+	}
+	if "" != perm.Parent {
+		if err := a.CheckContractPermission(perm.Parent, contract); err == nil {
+			return nil
+		}
+	}
+	if acc, ok := perm.Accounts[contract.String()]; ok && acc.Weight >= perm.Threshold {
+		return nil
+	}
+	return errors.New(fmt.Sprintf("account:%s permission %s is not granted to contract %s", common.IndexToName(a.Index), name, common.IndexToName(contract)))
+}
+
 /**
  *  @brief get the permission information by name, return json string
  *  @param name - the permission name
@@ -0,0 +1,141 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/store"
+	"github.com/ecoball/go-ecoball/core/trie"
+)
+
+// the number of deletions written in a batch by offline pruning
+const pruneBatchSize = 10000
+
+/**
+ *  @brief delete the trie nodes not reachable from the states kept, include the nodes of account storage, and
+ *  rebuild the reference counts of the kept nodes so they are pruned online later, the database is compacted
+ *  afterwards. It must be run offline
+ *  @param from - the height of the first state kept
+ *  @param roots - the state roots of the blocks kept in order of height
+ *  @return the number of deleted trie nodes
+ */
+func (s *State) Prune(from uint64, roots []common.Hash) (int, error) {
+	batch := s.diskDb.NewBatch()
+	refs := s.db.TrieDB().RebuildRefCounter(batch)
+	for i, root := range roots {
+		if err := refs.Reference(root); err != nil {
+			return 0, errors.New(fmt.Sprintf("the state of block %d is incomplete: %s", from+uint64(i), err.Error()))
+		}
+	}
+
+	//a storage root is referenced by the first state kept and by every state replacing the root before
+	accounts, err := s.storageAccounts()
+	if err != nil {
+		return 0, err
+	}
+	storageRefs := make(map[common.AccountName]*trie.RefCounter)
+	for _, index := range accounts {
+		storageRefs[index] = s.storage.get(index).TrieDB().RebuildRefCounter(store.NewTableBatch(batch, storagePrefix(index)))
+	}
+	replaced := make(map[uint64][]storageRoot)
+	last := make(map[common.AccountName]common.Hash)
+	for i, root := range roots {
+		st, err := s.StateAt(root)
+		if err != nil {
+			return 0, err
+		}
+		height := from + uint64(i)
+		for _, index := range accounts {
+			var hash common.Hash
+			if acc, err := st.GetAccountByName(index); err == nil {
+				hash = acc.Hash
+			}
+			if prev, ok := last[index]; ok && prev == hash {
+				continue
+			} else if ok {
+				replaced[height] = append(replaced[height], storageRoot{Index: index, Root: prev})
+			}
+			last[index] = hash
+			if err := storageRefs[index].Reference(hash); err != nil {
+				return 0, errors.New(fmt.Sprintf("the storage of account %s is incomplete: %s", common.IndexToName(index), err.Error()))
+			}
+		}
+	}
+	if err := refs.Write(); err != nil {
+		return 0, err
+	}
+	for _, r := range storageRefs {
+		if err := r.Write(); err != nil {
+			return 0, err
+		}
+	}
+	it := s.diskDb.NewIteratorWithPrefix(replacedStoragePrefix)
+	for it.Next() {
+		batch.Delete(common.CopyBytes(it.Key()))
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return 0, err
+	}
+	for height, roots := range replaced {
+		batch.Put(replacedStorageKey(height), encodeStorageRoots(roots))
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	batch.Reset()
+
+	//the nodes are keyed by their hashes, the counts of the deleted nodes are removed with them
+	var deleted, pending int
+	it = s.diskDb.NewIterator()
+	for it.Next() {
+		key := it.Key()
+		counter := refs
+		if bytes.HasPrefix(key, storageKeyPrefix) && len(key) > len(storageKeyPrefix)+8 {
+			counter = storageRefs[common.IndexSetBytes(key[len(storageKeyPrefix):len(storageKeyPrefix)+8])]
+			key = key[len(storageKeyPrefix)+8:]
+		}
+		hash, isNode, ok := trie.NodeOfKey(key)
+		if !ok || counter == nil || counter.Counted(hash) {
+			continue
+		}
+		if isNode {
+			deleted++
+		}
+		batch.Delete(common.CopyBytes(it.Key()))
+		if pending++; pending >= pruneBatchSize {
+			if err := batch.Write(); err != nil {
+				it.Release()
+				return 0, err
+			}
+			batch.Reset()
+			pending = 0
+		}
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return 0, err
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	return deleted, s.diskDb.Compact()
+}
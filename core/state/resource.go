@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"github.com/ecoball/go-ecoball/common"
 	"math/big"
-	"time"
 )
 
 var cpuAmount = "cpu_amount"
@@ -16,6 +15,15 @@ const VirtualBlockNetLimit float32 = 1048576000.0
 const BlockCpuLimit float32 = 200000.0
 const BlockNetLimit float32 = 1048576.0
 
+const (
+	GasPerCpuMs   uint64 = 10000    //gas units metered by the virtual machine for one ms of cpu
+	TxGasLimit    uint64 = 50000000 //gas budget of a single transaction
+	GasTransfer   uint64 = 1000
+	GasDeploy     uint64 = 10000
+	GasDeployByte uint64 = 10
+	GasNative     uint64 = 5000
+)
+
 var BlockCpu = BlockCpuLimit
 var BlockNet = BlockNetLimit
 
@@ -120,7 +128,7 @@ func (s *State) SubResourceLimits(index common.AccountName, cpu, net float32) er
 	if err != nil {
 		return err
 	}
-	acc.RecoverResources(cpuStakedSum, netStakedSum)
+	acc.RecoverResources(cpuStakedSum, netStakedSum, s.timeStamp*1000)
 	if err := acc.SubResourceLimits(cpu, net, cpuStakedSum, netStakedSum); err != nil {
 		return err
 	}
@@ -185,7 +193,7 @@ func (s *State) RecoverResources(acc *Account) error {
 	if err != nil {
 		return err
 	}
-	return acc.RecoverResources(cpuStakedSum, netStakedSum)
+	return acc.RecoverResources(cpuStakedSum, netStakedSum, s.timeStamp*1000)
 }
 func (s *State) RequireResources(index common.AccountName) (float32, float32, error) {
 	cpuStakedSum, err := s.GetParam(cpuAmount)
@@ -200,7 +208,7 @@ func (s *State) RequireResources(index common.AccountName) (float32, float32, er
 	if err != nil {
 		return 0, 0, err
 	}
-	acc.RecoverResources(cpuStakedSum, netStakedSum)
+	acc.RecoverResources(cpuStakedSum, netStakedSum, s.timeStamp*1000)
 	log.Debug("cpu:", acc.Cpu.Used, acc.Cpu.Available, acc.Cpu.Limit)
 	log.Debug("net:", acc.Net.Used, acc.Net.Available, acc.Net.Limit)
 	return acc.Cpu.Available, acc.Net.Available, nil
@@ -294,9 +302,17 @@ func (a *Account) UpdateResource(cpuStakedSum, netStakedSum uint64) error {
 	a.Net.Available = a.Net.Limit - a.Net.Used
 	return nil
 }
-func (a *Account) RecoverResources(cpuStakedSum, netStakedSum uint64) error {
-	t := time.Now().UnixNano() / (1000 * 1000)
-	interval := 100.0 * float32(t-a.TimeStamp) / (24.0 * 60.0 * 60.0 * 1000)
+
+/**
+ *  @brief recover the resources used by the account in proportion to the time passed, all used resources are
+ *  recovered in a day, the time of block is used so every node recovers the same amount
+ *  @param now - the time of block in milliseconds, the resources are not recovered if it's before the last time
+ */
+func (a *Account) RecoverResources(cpuStakedSum, netStakedSum uint64, now int64) error {
+	if now < a.TimeStamp {
+		return a.UpdateResource(cpuStakedSum, netStakedSum)
+	}
+	interval := 100.0 * float32(now-a.TimeStamp) / (24.0 * 60.0 * 60.0 * 1000)
 	if interval >= 100 {
 		a.Cpu.Used = 0
 		a.Net.Used = 0
@@ -308,6 +324,6 @@ func (a *Account) RecoverResources(cpuStakedSum, netStakedSum uint64) error {
 		a.Net.Used -= a.Net.Used * interval
 	}
 	a.UpdateResource(cpuStakedSum, netStakedSum)
-	a.TimeStamp = t
+	a.TimeStamp = now
 	return nil
 }
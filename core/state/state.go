@@ -23,13 +23,15 @@ import (
 	"github.com/ecoball/go-ecoball/common"
 	"github.com/ecoball/go-ecoball/common/elog"
 	"github.com/ecoball/go-ecoball/core/store"
-	"github.com/ecoball/go-ecoball/core/types"
 )
 
 var log = elog.NewLogger("state", elog.DebugLog)
 var IndexAbaRoot = common.NameToIndex("root")
 var AbaToken = "ABA"
 
+// returned by the methods changing a state which is set read only
+var ErrReadOnly = errors.New("the state is read only")
+
 type State struct {
 	path   string
 	trie   Trie
@@ -38,12 +40,22 @@ type State struct {
 
 	Accounts map[string]Account
 	Params   map[string]uint64
+
+	height    uint64
+	timeStamp int64
+	readOnly  bool
+	pruning   bool
+
+	//the storage tries of accounts opened since last commit, and their roots when opened
+	storage      *storageDatabase
+	storageTries map[common.AccountName]Trie
+	storageBase  map[common.AccountName]common.Hash
 }
 
 /**
  *  @brief create a new mpt trie and a levelDB
  *  @param path - the levelDB store path
- *  @param root - the root of mpt trie, this value decide the state of trie
+ *  @param root - the root of mpt trie, this value decide the state of trie, it fails if the root is not stored
  */
 func NewState(path string, root common.Hash) (st *State, err error) {
 	st = &State{path: path}
@@ -53,12 +65,20 @@ func NewState(path string, root common.Hash) (st *State, err error) {
 	}
 	st.db = NewDatabase(st.diskDb)
 	log.Notice("Open Trie Hash:", root.HexString())
+	//the state of a missing root is never replaced by an empty one, the chain would run on a wrong state
 	st.trie, err = st.db.OpenTrie(root)
 	if err != nil {
-		st.trie, _ = st.db.OpenTrie(common.Hash{})
+		st.diskDb.Close()
+		return nil, errors.New(fmt.Sprintf("can't open the state of root %s: %s", root.HexString(), err.Error()))
 	}
 	st.Accounts = make(map[string]Account, 1)
 	st.Params = make(map[string]uint64, 1)
+	st.storage = newStorageDatabase(st.diskDb)
+	st.storageTries = make(map[common.AccountName]Trie)
+	st.storageBase = make(map[common.AccountName]common.Hash)
+	if err := st.migrateStorage(); err != nil {
+		return nil, err
+	}
 	return st, nil
 }
 func (s *State) CopyState() (*State, error) {
@@ -79,20 +99,101 @@ func (s *State) CopyState() (*State, error) {
 			return nil, err
 		}
 	}
+	storageTries, storageBase := s.copyStorageTries()
+	return &State{
+		path:         s.path,
+		trie:         s.db.CopyTrie(s.trie),
+		db:           s.db,
+		diskDb:       s.diskDb,
+		Accounts:     accounts,
+		Params:       params,
+		height:       s.height,
+		timeStamp:    s.timeStamp,
+		readOnly:     s.readOnly,
+		pruning:      s.pruning,
+		storage:      s.storage,
+		storageTries: storageTries,
+		storageBase:  storageBase,
+	}, nil
+}
+
+/**
+ *  @brief forbid any change of the state, the contracts executed on it for a query can only read
+ */
+func (s *State) SetReadOnly() {
+	s.readOnly = true
+}
+func (s *State) ReadOnly() bool {
+	return s.readOnly
+}
+
+/**
+ *  @brief open the state of a past block by the root of mpt trie, the changes made on it are never
+ *  committed into the current state
+ *  @param root - the state root of block
+ */
+func (s *State) StateAt(root common.Hash) (*State, error) {
+	trie, err := s.db.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
 	return &State{
-		path:     s.path,
-		trie:     s.db.CopyTrie(s.trie),
-		Accounts: accounts,
-		Params:   params,
+		path:         s.path,
+		trie:         trie,
+		db:           s.db,
+		diskDb:       s.diskDb,
+		Accounts:     make(map[string]Account, 1),
+		Params:       make(map[string]uint64, 1),
+		storage:      s.storage,
+		storageTries: make(map[common.AccountName]Trie),
+		storageBase:  make(map[common.AccountName]common.Hash),
 	}, nil
 }
 
+/**
+ *  @brief set the height and timestamp of the block whose transactions are executed on this state
+ *  @param height - the height of block
+ *  @param timeStamp - the timestamp of block in seconds
+ */
+func (s *State) SetBlockInfo(height uint64, timeStamp int64) {
+	s.height = height
+	s.timeStamp = timeStamp
+}
+func (s *State) BlockHeight() uint64 {
+	return s.height
+}
+func (s *State) BlockTime() int64 {
+	return s.timeStamp
+}
+
+/**
+ *  @brief take a snapshot of the current state, the snapshot can be used to revert the changes made after this call
+ */
+func (s *State) Snapshot() (*State, error) {
+	return s.CopyState()
+}
+
+/**
+ *  @brief discard all changes made after the snapshot was taken
+ *  @param snapshot - the state returned by Snapshot
+ */
+func (s *State) RevertToSnapshot(snapshot *State) {
+	s.trie = snapshot.trie
+	s.Accounts = snapshot.Accounts
+	s.Params = snapshot.Params
+	s.storageTries = snapshot.storageTries
+	s.storageBase = snapshot.storageBase
+}
+
 /**
  *  @brief create a new account and store into mpt trie, meanwhile store the mapping of addr and index
  *  @param index - account's index
  *  @param addr - account's address convert from public key
  */
 func (s *State) AddAccount(index common.AccountName, addr common.Address) (*Account, error) {
+	if s.readOnly {
+		return nil, ErrReadOnly
+	}
 	key := common.IndexToBytes(index)
 	data, err := s.trie.TryGet(key)
 	if err != nil {
@@ -101,7 +202,7 @@ func (s *State) AddAccount(index common.AccountName, addr common.Address) (*Acco
 	if data != nil {
 		return nil, errors.New("reduplicate name")
 	}
-	acc, err := NewAccount(s.path, index, addr)
+	acc, err := NewAccount(index, addr, s.timeStamp*1000)
 	if err != nil {
 		return nil, err
 	}
@@ -117,51 +218,80 @@ func (s *State) AddAccount(index common.AccountName, addr common.Address) (*Acco
 	return acc, nil
 }
 
-/**
- *  @brief store the smart contract of account, every account only has one contract
- *  @param index - account's index
- *  @param t - the virtual machine type
- *  @param des - the description of contract
- *  @param code - the code of contract
- */
-func (s *State) SetContract(index common.AccountName, t types.VmType, des, code []byte) error {
+func (s *State) StoreSet(index common.AccountName, key, value []byte) (err error) {
+	if s.readOnly {
+		return ErrReadOnly
+	}
 	acc, err := s.GetAccountByName(index)
 	if err != nil {
 		return err
 	}
-	if err := acc.SetContract(t, des, code); err != nil {
+	acc.OrderedStorage = acc.orderedStorage()
+	t, err := s.storageTrie(acc)
+	if err != nil {
+		return err
+	}
+	log.Debug("StoreSet key:", string(key), "value:", string(value))
+	if err := t.TryUpdate(key, value); err != nil {
 		return err
 	}
+	acc.Hash = t.Hash()
 	return s.CommitAccount(acc)
 }
-
-/**
- *  @brief get the code of account
- *  @param index - account's index
- */
-func (s *State) GetContract(index common.AccountName) (*types.DeployInfo, error) {
+func (s *State) StoreGet(index common.AccountName, key []byte) (value []byte, err error) {
 	acc, err := s.GetAccountByName(index)
 	if err != nil {
 		return nil, err
 	}
-	return acc.GetContract()
+	t, err := s.storageTrie(acc)
+	if err != nil {
+		return nil, err
+	}
+	value, err = t.TryGet(key)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("StoreGet key:", string(key), "value:", string(value))
+	return value, nil
 }
-func (s *State) StoreSet(index common.AccountName, key, value []byte) (err error) {
+func (s *State) StoreDelete(index common.AccountName, key []byte) (err error) {
+	if s.readOnly {
+		return ErrReadOnly
+	}
 	acc, err := s.GetAccountByName(index)
 	if err != nil {
 		return err
 	}
-	if err := acc.StoreSet(s.path, key, value); err != nil {
+	acc.OrderedStorage = acc.orderedStorage()
+	t, err := s.storageTrie(acc)
+	if err != nil {
 		return err
 	}
+	log.Debug("StoreDelete key:", string(key))
+	if err := t.TryDelete(key); err != nil {
+		return err
+	}
+	acc.Hash = t.Hash()
 	return s.CommitAccount(acc)
 }
-func (s *State) StoreGet(index common.AccountName, key []byte) (value []byte, err error) {
+
+/**
+ *  @brief find the smallest key of the account storage which starts with prefix and is greater than the given key
+ *  @param index - the account index
+ *  @param prefix - the prefix of keys
+ *  @param key - the previous key returned, nil to get the first one
+ *  @return the next key, nil if there is no more key, and the number of storage entries visited
+ */
+func (s *State) StoreNext(index common.AccountName, prefix, key []byte) ([]byte, int, error) {
 	acc, err := s.GetAccountByName(index)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+	t, err := s.storageTrie(acc)
+	if err != nil {
+		return nil, 0, err
 	}
-	return acc.StoreGet(s.path, key)
+	return nextStorageKey(t, prefix, key)
 }
 
 /**
@@ -212,6 +342,35 @@ func (s *State) GetAccountByAddr(addr common.Address) (*Account, error) {
 	}
 }
 
+/**
+ *  @brief get the nonce of account, it is the nonce of the next transaction sent by the account
+ *  @param index - the account index
+ */
+func (s *State) GetNonce(index common.AccountName) (uint64, error) {
+	acc, err := s.GetAccountByName(index)
+	if err != nil {
+		return 0, err
+	}
+	return acc.Nonce, nil
+}
+
+/**
+ *  @brief check the nonce of transaction is the next one of account, then increase the nonce of account
+ *  @param index - the account index
+ *  @param nonce - the nonce of transaction sent by the account
+ */
+func (s *State) IncreaseNonce(index common.AccountName, nonce uint64) error {
+	acc, err := s.GetAccountByName(index)
+	if err != nil {
+		return err
+	}
+	if nonce != acc.Nonce {
+		return errors.New(fmt.Sprintf("the nonce of transaction %d mismatches the nonce of account %d", nonce, acc.Nonce))
+	}
+	acc.Nonce++
+	return s.CommitAccount(acc)
+}
+
 /**
  *  @brief update the account's information into trie
  *  @param acc - account object
@@ -220,6 +379,9 @@ func (s *State) CommitAccount(acc *Account) error {
 	if acc == nil {
 		return errors.New("param acc is nil")
 	}
+	if s.readOnly {
+		return ErrReadOnly
+	}
 	d, err := acc.Serialize()
 	if err != nil {
 		return err
@@ -232,6 +394,9 @@ func (s *State) CommitAccount(acc *Account) error {
 	return nil
 }
 func (s *State) CommitParam(key string, value uint64) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
 	if err := s.trie.TryUpdate([]byte(key), common.Uint64ToBytes(value)); err != nil {
 		return err
 	}
@@ -270,13 +435,129 @@ func (s *State) CommitToMemory() error {
 }
 
 /**
- *  @brief save the information of mpt trie into levelDB
+ *  @brief save the information of mpt trie and the storage tries of accounts into levelDB
  */
 func (s *State) CommitToDB() error {
+	batch := s.diskDb.NewBatch()
+	if err := s.CommitToBatch(batch, nil); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	s.ReleaseCommitted()
+	return nil
+}
+
+/**
+ *  @brief count the references of trie nodes persisted by CommitToBatch, the nodes only referenced by the released
+ *  roots are deleted, the nodes persisted before are kept forever
+ *  @param enable - false keeps all trie nodes (archive)
+ */
+func (s *State) SetPruning(enable bool) {
+	s.pruning = enable
+}
+
+// the state of a block which is no longer retained
+type ExpiredState struct {
+	Height uint64
+	Root   common.Hash
+}
+
+/**
+ *  @brief commit the mpt trie and the storage tries of accounts, and write their new nodes into the batch instead
+ *  of levelDB, the nodes are kept in memory until ReleaseCommitted is called after the batch is written into Store
+ *  @param batch - the batch receiving the trie nodes
+ *  @param expired - the states no longer retained, they are only released in pruning mode
+ */
+func (s *State) CommitToBatch(batch store.Batch, expired []ExpiredState) error {
+	changed, err := s.commitStorageToMemory()
+	if err != nil {
+		return err
+	}
 	if err := s.CommitToMemory(); err != nil {
 		return err
 	}
-	return s.db.TrieDB().Commit(s.trie.Hash(), false)
+	root := s.trie.Hash()
+	//the references are counted before any node is written, a node on disk without count is never deleted
+	if s.pruning {
+		refs := s.db.TrieDB().NewRefCounter(batch)
+		if err := refs.Reference(root); err != nil {
+			return err
+		}
+		storageRefs, err := s.pruneStorage(batch, changed, expired)
+		if err != nil {
+			return err
+		}
+		for _, e := range expired {
+			if err := refs.Release(e.Root); err != nil {
+				return err
+			}
+		}
+		for _, r := range append(storageRefs, refs) {
+			if err := r.Write(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := s.commitStorageTo(batch, changed); err != nil {
+		return err
+	}
+	return s.db.TrieDB().CommitTo(root, batch)
+}
+
+/**
+ *  @brief drop the trie nodes written by CommitToBatch from memory
+ */
+func (s *State) ReleaseCommitted() {
+	s.releaseStorage()
+	s.db.TrieDB().Uncache(s.trie.Hash())
+}
+
+/**
+ *  @brief walk all nodes of mpt trie and the storage tries of accounts, it fails if any node can't be loaded
+ *  from levelDB
+ *  @return the number of nodes
+ */
+func (s *State) VerifyTrie() (int, error) {
+	var nodes int
+	it := s.trie.NodeIterator(nil)
+	for it.Next(true) {
+		nodes++
+	}
+	if it.Error() != nil {
+		return nodes, it.Error()
+	}
+	accounts, err := s.storageAccounts()
+	if err != nil {
+		return nodes, err
+	}
+	for _, index := range accounts {
+		acc, err := s.GetAccountByName(index)
+		if err != nil {
+			//the account is created after this state
+			continue
+		}
+		t, err := s.storage.get(index).OpenStorageTrie(common.Hash{}, acc.Hash)
+		if err != nil {
+			return nodes, errors.New(fmt.Sprintf("the storage of account %s is lost: %s", common.IndexToName(index), err.Error()))
+		}
+		it := t.NodeIterator(nil)
+		for it.Next(true) {
+			nodes++
+		}
+		if it.Error() != nil {
+			return nodes, errors.New(fmt.Sprintf("the storage of account %s is incomplete: %s", common.IndexToName(index), it.Error().Error()))
+		}
+	}
+	return nodes, nil
+}
+
+/**
+ *  @brief the levelDB of mpt trie
+ */
+func (s *State) Store() store.Storage {
+	return s.diskDb
 }
 
 /**
@@ -289,14 +570,15 @@ func (s *State) Reset(hash common.Hash) error {
 	if err != nil {
 		return err
 	}
+	s.diskDb = diskDb
 	s.db = NewDatabase(diskDb)
+	s.storage = newStorageDatabase(diskDb)
+	s.storageTries = make(map[common.AccountName]Trie)
+	s.storageBase = make(map[common.AccountName]common.Hash)
 	log.Notice("Open Trie Hash:", hash.HexString())
 	s.trie, err = s.db.OpenTrie(hash)
 	if err != nil {
-		s.trie, err = s.db.OpenTrie(common.Hash{})
-		if err != nil {
-			return err
-		}
+		return errors.New(fmt.Sprintf("can't open the state of root %s: %s", hash.HexString(), err.Error()))
 	}
 	return nil
 }
@@ -22,12 +22,10 @@ import (
 	"fmt"
 	"github.com/ecoball/go-ecoball/common"
 	"github.com/ecoball/go-ecoball/core/pb"
-	"github.com/ecoball/go-ecoball/core/store"
 	"github.com/ecoball/go-ecoball/core/types"
 	"github.com/gogo/protobuf/proto"
 	"math/big"
 	"sort"
-	"time"
 	"sync"
 )
 
@@ -37,13 +35,17 @@ type Account struct {
 	Tokens      map[string]Token      `json:"token"`
 	Permissions map[string]Permission `json:"permissions"`
 	Contract    types.DeployInfo      `json:"contract"`
+	CodeHash    common.Hash           `json:"codeHash"`
+	Versions    []ContractVersion     `json:"versions"`
 	Delegates   []Delegate            `json:"delegate"`
 	Resource    `json:"resource"`
+	//the number of transactions sent by the account, it is the nonce of the next transaction
+	Nonce uint64 `json:"nonce"`
 
-	Hash   common.Hash `json:"hash"`
-	trie   Trie
-	db     Database
-	diskDb *store.LevelDBStore
+	//the root of account's storage trie, the storage is kept in the state database under the prefix of account
+	Hash common.Hash `json:"hash"`
+	//the storage trie is keyed by the original keys, the storage written before is keyed by hash
+	OrderedStorage bool `json:"orderedStorage"`
 
 	mutex sync.RWMutex
 }
@@ -52,13 +54,14 @@ type Account struct {
  *  @brief create a new account, binding a char name with a address
  *  @param index - the unique id of account name created by common.NameToIndex()
  *  @param address - the account's public key
+ *  @param timeStamp - the time of block creating the account in milliseconds
  */
-func NewAccount(path string, index common.AccountName, addr common.Address) (acc *Account, err error) {
+func NewAccount(index common.AccountName, addr common.Address, timeStamp int64) (acc *Account, err error) {
 	log.Info("add a new account:", index)
 	fmt.Printf("index:%d\n", index)
 	acc = &Account{
 		Index:       index,
-		TimeStamp:   time.Now().UnixNano() / (1000 * 1000),
+		TimeStamp:   timeStamp,
 		Tokens:      make(map[string]Token, 1),
 		Permissions: make(map[string]Permission, 1),
 	}
@@ -67,82 +70,49 @@ func NewAccount(path string, index common.AccountName, addr common.Address) (acc
 	perm = NewPermission(Active, Owner, 1, []KeyFactor{{Actor: addr, Weight: 1}}, []AccFactor{})
 	acc.AddPermission(perm)
 
-	if err := acc.NewStoreTrie(path); err != nil {
-		return nil, err
-	}
-	acc.diskDb.Close()
 	return acc, nil
 }
 
-func (a *Account) NewStoreTrie(path string) error {
-	diskDb, err := store.NewLevelDBStore(path+"/"+common.IndexToName(a.Index), 0, 0)
-	if err != nil {
-		return err
-	}
-	a.diskDb = diskDb
-	a.db = NewDatabase(diskDb)
-	a.trie, err = a.db.OpenTrie(a.Hash)
-	if err != nil {
-		a.trie, err = a.db.OpenTrie(common.Hash{})
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 /**
- *  @brief add a smart contract into a account data
+ *  @brief add a new version of smart contract into a account data, the code is stored in state by hash,
+ *  Contract and CodeHash always describe the latest version
  *  @param t - the type of virtual machine
  *  @param des - the description of smart contract
- *  @param code - the code of smart contract
+ *  @param codeHash - the hash of contract code, see State.SetCode
+ *  @param abi - the ABI of smart contract, nil if not provided
+ *  @param height - the block height from which this version takes effect
  */
-func (a *Account) SetContract(t types.VmType, des, code []byte) error {
-	a.Contract.TypeVm = t
-	a.Contract.Describe = common.CopyBytes(des)
-	a.Contract.Code = common.CopyBytes(code)
+func (a *Account) SetContract(t types.VmType, des []byte, codeHash common.Hash, abi *types.Abi, height uint64) error {
+	version := ContractVersion{
+		Version:  uint32(len(a.Versions) + 1),
+		Height:   height,
+		TypeVm:   t,
+		Describe: common.CopyBytes(des),
+		CodeHash: codeHash,
+		Abi:      abi,
+	}
+	a.Versions = append(a.Versions, version)
+	a.Contract = types.DeployInfo{TypeVm: t, Describe: common.CopyBytes(des), Abi: abi}
+	a.CodeHash = codeHash
 	return nil
 }
 
 /**
- *  @brief get a smart contract from a account data
+ *  @brief get the version of smart contract in effect at the block height, the code is not loaded
+ *  @param height - the block height
  */
-func (a *Account) GetContract() (*types.DeployInfo, error) {
-	if a.Contract.TypeVm == 0 {
-		return nil, errors.New("this account is not set contract")
-	}
-	return &a.Contract, nil
-}
-
-func (a *Account) StoreSet(path string, key, value []byte) (err error) {
-	if err := a.NewStoreTrie(path); err != nil {
-		return err
-	}
-	defer a.diskDb.Close()
-	log.Debug("StoreSet key:", string(key), "value:", string(value))
-	if err := a.trie.TryUpdate(key, value); err != nil {
-		return err
-	}
-	if _, err := a.trie.Commit(nil); err != nil {
-		return err
-	}
-	if err := a.db.TrieDB().Commit(a.trie.Hash(), false); err != nil {
-		return err
-	}
-	a.Hash = a.trie.Hash()
-	return nil
-}
-func (a *Account) StoreGet(path string, key []byte) (value []byte, err error) {
-	if err := a.NewStoreTrie(path); err != nil {
-		return nil, err
+func (a *Account) ContractAt(height uint64) (*ContractVersion, error) {
+	var current *ContractVersion
+	for i := range a.Versions {
+		v := &a.Versions[i]
+		if v.Height <= height && (current == nil || v.Version > current.Version) {
+			current = v
+		}
 	}
-	defer a.diskDb.Close()
-	value, err = a.trie.TryGet(key)
-	if err != nil {
-		return nil, err
+	if current == nil {
+		return nil, errors.New("this account is not set contract")
 	}
-	log.Debug("StoreGet key:", string(key), "value:", string(value))
-	return value, nil
+	return current, nil
 }
 
 /**
@@ -226,6 +196,10 @@ func (a *Account) ProtoBuf() (*pb.Account, error) {
 		d := pb.Delegate{Index: uint64(v.Index), Cpu: v.CpuStaked, Net: v.NetStaked}
 		delegates = append(delegates, &d)
 	}
+	var versions []*pb.ContractVersion
+	for _, v := range a.Versions {
+		versions = append(versions, v.ProtoBuf())
+	}
 	pbAcc := pb.Account{
 		Index:       uint64(a.Index),
 		TimeStamp:   a.TimeStamp,
@@ -234,8 +208,10 @@ func (a *Account) ProtoBuf() (*pb.Account, error) {
 		Contract: &pb.DeployInfo{
 			TypeVm:   uint32(a.Contract.TypeVm),
 			Describe: common.CopyBytes(a.Contract.Describe),
-			Code:     common.CopyBytes(a.Contract.Code),
+			Abi:      a.Contract.Abi.ProtoBuf(),
 		},
+		CodeHash:  a.CodeHash.Bytes(),
+		Versions:  versions,
 		Delegates: delegates,
 		Ram: &pb.Ram{
 			Quota: a.Ram.Quota,
@@ -255,7 +231,9 @@ func (a *Account) ProtoBuf() (*pb.Account, error) {
 			Available: a.Net.Available,
 			Limit:     a.Net.Limit,
 		},
-		Hash: a.Hash.Bytes(),
+		Hash:           a.Hash.Bytes(),
+		Nonce:          a.Nonce,
+		OrderedStorage: a.OrderedStorage,
 	}
 
 	return &pbAcc, nil
@@ -275,6 +253,7 @@ func (a *Account) Deserialize(data []byte) error {
 	}
 	a.Index = common.AccountName(pbAcc.Index)
 	a.TimeStamp = pbAcc.TimeStamp
+	a.Nonce = pbAcc.Nonce
 
 	a.Ram.Quota = pbAcc.Ram.Quota
 	a.Ram.Used = pbAcc.Ram.Used
@@ -290,11 +269,17 @@ func (a *Account) Deserialize(data []byte) error {
 	a.Net.Limit = pbAcc.Net.Limit
 
 	a.Hash = common.NewHash(pbAcc.Hash)
+	a.OrderedStorage = pbAcc.OrderedStorage
 	a.Tokens = make(map[string]Token)
 	a.Contract = types.DeployInfo{
 		TypeVm:   types.VmType(pbAcc.Contract.TypeVm),
 		Describe: common.CopyBytes(pbAcc.Contract.Describe),
-		Code:     common.CopyBytes(pbAcc.Contract.Code),
+		Abi:      types.NewAbiFromProto(pbAcc.Contract.Abi),
+	}
+	a.CodeHash = common.NewHash(pbAcc.CodeHash)
+	a.Versions = nil
+	for _, v := range pbAcc.Versions {
+		a.Versions = append(a.Versions, NewContractVersionFromProto(v))
 	}
 	a.Permissions = make(map[string]Permission, 1)
 	for _, v := range pbAcc.Tokens {
@@ -11,7 +11,7 @@ import (
 func TestStateObject(t *testing.T) {
 	addr := common.NewAddress(common.FromHex("01ca5cdd56d99a0023166b337ffc7fd0d2c42330"))
 	indexAcc := common.NameToIndex("pct")
-	acc1, _ := state.NewAccount("/tmp/state_object", indexAcc, addr)
+	acc1, _ := state.NewAccount(indexAcc, addr, 0)
 
 	acc1.AddBalance(state.AbaToken, new(big.Int).SetUint64(100))
 	value, err := acc1.Balance(state.AbaToken)
@@ -49,7 +49,7 @@ func TestStateObject(t *testing.T) {
 func TestNewAccount(t *testing.T) {
 	addr := common.NewAddress(common.FromHex("01ca5cdd56d99a0023166b337ffc7fd0d2c42330"))
 	indexAcc := common.NameToIndex("pct")
-	acc, err := state.NewAccount("/tmp/acc", indexAcc, addr)
+	acc, err := state.NewAccount(indexAcc, addr, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
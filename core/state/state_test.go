@@ -21,16 +21,17 @@ import (
 	"github.com/ecoball/go-ecoball/common"
 	"github.com/ecoball/go-ecoball/core/state"
 	"github.com/ecoball/go-ecoball/core/store"
+	"github.com/ecoball/go-ecoball/core/types"
 	"math/big"
+	"os"
 	"testing"
 )
 
 func TestStateNew(t *testing.T) {
-	root := common.HexToHash("0x797ddb4c4b8cfcb353989acf8e41b5d425003fb0ae3d89e754658fe264e8dab5")
 	addr := common.NewAddress(common.FromHex("01ca5cdd56d99a0023166b337ffc7fd0d2c42330"))
 	indexAcc := common.NameToIndex("pct")
 	indexToken := state.AbaToken
-	s, err := state.NewState("/tmp/state", root)
+	s, err := state.NewState("/tmp/state", common.Hash{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -63,7 +64,7 @@ func TestStateRoot(t *testing.T) {
 	addr := common.NewAddress(common.FromHex("01ca5cdd56d99a0023166b337ffc7fd0d2c42330"))
 	indexAcc := common.NameToIndex("pct")
 	indexToken := state.AbaToken
-	s, err := state.NewState("/tmp/state_root", common.HexToHash("cf4bfc19264aa4bbd6898c0ef43ce5465c794fd587e622fccc19980e634cd9f2"))
+	s, err := state.NewState("/tmp/state_root", common.Hash{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -233,4 +234,424 @@ func TestRootHash(t *testing.T) {
 	//	t.Fatal(err)
 	//}
 	s.CommitToDB()
-}
\ No newline at end of file
+}
+func TestStoreNext(t *testing.T) {
+	addr := common.NewAddress(common.FromHex("01ca5cdd56d99a0023166b337ffc7fd0d2c42330"))
+	indexAcc := common.NameToIndex("store")
+	os.RemoveAll("/tmp/state_store")
+	s, err := state.NewState("/tmp/state_store", common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddAccount(indexAcc, addr); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"user.bob", "user.alice", "config", "user.carol"} {
+		if err := s.StoreSet(indexAcc, []byte(k), []byte("value of "+k)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.StoreDelete(indexAcc, []byte("user.bob")); err != nil {
+		t.Fatal(err)
+	}
+	value, err := s.StoreGet(indexAcc, []byte("user.bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != nil {
+		t.Fatal("deleted key still has value:", string(value))
+	}
+
+	var keys []string
+	key, _, err := s.StoreNext(indexAcc, []byte("user."), nil)
+	for ; err == nil && key != nil; key, _, err = s.StoreNext(indexAcc, []byte("user."), key) {
+		keys = append(keys, string(key))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != "user.alice" || keys[1] != "user.carol" {
+		t.Fatal("unexpected keys:", keys)
+	}
+
+	//the iteration seeks to the key instead of visiting the whole storage
+	for i := 0; i < 100; i++ {
+		if err := s.StoreSet(indexAcc, []byte(fmt.Sprintf("item.%03d", i)), []byte("item")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	key, visited, err := s.StoreNext(indexAcc, []byte("user."), []byte("user.alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(key) != "user.carol" || visited > 2 {
+		t.Fatal("unexpected next key:", string(key), "visited:", visited)
+	}
+}
+
+func TestContractUpgrade(t *testing.T) {
+	addr := common.NewAddress(common.FromHex("01ca5cdd56d99a0023166b337ffc7fd0d2c42330"))
+	indexAcc := common.NameToIndex("upgrade")
+	os.RemoveAll("/tmp/state_upgrade")
+	s, err := state.NewState("/tmp/state_upgrade", common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddAccount(indexAcc, addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CommitParam(state.ParamUpgradeDelay, 10); err != nil {
+		t.Fatal(err)
+	}
+	s.SetBlockInfo(5, 0)
+	if err := s.SetContract(indexAcc, types.VmWasm, []byte("v1"), []byte("code v1"), nil); err != nil {
+		t.Fatal(err)
+	}
+	s.SetBlockInfo(20, 0)
+	if err := s.SetContract(indexAcc, types.VmWasm, []byte("v2"), []byte("code v2"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		height uint64
+		code   string
+	}{{5, "code v1"}, {29, "code v1"}, {30, "code v2"}} {
+		contract, err := s.GetContractAt(indexAcc, c.height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(contract.Code) != c.code {
+			t.Fatal("height", c.height, "runs", string(contract.Code), "but want", c.code)
+		}
+	}
+	if _, err := s.GetContractAt(indexAcc, 4); err == nil {
+		t.Fatal("contract is in effect before deployed")
+	}
+	versions, err := s.ContractVersions(indexAcc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[1].Version != 2 || versions[1].Height != 30 {
+		t.Fatal("unexpected versions:", versions)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	addr := common.NewAddress(common.FromHex("01ca5cdd56d99a0023166b337ffc7fd0d2c42330"))
+	indexAcc := common.NameToIndex("readonly")
+	os.RemoveAll("/tmp/state_readonly")
+	s, err := state.NewState("/tmp/state_readonly", common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//the resources of account can't be copied if nothing is staked
+	if err := s.CommitParam("cpu_amount", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CommitParam("net_amount", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddAccount(indexAcc, addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StoreSet(indexAcc, []byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	root := s.GetHashRoot()
+
+	query, err := s.CopyState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	query.SetReadOnly()
+	if value, err := query.StoreGet(indexAcc, []byte("key")); err != nil || string(value) != "value" {
+		t.Fatal("read the read only state failed:", string(value), err)
+	}
+	if err := query.StoreSet(indexAcc, []byte("key"), []byte("changed")); err != state.ErrReadOnly {
+		t.Fatal("store set on read only state:", err)
+	}
+	if err := query.StoreDelete(indexAcc, []byte("key")); err != state.ErrReadOnly {
+		t.Fatal("store delete on read only state:", err)
+	}
+	if _, err := query.AddAccount(common.NameToIndex("other"), addr); err != state.ErrReadOnly {
+		t.Fatal("add account on read only state:", err)
+	}
+	if err := query.CommitParam("param", 1); err != state.ErrReadOnly {
+		t.Fatal("commit param on read only state:", err)
+	}
+	if err := query.SetContract(indexAcc, types.VmWasm, nil, []byte("code"), nil); err != state.ErrReadOnly {
+		t.Fatal("set contract on read only state:", err)
+	}
+	if value, err := s.StoreGet(indexAcc, []byte("key")); err != nil || string(value) != "value" {
+		t.Fatal("the state is changed by query:", string(value), err)
+	}
+	if newRoot := s.GetHashRoot(); !root.Equals(&newRoot) {
+		t.Fatal("the state root is changed by query")
+	}
+}
+
+func TestToken(t *testing.T) {
+	addr := common.NewAddress(common.FromHex("01ca5cdd56d99a0023166b337ffc7fd0d2c42330"))
+	issuer := common.NameToIndex("issuer")
+	holder := common.NameToIndex("holder")
+	os.RemoveAll("/tmp/state_token")
+	s, err := state.NewState("/tmp/state_token", common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddAccount(issuer, addr); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddAccount(holder, addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateToken("EOT", issuer, 4, big.NewInt(1000)); err != nil {
+		t.Fatal(err)
+	}
+	if !s.TokenExisted("EOT") || s.TokenExisted("XYZ") {
+		t.Fatal("token existed error")
+	}
+	if err := s.CreateToken("EOT", issuer, 4, big.NewInt(1000)); err == nil {
+		t.Fatal("create a token twice")
+	}
+	if err := s.CreateToken("E-T", issuer, 4, big.NewInt(1000)); err == nil {
+		t.Fatal("create a token of invalid symbol")
+	}
+	if err := s.IssueToken("EOT", issuer, big.NewInt(800)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.IssueToken("EOT", issuer, big.NewInt(201)); err == nil {
+		t.Fatal("issue token exceeds the maximum supply")
+	}
+	if err := s.TransferToken("EOT", issuer, holder, big.NewInt(300)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.TransferToken("EOT", holder, issuer, big.NewInt(301)); err == nil {
+		t.Fatal("transfer token more than balance")
+	}
+	if err := s.RetireToken("EOT", holder, big.NewInt(300)); err != nil {
+		t.Fatal(err)
+	}
+	info, err := s.GetTokenInfo("EOT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Issuer != issuer || info.Precision != 4 || info.Supply.Int64() != 500 || info.MaxSupply.Int64() != 1000 {
+		t.Fatal("token info error:", info)
+	}
+	if err := s.CloseBalance("EOT", issuer); err == nil {
+		t.Fatal("close the balance which is not zero")
+	}
+	if err := s.CloseBalance("EOT", holder); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AccountGetBalance(holder, "EOT"); err == nil {
+		t.Fatal("the balance is not closed")
+	}
+	if balance, err := s.AccountGetBalance(issuer, "EOT"); err != nil || balance.Int64() != 500 {
+		t.Fatal("balance error:", balance, err)
+	}
+}
+
+func TestNonce(t *testing.T) {
+	addr := common.NewAddress(common.FromHex("01ca5cdd56d99a0023166b337ffc7fd0d2c42330"))
+	indexAcc := common.NameToIndex("nonce")
+	os.RemoveAll("/tmp/state_nonce")
+	s, err := state.NewState("/tmp/state_nonce", common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddAccount(indexAcc, addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.IncreaseNonce(indexAcc, 1); err == nil {
+		t.Fatal("the nonce of a new account is 0")
+	}
+	for i := uint64(0); i < 3; i++ {
+		if err := s.IncreaseNonce(indexAcc, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.IncreaseNonce(indexAcc, 1); err == nil {
+		t.Fatal("the used nonce must be rejected")
+	}
+	if err := s.CommitToMemory(); err != nil {
+		t.Fatal(err)
+	}
+	copied, err := s.StateAt(s.GetHashRoot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nonce, err := copied.GetNonce(indexAcc); err != nil || nonce != 3 {
+		t.Fatal("the nonce is not stored in trie:", nonce, err)
+	}
+}
+
+func TestStorageTrie(t *testing.T) {
+	addr := common.NewAddress(common.FromHex("01ca5cdd56d99a0023166b337ffc7fd0d2c42330"))
+	indexAcc := common.NameToIndex("storage")
+	legacy := common.NameToIndex("legacy")
+	os.RemoveAll("/tmp/state_storage")
+
+	//the storage of account kept in a levelDB per account before
+	legacyDb, err := store.NewLevelDBStore("/tmp/state_storage/legacy", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := state.NewDatabase(legacyDb)
+	legacyTrie, err := db.OpenTrie(common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := legacyTrie.TryUpdate([]byte("old"), []byte("value of old")); err != nil {
+		t.Fatal(err)
+	}
+	legacyRoot, err := legacyTrie.Commit(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.TrieDB().Commit(legacyRoot, false); err != nil {
+		t.Fatal(err)
+	}
+	legacyDb.Close()
+
+	s, err := state.NewState("/tmp/state_storage", common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat("/tmp/state_storage/legacy"); !os.IsNotExist(err) {
+		t.Fatal("the legacy storage must be moved into the state database")
+	}
+	for _, index := range []common.AccountName{indexAcc, legacy} {
+		if _, err := s.AddAccount(index, addr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	acc, err := s.GetAccountByName(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc.Hash = legacyRoot
+	if err := s.CommitAccount(acc); err != nil {
+		t.Fatal(err)
+	}
+	root := s.GetHashRoot()
+	if err := s.StoreSet(indexAcc, []byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if hash := s.GetHashRoot(); hash.Equals(&root) {
+		t.Fatal("the state root must cover the storage")
+	}
+	if _, err := os.Stat("/tmp/state_storage/storage"); !os.IsNotExist(err) {
+		t.Fatal("the storage must not open a levelDB per account")
+	}
+	if err := s.CommitToDB(); err != nil {
+		t.Fatal(err)
+	}
+	root = s.GetHashRoot()
+	s.Close()
+
+	s, err = state.NewState("/tmp/state_storage", root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for _, c := range []struct {
+		index common.AccountName
+		key   string
+		value string
+	}{{indexAcc, "key", "value"}, {legacy, "old", "value of old"}} {
+		value, err := s.StoreGet(c.index, []byte(c.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(value) != c.value {
+			t.Fatal("the storage is not persisted:", string(value))
+		}
+	}
+	//the legacy storage keyed by hash is still iterated in order
+	if key, _, err := s.StoreNext(legacy, nil, nil); err != nil || string(key) != "old" {
+		t.Fatal("unexpected key of legacy storage:", string(key), err)
+	}
+	if _, err := s.VerifyTrie(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoragePruning(t *testing.T) {
+	addr := common.NewAddress(common.FromHex("01ca5cdd56d99a0023166b337ffc7fd0d2c42330"))
+	indexAcc := common.NameToIndex("pruning")
+	os.RemoveAll("/tmp/state_storage_prune")
+	s, err := state.NewState("/tmp/state_storage_prune", common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.SetPruning(true)
+	if _, err := s.AddAccount(indexAcc, addr); err != nil {
+		t.Fatal(err)
+	}
+	commit := func(height uint64, value string, expired []state.ExpiredState) common.Hash {
+		s.SetBlockInfo(height, 0)
+		if err := s.StoreSet(indexAcc, []byte("key"), []byte(value)); err != nil {
+			t.Fatal(err)
+		}
+		batch := s.Store().NewBatch()
+		if err := s.CommitToBatch(batch, expired); err != nil {
+			t.Fatal(err)
+		}
+		if err := batch.Write(); err != nil {
+			t.Fatal(err)
+		}
+		s.ReleaseCommitted()
+		return s.GetHashRoot()
+	}
+	//the storage trie of a single key is one node, the reference counts are longer keys under the same prefix
+	storageNodes := func() int {
+		nodes := 0
+		it := s.Store().(*store.LevelDBStore).NewIteratorWithPrefix([]byte("storage"))
+		defer it.Release()
+		for it.Next() {
+			if len(it.Key()) == len("storage")+8+common.HashLen {
+				nodes++
+			}
+		}
+		return nodes
+	}
+	storageAt := func(root common.Hash) string {
+		st, err := s.StateAt(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.VerifyTrie(); err != nil {
+			t.Fatal(err)
+		}
+		value, err := st.StoreGet(indexAcc, []byte("key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(value)
+	}
+
+	//the storage replaced by block 2 is released with the state of block 1
+	root1 := commit(1, "v1", nil)
+	root2 := commit(2, "v2", nil)
+	if n := storageNodes(); n != 2 {
+		t.Fatal("the storage of both states must be kept, nodes:", n)
+	}
+	root3 := commit(3, "v3", []state.ExpiredState{{Height: 1, Root: root1}})
+	if n := storageNodes(); n != 2 {
+		t.Fatal("the storage of block 1 must be pruned, nodes:", n)
+	}
+	if storageAt(root2) != "v2" || storageAt(root3) != "v3" {
+		t.Fatal("the storage of retained states must be kept")
+	}
+
+	//the offline pruning only keeps the storage of the last state
+	if _, err := s.Prune(3, []common.Hash{root3}); err != nil {
+		t.Fatal(err)
+	}
+	if n := storageNodes(); n != 1 || storageAt(root3) != "v3" {
+		t.Fatal("only the storage of block 3 must be kept, nodes:", n)
+	}
+}
@@ -0,0 +1,360 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/store"
+	"github.com/ecoball/go-ecoball/core/trie"
+)
+
+// the prefix of the keys of account storage in the state database, it's followed by the account index
+var storageKeyPrefix = []byte("storage")
+
+// the prefix of the storage roots replaced by a block, it's followed by the block height
+var replacedStoragePrefix = []byte("replaced-storage")
+
+func storagePrefix(index common.AccountName) []byte {
+	return append(common.CopyBytes(storageKeyPrefix), common.IndexToBytes(index)...)
+}
+
+func replacedStorageKey(height uint64) []byte {
+	return append(common.CopyBytes(replacedStoragePrefix), common.Uint64ToBytes(height)...)
+}
+
+// the storage root of an account replaced by a block
+type storageRoot struct {
+	Index common.AccountName
+	Root  common.Hash
+}
+
+func encodeStorageRoots(roots []storageRoot) []byte {
+	var data []byte
+	for _, r := range roots {
+		data = append(data, common.IndexToBytes(r.Index)...)
+		data = append(data, r.Root.Bytes()...)
+	}
+	return data
+}
+
+func decodeStorageRoots(data []byte) ([]storageRoot, error) {
+	size := 8 + common.HashLen
+	if len(data)%size != 0 {
+		return nil, errors.New(fmt.Sprintf("invalid storage roots of %d bytes", len(data)))
+	}
+	var roots []storageRoot
+	for i := 0; i < len(data); i += size {
+		roots = append(roots, storageRoot{Index: common.IndexSetBytes(data[i : i+8]), Root: common.NewHash(data[i+8 : i+size])})
+	}
+	return roots, nil
+}
+
+// the trie databases of account storage, they share the state database under the prefixes of accounts
+type storageDatabase struct {
+	disk  store.Database
+	dbs   map[common.AccountName]Database
+	mutex sync.Mutex
+}
+
+func newStorageDatabase(disk store.Database) *storageDatabase {
+	return &storageDatabase{disk: disk, dbs: make(map[common.AccountName]Database)}
+}
+
+func (d *storageDatabase) get(index common.AccountName) Database {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	db, ok := d.dbs[index]
+	if !ok {
+		db = NewDatabase(store.NewTable(d.disk, storagePrefix(index)))
+		d.dbs[index] = db
+	}
+	return db
+}
+
+//the storage created since the ordered tries are used is keyed by the original keys, the storage written before
+//keeps its trie keyed by hash
+func (a *Account) orderedStorage() bool {
+	return a.OrderedStorage || a.Hash == (common.Hash{})
+}
+
+/**
+ *  @brief open the storage trie of account, the trie is kept by the state until it's committed
+ *  @param acc - the account, its Hash is the root of storage
+ */
+func (s *State) storageTrie(acc *Account) (Trie, error) {
+	if t, ok := s.storageTries[acc.Index]; ok {
+		return t, nil
+	}
+	var t Trie
+	var err error
+	if acc.orderedStorage() {
+		t, err = s.storage.get(acc.Index).OpenOrderedTrie(acc.Hash)
+	} else {
+		t, err = s.storage.get(acc.Index).OpenStorageTrie(common.Hash{}, acc.Hash)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.storageTries[acc.Index] = t
+	s.storageBase[acc.Index] = t.Hash()
+	return t, nil
+}
+
+func (s *State) copyStorageTries() (map[common.AccountName]Trie, map[common.AccountName]common.Hash) {
+	tries := make(map[common.AccountName]Trie, len(s.storageTries))
+	base := make(map[common.AccountName]common.Hash, len(s.storageBase))
+	for index, t := range s.storageTries {
+		tries[index] = s.storage.get(index).CopyTrie(t)
+		base[index] = s.storageBase[index]
+	}
+	return tries, base
+}
+
+/**
+ *  @brief find the smallest key of the storage trie which starts with prefix and is greater than the given key,
+ *  the iteration seeks to the key, so only the entries from it to the next key are visited
+ *  @param t - the storage trie of account, keyed by the original keys
+ *  @param prefix - the prefix of keys, empty for all keys
+ *  @param key - the previous key, nil to get the first one
+ *  @return the next key, nil if there is no more key, and the number of entries visited
+ */
+func nextStorageKey(t Trie, prefix, key []byte) ([]byte, int, error) {
+	if _, ok := t.(orderedTrie); !ok {
+		return scanStorageKey(t, prefix, key)
+	}
+	start := prefix
+	if key != nil && bytes.Compare(key, prefix) > 0 {
+		start = key
+	}
+	var visited int
+	it := trie.NewIterator(t.NodeIterator(start))
+	for it.Next() {
+		visited++
+		if !bytes.HasPrefix(it.Key, prefix) {
+			return nil, visited, nil
+		}
+		if key == nil || bytes.Compare(it.Key, key) > 0 {
+			return common.CopyBytes(it.Key), visited, nil
+		}
+	}
+	return nil, visited, it.Err
+}
+
+/**
+ *  @brief find the next key in the storage trie keyed by hash, the keys are not in order so all entries are visited
+ *  and the original keys are recovered from the preimages
+ */
+func scanStorageKey(t Trie, prefix, key []byte) ([]byte, int, error) {
+	var next []byte
+	var visited int
+	it := trie.NewIterator(t.NodeIterator(nil))
+	for it.Next() {
+		visited++
+		k := t.GetKey(it.Key)
+		if k == nil {
+			return nil, visited, errors.New(fmt.Sprintf("no preimage of storage key:%x", it.Key))
+		}
+		if !bytes.HasPrefix(k, prefix) || (key != nil && bytes.Compare(k, key) <= 0) {
+			continue
+		}
+		if next == nil || bytes.Compare(k, next) < 0 {
+			next = common.CopyBytes(k)
+		}
+	}
+	return next, visited, it.Err
+}
+
+/**
+ *  @brief commit the storage tries changed since last commit into memory, the roots of them are already in the
+ *  accounts
+ *  @return the accounts whose storage root is replaced
+ */
+func (s *State) commitStorageToMemory() ([]common.AccountName, error) {
+	var changed []common.AccountName
+	for index, t := range s.storageTries {
+		root, err := t.Commit(nil)
+		if err != nil {
+			return nil, err
+		}
+		if root != s.storageBase[index] {
+			changed = append(changed, index)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i] < changed[j] })
+	return changed, nil
+}
+
+/**
+ *  @brief write the nodes of changed storage tries into the batch under the prefixes of accounts
+ *  @param batch - the batch receiving the trie nodes
+ *  @param changed - the accounts whose storage root is replaced
+ */
+func (s *State) commitStorageTo(batch store.Batch, changed []common.AccountName) error {
+	for _, index := range changed {
+		db := s.storage.get(index).TrieDB()
+		if err := db.CommitTo(s.storageTries[index].Hash(), store.NewTableBatch(batch, storagePrefix(index))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ *  @brief count the references of the storage roots replaced by the block of state, and release the storage roots
+ *  replaced by the blocks next to the expired ones, the states before them are the last ones using the roots
+ *  @param batch - the batch receiving the reference counts
+ *  @param changed - the accounts whose storage root is replaced
+ *  @param expired - the states of blocks no longer retained
+ */
+func (s *State) pruneStorage(batch store.Batch, changed []common.AccountName, expired []ExpiredState) ([]*trie.RefCounter, error) {
+	counters := make(map[common.AccountName]*trie.RefCounter)
+	counter := func(index common.AccountName) *trie.RefCounter {
+		r, ok := counters[index]
+		if !ok {
+			r = s.storage.get(index).TrieDB().NewRefCounter(store.NewTableBatch(batch, storagePrefix(index)))
+			counters[index] = r
+		}
+		return r
+	}
+	var replaced []storageRoot
+	for _, index := range changed {
+		if err := counter(index).Reference(s.storageTries[index].Hash()); err != nil {
+			return nil, err
+		}
+		replaced = append(replaced, storageRoot{Index: index, Root: s.storageBase[index]})
+	}
+	consumed := false
+	for _, e := range expired {
+		var roots []storageRoot
+		if e.Height+1 == s.height {
+			roots, consumed = replaced, true
+		} else {
+			key := replacedStorageKey(e.Height + 1)
+			has, err := s.diskDb.Has(key)
+			if err != nil {
+				return nil, err
+			}
+			if has {
+				data, err := s.diskDb.Get(key)
+				if err != nil {
+					return nil, err
+				}
+				if roots, err = decodeStorageRoots(data); err != nil {
+					return nil, err
+				}
+				if err := batch.Delete(key); err != nil {
+					return nil, err
+				}
+			}
+		}
+		for _, r := range roots {
+			if err := counter(r.Index).Release(r.Root); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !consumed && len(replaced) != 0 {
+		if err := batch.Put(replacedStorageKey(s.height), encodeStorageRoots(replaced)); err != nil {
+			return nil, err
+		}
+	}
+	var refs []*trie.RefCounter
+	for _, r := range counters {
+		refs = append(refs, r)
+	}
+	return refs, nil
+}
+
+/**
+ *  @brief drop the storage tries committed from memory, they are opened from the state database next time
+ */
+func (s *State) releaseStorage() {
+	for index, t := range s.storageTries {
+		s.storage.get(index).TrieDB().Uncache(t.Hash())
+	}
+	s.storageTries = make(map[common.AccountName]Trie)
+	s.storageBase = make(map[common.AccountName]common.Hash)
+}
+
+/**
+ *  @brief get the accounts having storage in the state database
+ */
+func (s *State) storageAccounts() ([]common.AccountName, error) {
+	var accounts []common.AccountName
+	it := s.diskDb.NewIteratorWithPrefix(storageKeyPrefix)
+	defer it.Release()
+	for it.Next() {
+		key := it.Key()
+		if len(key) < len(storageKeyPrefix)+8 {
+			continue
+		}
+		index := common.IndexSetBytes(key[len(storageKeyPrefix) : len(storageKeyPrefix)+8])
+		if len(accounts) == 0 || accounts[len(accounts)-1] != index {
+			accounts = append(accounts, index)
+		}
+	}
+	return accounts, it.Error()
+}
+
+/**
+ *  @brief move the storage of accounts kept in a levelDB per account under the state path into the state database,
+ *  the levelDBs are removed afterwards
+ */
+func (s *State) migrateStorage() error {
+	files, err := ioutil.ReadDir(s.path)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+		dir := filepath.Join(s.path, f.Name())
+		db, err := store.NewLevelDBStore(dir, 0, 0)
+		if err != nil {
+			return err
+		}
+		prefix := storagePrefix(common.NameToIndex(f.Name()))
+		it := db.NewIterator()
+		for it.Next() {
+			s.diskDb.BatchPut(append(common.CopyBytes(prefix), it.Key()...), common.CopyBytes(it.Value()))
+		}
+		it.Release()
+		err = it.Error()
+		db.Close()
+		if err != nil {
+			return err
+		}
+		if err := s.diskDb.BatchCommit(); err != nil {
+			return err
+		}
+		log.Notice("move the storage of account", f.Name(), "into the state database")
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,14 +4,85 @@ import (
 	"errors"
 	"fmt"
 	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/pb"
+	"github.com/gogo/protobuf/proto"
 	"math/big"
 )
 
+// the maximum length of token symbol
+const MaxSymbolLen = 8
+
+// the prefix of the key of token information in the state trie, the token is keyed by its symbol
+var tokenPrefix = []byte("token")
+
 type Token struct {
 	Name    string   `json:"index"`
 	Balance *big.Int `json:"balance"`
 }
 
+// the registration of a token, the supply is the amount issued and not retired
+type TokenInfo struct {
+	Symbol    string             `json:"symbol"`
+	Issuer    common.AccountName `json:"issuer"`
+	Precision uint32             `json:"precision"`
+	MaxSupply *big.Int           `json:"maxSupply"`
+	Supply    *big.Int           `json:"supply"`
+}
+
+func (t *TokenInfo) Serialize() ([]byte, error) {
+	maxSupply, err := t.MaxSupply.GobEncode()
+	if err != nil {
+		return nil, err
+	}
+	supply, err := t.Supply.GobEncode()
+	if err != nil {
+		return nil, err
+	}
+	p := &pb.TokenInfo{
+		Symbol:    t.Symbol,
+		Issuer:    uint64(t.Issuer),
+		Precision: t.Precision,
+		MaxSupply: maxSupply,
+		Supply:    supply,
+	}
+	return proto.Marshal(p)
+}
+
+func (t *TokenInfo) Deserialize(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("input token info's length is zero")
+	}
+	var p pb.TokenInfo
+	if err := proto.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	t.Symbol = p.Symbol
+	t.Issuer = common.AccountName(p.Issuer)
+	t.Precision = p.Precision
+	t.MaxSupply = new(big.Int)
+	if err := t.MaxSupply.GobDecode(p.MaxSupply); err != nil {
+		return err
+	}
+	t.Supply = new(big.Int)
+	return t.Supply.GobDecode(p.Supply)
+}
+
+func tokenKey(symbol string) []byte {
+	return append(common.CopyBytes(tokenPrefix), []byte(symbol)...)
+}
+
+func checkSymbol(symbol string) error {
+	if len(symbol) == 0 || len(symbol) > MaxSymbolLen {
+		return errors.New(fmt.Sprintf("the length of token symbol must be 1 to %d", MaxSymbolLen))
+	}
+	for _, c := range symbol {
+		if !(c >= 'A' && c <= 'Z') && !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') {
+			return errors.New(fmt.Sprintf("invalid token symbol:%s", symbol))
+		}
+	}
+	return nil
+}
+
 func (s *State) AccountGetBalance(index common.AccountName, token string) (*big.Int, error) {
 	acc, err := s.GetAccountByName(index)
 	if err != nil {
@@ -32,7 +103,7 @@ func (s *State) AccountSubBalance(index common.AccountName, token string, value
 	if balance.Cmp(value) == -1 {
 		return errors.New("no enough balance")
 	}
-	if err := acc.SubBalance(AbaToken, value); err != nil {
+	if err := acc.SubBalance(token, value); err != nil {
 		return err
 	}
 	if err := s.CommitAccount(acc); err != nil {
@@ -45,7 +116,7 @@ func (s *State) AccountAddBalance(index common.AccountName, token string, value
 	if err != nil {
 		return err
 	}
-	if err := acc.AddBalance(AbaToken, value); err != nil {
+	if err := acc.AddBalance(token, value); err != nil {
 		return err
 	}
 	if err := s.CommitAccount(acc); err != nil {
@@ -55,13 +126,171 @@ func (s *State) AccountAddBalance(index common.AccountName, token string, value
 	return nil
 }
 
+/**
+ *  @brief check if the token is registered
+ *  @param name - the symbol of token
+ */
 func (s *State) TokenExisted(name string) bool {
-	data, err := s.trie.TryGet([]byte(name))
+	data, err := s.trie.TryGet(tokenKey(name))
 	if err != nil {
 		log.Error(err)
 		return false
 	}
-	return string(data) == name
+	return len(data) != 0
+}
+
+/**
+ *  @brief get the registration of token
+ *  @param symbol - the symbol of token
+ */
+func (s *State) GetTokenInfo(symbol string) (*TokenInfo, error) {
+	data, err := s.trie.TryGet(tokenKey(symbol))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New(fmt.Sprintf("the token %s is not existed", symbol))
+	}
+	info := new(TokenInfo)
+	if err := info.Deserialize(data); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (s *State) commitTokenInfo(info *TokenInfo) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	data, err := info.Serialize()
+	if err != nil {
+		return err
+	}
+	return s.trie.TryUpdate(tokenKey(info.Symbol), data)
+}
+
+/**
+ *  @brief register a new token, nothing is issued
+ *  @param symbol - the symbol of token, letters and digits
+ *  @param issuer - the account which can issue the token
+ *  @param precision - the number of decimal places of token, it only affects the display of amount
+ *  @param maxSupply - the maximum supply of token
+ */
+func (s *State) CreateToken(symbol string, issuer common.AccountName, precision uint32, maxSupply *big.Int) error {
+	if err := checkSymbol(symbol); err != nil {
+		return err
+	}
+	if maxSupply == nil || maxSupply.Sign() <= 0 {
+		return errors.New("the maximum supply of token must be positive")
+	}
+	if s.TokenExisted(symbol) {
+		return errors.New(fmt.Sprintf("the token %s is existed", symbol))
+	}
+	if _, err := s.GetAccountByName(issuer); err != nil {
+		return err
+	}
+	info := &TokenInfo{Symbol: symbol, Issuer: issuer, Precision: precision, MaxSupply: new(big.Int).Set(maxSupply), Supply: new(big.Int)}
+	return s.commitTokenInfo(info)
+}
+
+/**
+ *  @brief issue new token to an account, the supply can't exceed the maximum supply, the account is credited
+ *  before the supply is changed so the supply is kept if the account can't receive the token
+ *  @param symbol - the symbol of token
+ *  @param to - the account receives the token
+ *  @param amount - the amount of token
+ */
+func (s *State) IssueToken(symbol string, to common.AccountName, amount *big.Int) error {
+	if amount.Sign() <= 0 {
+		return errors.New("the amount of token must be positive")
+	}
+	info, err := s.GetTokenInfo(symbol)
+	if err != nil {
+		return err
+	}
+	supply := new(big.Int).Add(info.Supply, amount)
+	if supply.Cmp(info.MaxSupply) > 0 {
+		return errors.New(fmt.Sprintf("the supply of token %s exceeds the maximum supply %s", symbol, info.MaxSupply.String()))
+	}
+	if err := s.AccountAddBalance(to, symbol, amount); err != nil {
+		return err
+	}
+	info.Supply = supply
+	return s.commitTokenInfo(info)
+}
+
+/**
+ *  @brief transfer token between accounts
+ *  @param symbol - the symbol of token
+ *  @param amount - the amount of token
+ */
+func (s *State) TransferToken(symbol string, from, to common.AccountName, amount *big.Int) error {
+	if amount.Sign() <= 0 {
+		return errors.New("the amount of token must be positive")
+	}
+	if !s.TokenExisted(symbol) {
+		return errors.New(fmt.Sprintf("the token %s is not existed", symbol))
+	}
+	if from == to {
+		return errors.New("can't transfer token to self")
+	}
+	if _, err := s.GetAccountByName(to); err != nil {
+		return err
+	}
+	if err := s.AccountSubBalance(from, symbol, amount); err != nil {
+		return err
+	}
+	return s.AccountAddBalance(to, symbol, amount)
+}
+
+/**
+ *  @brief destroy token of an account, the supply is reduced
+ *  @param symbol - the symbol of token
+ *  @param from - the account whose token is destroyed
+ *  @param amount - the amount of token
+ */
+func (s *State) RetireToken(symbol string, from common.AccountName, amount *big.Int) error {
+	if amount.Sign() <= 0 {
+		return errors.New("the amount of token must be positive")
+	}
+	info, err := s.GetTokenInfo(symbol)
+	if err != nil {
+		return err
+	}
+	if err := s.AccountSubBalance(from, symbol, amount); err != nil {
+		return err
+	}
+	info.Supply = new(big.Int).Sub(info.Supply, amount)
+	return s.commitTokenInfo(info)
+}
+
+/**
+ *  @brief remove the token of zero balance from account
+ *  @param symbol - the symbol of token
+ *  @param index - the account
+ */
+func (s *State) CloseBalance(symbol string, index common.AccountName) error {
+	acc, err := s.GetAccountByName(index)
+	if err != nil {
+		return err
+	}
+	if err := acc.CloseToken(symbol); err != nil {
+		return err
+	}
+	return s.CommitAccount(acc)
+}
+
+/**
+ *  @brief register a new token and issue all of the supply to the creator
+ *  @param index - the account which creates the token
+ *  @param name - the name of token
+ *  @param maxSupply - the maximum supply of token
+ */
+func (s *State) TokenCreate(index common.AccountName, name string, maxSupply *big.Int) error {
+	if err := s.CreateToken(name, index, 0, maxSupply); err != nil {
+		return err
+	}
+	return s.IssueToken(name, index, maxSupply)
 }
 
 /**
@@ -87,6 +316,22 @@ func (a *Account) TokenExisted(token string) bool {
 	return false
 }
 
+/**
+ *  @brief remove the token from account, the balance must be zero
+ *  @param token - the symbol of token
+ */
+func (a *Account) CloseToken(token string) error {
+	t, ok := a.Tokens[token]
+	if !ok {
+		return errors.New(fmt.Sprintf("can't find token account:%s, in account:%s", token, common.IndexToName(a.Index)))
+	}
+	if t.GetBalance().Sign() != 0 {
+		return errors.New(fmt.Sprintf("the balance of token %s is not zero", token))
+	}
+	delete(a.Tokens, token)
+	return nil
+}
+
 /**
  *  @brief add balance into account
  *  @param index - the unique id of token name created by common.NameToIndex()
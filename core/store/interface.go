@@ -33,6 +33,7 @@ type Database interface {
 
 type Batch interface {
 	Putter
+	Delete(key []byte) error
 	ValueSize() int
 	Write() error
 	Reset()
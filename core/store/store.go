@@ -33,10 +33,14 @@ type Storage interface {
 	Has(key []byte) (bool, error)
 	Delete(key []byte) error
 	BatchPut(key, value []byte)
+	BatchDelete(key []byte)
 	BatchCommit() error
 	SearchAll() (result map[string]string, err error)
 	DeleteAll() error
 	NewIterator() iterator.Iterator
+	NewBatch() Batch
+	Compact() error
+	Close()
 }
 
 type LevelDBStore struct {
@@ -100,6 +104,13 @@ func (l *LevelDBStore) BatchPut(key, value []byte) {
 	l.batch.Put(key, value)
 }
 
+func (l *LevelDBStore) BatchDelete(key []byte) {
+	if l.batch == nil {
+		l.batch = new(leveldb.Batch)
+	}
+	l.batch.Delete(key)
+}
+
 func (l *LevelDBStore) BatchCommit() error {
 	if l.batch == nil {
 		return nil
@@ -154,6 +165,11 @@ func (l *LevelDBStore) NewBatch() Batch {
 	return &ldbBatch{db: l.db, b: new(leveldb.Batch)}
 }
 
+// Compact compacts the whole database, the space of deleted data is reclaimed
+func (l *LevelDBStore) Compact() error {
+	return l.db.CompactRange(util.Range{})
+}
+
 func (l *LevelDBStore) Close() {
 	l.db.Close()
 }
@@ -170,6 +186,11 @@ func (b *ldbBatch) Put(key, value []byte) error {
 	return nil
 }
 
+func (b *ldbBatch) Delete(key []byte) error {
+	b.b.Delete(key)
+	return nil
+}
+
 func (b *ldbBatch) Write() error {
 	return b.db.Write(b.b, nil)
 }
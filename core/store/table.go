@@ -0,0 +1,89 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package store
+
+//the view of a database whose keys are all prefixed, it shares a database among many users
+type table struct {
+	db     Database
+	prefix string
+}
+
+/**
+ *  @brief create a view of database, the keys of it are stored with the prefix
+ *  @param db - the database shared
+ *  @param prefix - the prefix of keys
+ */
+func NewTable(db Database, prefix []byte) Database {
+	return &table{db: db, prefix: string(prefix)}
+}
+
+func (t *table) Put(key []byte, value []byte) error {
+	return t.db.Put(append([]byte(t.prefix), key...), value)
+}
+
+func (t *table) Get(key []byte) ([]byte, error) {
+	return t.db.Get(append([]byte(t.prefix), key...))
+}
+
+func (t *table) Has(key []byte) (bool, error) {
+	return t.db.Has(append([]byte(t.prefix), key...))
+}
+
+func (t *table) Delete(key []byte) error {
+	return t.db.Delete(append([]byte(t.prefix), key...))
+}
+
+//the database is shared, it's closed by the owner
+func (t *table) Close() {}
+
+func (t *table) NewBatch() Batch {
+	return NewTableBatch(t.db.NewBatch(), []byte(t.prefix))
+}
+
+type tableBatch struct {
+	batch  Batch
+	prefix string
+}
+
+/**
+ *  @brief create a view of batch, the keys written by it are prefixed
+ *  @param batch - the batch shared
+ *  @param prefix - the prefix of keys
+ */
+func NewTableBatch(batch Batch, prefix []byte) Batch {
+	return &tableBatch{batch: batch, prefix: string(prefix)}
+}
+
+func (b *tableBatch) Put(key, value []byte) error {
+	return b.batch.Put(append([]byte(b.prefix), key...), value)
+}
+
+func (b *tableBatch) Delete(key []byte) error {
+	return b.batch.Delete(append([]byte(b.prefix), key...))
+}
+
+func (b *tableBatch) ValueSize() int {
+	return b.batch.ValueSize()
+}
+
+func (b *tableBatch) Write() error {
+	return b.batch.Write()
+}
+
+func (b *tableBatch) Reset() {
+	b.batch.Reset()
+}
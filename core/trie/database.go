@@ -1,6 +1,9 @@
 package trie
 
 import (
+	"bytes"
+	"encoding/binary"
+
 	"github.com/ecoball/go-ecoball/common"
 	"github.com/ecoball/go-ecoball/common/elog"
 	"github.com/ecoball/go-ecoball/core/store"
@@ -11,6 +14,9 @@ import (
 const secureKeyLength = 11 + 32
 
 var secureKeyPrefix = []byte("secure-key-")
+
+// the prefix of the reference count of a persisted node
+var refPrefix = []byte("ref-")
 var log = elog.NewLogger("trie", elog.InfoLog)
 
 type DatabaseReader interface {
@@ -188,15 +194,46 @@ func (db *Database) dereference(child common.Hash, parent common.Hash) {
 }
 
 func (db *Database) Commit(node common.Hash, report bool) error {
-	db.lock.RLock()
-
 	start := time.Now()
 	batch := db.diskDB.NewBatch()
+	db.lock.RLock()
+	nodes, storage := len(db.nodes), db.nodesSize+db.preImagesSize
+	db.lock.RUnlock()
+	if err := db.CommitTo(node, batch); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to write trie to disk", "err", err)
+		return err
+	}
+	db.Uncache(node)
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	logger := log.Info
+	if !report {
+		logger = log.Debug
+	}
+	logger("Persisted trie from memory database", "nodes", nodes-len(db.nodes), "size", storage-db.nodesSize, "time", time.Since(start),
+		"gcNodes", db.gcNodes, "gcSize", db.gcSize, "gcTime", db.gcTime, "liveNodes", len(db.nodes), "liveSize", db.nodesSize)
+
+	db.gcNodes, db.gcSize, db.gcTime = 0, 0, 0
+
+	return nil
+}
+
+/**
+** write the preImages and the node with its children cached in memory into the batch, the nodes stay in
+** memory until Uncache is called after the batch is written
+ */
+func (db *Database) CommitTo(node common.Hash, batch store.Batch) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
 
 	for hash, preImage := range db.preImages {
 		if err := batch.Put(db.secureKey(hash[:]), preImage); err != nil {
 			log.Error("Failed to commit preImage from trie database", "err", err)
-			db.lock.RUnlock()
 			return err
 		}
 		if batch.ValueSize() > store.IdealBatchSize {
@@ -206,19 +243,17 @@ func (db *Database) Commit(node common.Hash, report bool) error {
 			batch.Reset()
 		}
 	}
-	nodes, storage := len(db.nodes), db.nodesSize+db.preImagesSize
 	if err := db.commit(node, batch); err != nil {
 		log.Error("Failed to commit trie from trie database", "err", err)
-		db.lock.RUnlock()
-		return err
-	}
-	if err := batch.Write(); err != nil {
-		log.Error("Failed to write trie to disk", "err", err)
-		db.lock.RUnlock()
 		return err
 	}
-	db.lock.RUnlock()
+	return nil
+}
 
+/**
+** drop the preImages and the node with its children from memory, they must have been written to disk
+ */
+func (db *Database) Uncache(node common.Hash) {
 	db.lock.Lock()
 	defer db.lock.Unlock()
 
@@ -226,17 +261,6 @@ func (db *Database) Commit(node common.Hash, report bool) error {
 	db.preImagesSize = 0
 
 	db.unCache(node)
-
-	logger := log.Info
-	if !report {
-		logger = log.Debug
-	}
-	logger("Persisted trie from memory database", "nodes", nodes-len(db.nodes), "size", storage-db.nodesSize, "time", time.Since(start),
-		"gcNodes", db.gcNodes, "gcSize", db.gcSize, "gcTime", db.gcTime, "liveNodes", len(db.nodes), "liveSize", db.nodesSize)
-
-	db.gcNodes, db.gcSize, db.gcTime = 0, 0, 0
-
-	return nil
 }
 
 func (db *Database) commit(hash common.Hash, batch store.Batch) error {
@@ -278,3 +302,214 @@ func (db *Database) Size() common.StorageSize {
 	defer db.lock.RUnlock()
 	return db.nodesSize + db.preImagesSize
 }
+
+func refKey(hash common.Hash) []byte {
+	return append(common.CopyBytes(refPrefix), hash[:]...)
+}
+
+/**
+** get the node whose data or reference count is stored by the key of disk database
+** @return true if the key stores the data of node, false if the key is not a key of node
+ */
+func NodeOfKey(key []byte) (hash common.Hash, isNode bool, ok bool) {
+	switch {
+	case len(key) == common.HashLen:
+		return common.BytesToHash(key), true, true
+	case len(key) == len(refPrefix)+common.HashLen && bytes.HasPrefix(key, refPrefix):
+		return common.BytesToHash(key[len(refPrefix):]), false, true
+	default:
+		return common.Hash{}, false, false
+	}
+}
+
+/**
+** the reference counts of persisted nodes for pruning, a node is counted once for every persisted node referencing
+** it and every retained root referencing it, it's deleted with its key when the count drops to zero. The nodes
+** persisted before pruning is enabled have no count and are never deleted. The counts are read from disk on first
+** use and written into the batch by Write
+ */
+type RefCounter struct {
+	db      *Database
+	batch   store.Batch
+	counts  map[common.Hash]uint32
+	dirty   map[common.Hash]struct{}
+	rebuild bool
+}
+
+/**
+** count the references of the nodes written into the batch, the new roots must be referenced before the old ones
+** are released in the same batch
+ */
+func (db *Database) NewRefCounter(batch store.Batch) *RefCounter {
+	return &RefCounter{
+		db:     db,
+		batch:  batch,
+		counts: make(map[common.Hash]uint32),
+		dirty:  make(map[common.Hash]struct{}),
+	}
+}
+
+/**
+** count the references of all persisted nodes reachable from the referenced roots again, the counts on disk are
+** ignored, it's used by offline pruning to find the nodes to keep
+ */
+func (db *Database) RebuildRefCounter(batch store.Batch) *RefCounter {
+	r := db.NewRefCounter(batch)
+	r.rebuild = true
+	return r
+}
+
+func (r *RefCounter) count(hash common.Hash) (uint32, error) {
+	if n, ok := r.counts[hash]; ok || r.rebuild {
+		return n, nil
+	}
+	key := refKey(hash)
+	if has, err := r.db.diskDB.Has(key); err != nil || !has {
+		return 0, err
+	}
+	data, err := r.db.diskDB.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	n := binary.BigEndian.Uint32(data)
+	r.counts[hash] = n
+	return n, nil
+}
+
+func (r *RefCounter) set(hash common.Hash, n uint32) {
+	r.counts[hash] = n
+	r.dirty[hash] = struct{}{}
+}
+
+// the data of a node not counted yet, nil if the node is persisted before pruning is enabled
+func (r *RefCounter) blob(hash common.Hash) ([]byte, error) {
+	if hash == emptyRoot || hash == (common.Hash{}) {
+		return nil, nil
+	}
+	if r.rebuild {
+		return r.db.diskDB.Get(hash[:])
+	}
+	r.db.lock.RLock()
+	node := r.db.nodes[hash]
+	r.db.lock.RUnlock()
+	if node == nil {
+		return nil, nil
+	}
+	if has, err := r.db.diskDB.Has(hash[:]); err != nil || has {
+		return nil, err
+	}
+	return node.blob, nil
+}
+
+/**
+** add a reference to the node, the node not counted yet references its children
+ */
+func (r *RefCounter) Reference(hash common.Hash) error {
+	n, err := r.count(hash)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		r.set(hash, n+1)
+		return nil
+	}
+	blob, err := r.blob(hash)
+	if err != nil || blob == nil {
+		return err
+	}
+	r.set(hash, 1)
+	children, err := childrenOf(hash, blob)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := r.Reference(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+** remove a reference of the node, the node is deleted and releases its children when no reference is left
+ */
+func (r *RefCounter) Release(hash common.Hash) error {
+	n, err := r.count(hash)
+	if err != nil || n == 0 {
+		return err
+	}
+	if n > 1 {
+		r.set(hash, n-1)
+		return nil
+	}
+	blob, err := r.db.diskDB.Get(hash[:])
+	if err != nil {
+		return err
+	}
+	r.set(hash, 0)
+	if err := r.batch.Delete(hash[:]); err != nil {
+		return err
+	}
+	children, err := childrenOf(hash, blob)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := r.Release(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+** check the node is referenced
+ */
+func (r *RefCounter) Counted(hash common.Hash) bool {
+	return r.counts[hash] > 0
+}
+
+/**
+** write the changed counts into the batch
+ */
+func (r *RefCounter) Write() error {
+	for hash := range r.dirty {
+		if n := r.counts[hash]; n == 0 {
+			if err := r.batch.Delete(refKey(hash)); err != nil {
+				return err
+			}
+		} else {
+			data := make([]byte, 4)
+			binary.BigEndian.PutUint32(data, n)
+			if err := r.batch.Put(refKey(hash), data); err != nil {
+				return err
+			}
+		}
+	}
+	r.dirty = make(map[common.Hash]struct{})
+	return nil
+}
+
+// the hashes of the persisted nodes referenced by a node, the embedded nodes are walked through
+func childrenOf(hash common.Hash, blob []byte) ([]common.Hash, error) {
+	n, err := decodeNode(hash[:], blob, 0)
+	if err != nil {
+		return nil, err
+	}
+	var children []common.Hash
+	var walk func(n node)
+	walk = func(n node) {
+		switch n := n.(type) {
+		case *shortNode:
+			walk(n.Val)
+		case *fullNode:
+			for _, child := range n.Children[:16] {
+				walk(child)
+			}
+		case hashNode:
+			children = append(children, common.BytesToHash(n))
+		}
+	}
+	walk(n)
+	return children, nil
+}
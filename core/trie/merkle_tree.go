@@ -2,12 +2,24 @@ package trie
 
 import (
 	"crypto/sha256"
+	"errors"
+	"fmt"
 	"github.com/ecoball/go-ecoball/common"
 )
 
 type MerkleTree struct {
 	Depth uint
 	Root  *MerkleNode
+	//the nodes of every level from the leaves to the root
+	levels [][]*MerkleNode
+}
+
+/**
+** the sibling of a node on the path from a leaf to the root, Left is true if the sibling is the left child
+ */
+type MerkleProofNode struct {
+	Hash common.Hash `json:"hash"`
+	Left bool        `json:"left"`
 }
 
 /**
@@ -28,14 +40,54 @@ func NewMerkleTree(hashes []common.Hash) *MerkleTree {
 		nodes = append(nodes, &MerkleNode{h, nil, nil})
 	}
 	var height uint = 1
+	levels := [][]*MerkleNode{nodes}
 	for len(nodes) > 1 {
 		nodes = buildTree(nodes)
+		levels = append(levels, nodes)
 		height += 1
 	}
 	return &MerkleTree{
-		Depth: height,
-		Root:  nodes[0],
+		Depth:  height,
+		Root:   nodes[0],
+		levels: levels,
+	}
+}
+
+/**
+** the proof of the leaf at index, the siblings from the leaf to the root, the last node of an odd level is
+** paired with itself
+ */
+func (m *MerkleTree) Proof(index int) ([]MerkleProofNode, error) {
+	if index < 0 || index >= len(m.levels[0]) {
+		return nil, errors.New(fmt.Sprintf("the leaf index %d is out of range", index))
+	}
+	var proof []MerkleProofNode
+	for _, nodes := range m.levels[:len(m.levels)-1] {
+		if index%2 == 1 {
+			proof = append(proof, MerkleProofNode{Hash: nodes[index-1].hash, Left: true})
+		} else if index+1 < len(nodes) {
+			proof = append(proof, MerkleProofNode{Hash: nodes[index+1].hash})
+		} else {
+			proof = append(proof, MerkleProofNode{Hash: nodes[index].hash})
+		}
+		index /= 2
+	}
+	return proof, nil
+}
+
+/**
+** check the leaf is in the tree of root by the proof returned by MerkleTree.Proof
+ */
+func VerifyMerkleProof(root, leaf common.Hash, proof []MerkleProofNode) bool {
+	hash := leaf
+	for _, node := range proof {
+		if node.Left {
+			hash = merkleHash([]common.Hash{node.Hash, hash})
+		} else {
+			hash = merkleHash([]common.Hash{hash, node.Hash})
+		}
 	}
+	return hash.Equals(&root)
 }
 
 /**
@@ -31,3 +31,27 @@ func TestMerkleTree(t *testing.T) {
 	fmt.Println(merkleRoot.HexString())
 
 }
+
+func TestMerkleProof(t *testing.T) {
+	var hashes []common.Hash
+	for i := 0; i < 7; i++ {
+		hashes = append(hashes, common.SingleHash([]byte{byte(i)}))
+		root, _ := trie.GetMerkleRoot(hashes)
+		tree := trie.NewMerkleTree(hashes)
+		for j, h := range hashes {
+			proof, err := tree.Proof(j)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !trie.VerifyMerkleProof(root, h, proof) {
+				t.Fatal("the proof of leaf", j, "in", len(hashes), "leaves is invalid")
+			}
+			if trie.VerifyMerkleProof(root, hashes[(j+1)%len(hashes)], proof) && len(hashes) > 1 {
+				t.Fatal("the proof must not verify another leaf")
+			}
+		}
+		if _, err := tree.Proof(len(hashes)); err == nil {
+			t.Fatal("the index out of range must fail")
+		}
+	}
+}
@@ -63,6 +63,12 @@ func (t *Trie) newFlag() nodeFlag {
 	return nodeFlag{dirty: true, gen: t.cacheGen}
 }
 
+// Copy returns a copy of the trie, the changes of the copy don't affect the original one.
+func (t *Trie) Copy() *Trie {
+	cpy := *t
+	return &cpy
+}
+
 func (t *Trie) NodeIterator(start []byte) NodeIterator {
 	return newNodeIterator(t, start)
 }
@@ -0,0 +1,236 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/pb"
+)
+
+/**
+** The parameter and return types of contract ABI, int32 and int64 are passed to the method by value,
+** the others are written into the linear memory and passed as an (offset, length) pair
+*/
+const (
+	AbiInt32  = "int32"
+	AbiInt64  = "int64"
+	AbiString = "string"
+	AbiBytes  = "bytes"
+	AbiName   = "name"
+	AbiAsset  = "asset"
+)
+
+var assetRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9]+)? [A-Z]{1,12}$`)
+
+type AbiParam struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type AbiMethod struct {
+	Name    string     `json:"name"`
+	Params  []AbiParam `json:"params"`
+	Returns []string   `json:"returns"`
+}
+
+type Abi struct {
+	Methods []AbiMethod `json:"methods"`
+}
+
+/**
+ *  @brief parse the ABI from json and check the types of methods
+ *  @param data - the json description of ABI
+ */
+func NewAbi(data []byte) (*Abi, error) {
+	abi := new(Abi)
+	if err := json.Unmarshal(data, abi); err != nil {
+		return nil, err
+	}
+	if err := abi.Check(); err != nil {
+		return nil, err
+	}
+	return abi, nil
+}
+
+/**
+ *  @brief check the method names are unique and all types are known
+ */
+func (a *Abi) Check() error {
+	names := make(map[string]bool, len(a.Methods))
+	for _, m := range a.Methods {
+		if m.Name == "" {
+			return errors.New("abi method name is empty")
+		}
+		if names[m.Name] {
+			return errors.New(fmt.Sprintf("abi method %s is duplicated", m.Name))
+		}
+		names[m.Name] = true
+		for _, p := range m.Params {
+			switch p.Type {
+			case AbiInt32, AbiInt64, AbiString, AbiBytes, AbiName, AbiAsset:
+			default:
+				return errors.New(fmt.Sprintf("abi method %s has unknown parameter type %s", m.Name, p.Type))
+			}
+		}
+		if len(m.Returns) > 1 {
+			return errors.New(fmt.Sprintf("abi method %s has more than one return", m.Name))
+		}
+		for _, r := range m.Returns {
+			if r != AbiInt32 && r != AbiInt64 {
+				return errors.New(fmt.Sprintf("abi method %s has unsupported return type %s", m.Name, r))
+			}
+		}
+	}
+	return nil
+}
+
+/**
+ *  @brief find the method by name
+ *  @param name - the method name
+ */
+func (a *Abi) Method(name string) (*AbiMethod, error) {
+	for i := range a.Methods {
+		if a.Methods[i].Name == name {
+			return &a.Methods[i], nil
+		}
+	}
+	return nil, errors.New(fmt.Sprintf("abi has no method named %s", name))
+}
+
+/**
+ *  @brief check the arguments against the parameter types and convert them into the canonical form stored in
+ *  InvokeInfo.Param, bytes are given in hex and stored as raw bytes
+ *  @param args - the arguments in text form
+ */
+func (m *AbiMethod) EncodeArgs(args []string) ([]string, error) {
+	if len(args) != len(m.Params) {
+		return nil, errors.New(fmt.Sprintf("method %s needs %d arguments, but got %d", m.Name, len(m.Params), len(args)))
+	}
+	params := make([]string, len(args))
+	for i, p := range m.Params {
+		v := args[i]
+		switch p.Type {
+		case AbiInt32:
+			n, err := strconv.ParseInt(v, 10, 32)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("argument %s is not a int32: %s", p.Name, err))
+			}
+			params[i] = strconv.FormatInt(n, 10)
+		case AbiInt64:
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("argument %s is not a int64: %s", p.Name, err))
+			}
+			params[i] = strconv.FormatInt(n, 10)
+		case AbiBytes:
+			if !isHex(v) {
+				return nil, errors.New(fmt.Sprintf("argument %s is not hex bytes", p.Name))
+			}
+			params[i] = string(common.FromHex(v))
+		case AbiName:
+			if err := common.AccountNameCheck(v); err != nil {
+				return nil, errors.New(fmt.Sprintf("argument %s is not a account name: %s", p.Name, err))
+			}
+			params[i] = v
+		case AbiAsset:
+			if !assetRegexp.MatchString(v) {
+				return nil, errors.New(fmt.Sprintf("argument %s is not a asset like \"1.0000 ABA\"", p.Name))
+			}
+			params[i] = v
+		default:
+			params[i] = v
+		}
+	}
+	return params, nil
+}
+
+func isHex(s string) bool {
+	if len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		s = s[2:]
+	}
+	if len(s)%2 != 0 {
+		return false
+	}
+	for _, c := range []byte(s) {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+ *  @brief decode the result returned by the contract service with the return type of method
+ *  @param ret - the result of method
+ *  @return nil for the method without return
+ */
+func (m *AbiMethod) DecodeResult(ret []byte) (interface{}, error) {
+	if len(m.Returns) == 0 {
+		return nil, nil
+	}
+	switch m.Returns[0] {
+	case AbiInt32:
+		if len(ret) != 4 {
+			return nil, errors.New("the result is not a int32")
+		}
+		return int32(binary.LittleEndian.Uint32(ret)), nil
+	case AbiInt64:
+		if len(ret) != 8 {
+			return nil, errors.New("the result is not a int64")
+		}
+		return int64(binary.LittleEndian.Uint64(ret)), nil
+	default:
+		return nil, errors.New(fmt.Sprintf("unsupported return type %s", m.Returns[0]))
+	}
+}
+
+func (a *Abi) ProtoBuf() *pb.Abi {
+	if a == nil {
+		return nil
+	}
+	p := &pb.Abi{}
+	for _, m := range a.Methods {
+		method := &pb.AbiMethod{Name: m.Name, Returns: m.Returns}
+		for _, v := range m.Params {
+			method.Params = append(method.Params, &pb.AbiParam{Name: v.Name, Type: v.Type})
+		}
+		p.Methods = append(p.Methods, method)
+	}
+	return p
+}
+
+func NewAbiFromProto(p *pb.Abi) *Abi {
+	if p == nil {
+		return nil
+	}
+	a := &Abi{}
+	for _, m := range p.Methods {
+		method := AbiMethod{Name: m.Name, Returns: m.Returns}
+		for _, v := range m.Params {
+			method.Params = append(method.Params, AbiParam{Name: v.Name, Type: v.Type})
+		}
+		a.Methods = append(a.Methods, method)
+	}
+	return a
+}
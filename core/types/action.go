@@ -0,0 +1,180 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/pb"
+)
+
+//an action of multi-action transaction, it is executed as a transaction of its type from its own account
+type Action struct {
+	Type       TxType             `json:"type"`
+	From       common.AccountName `json:"from"`
+	Permission string             `json:"permission"`
+	Addr       common.AccountName `json:"addr"`
+	Payload    Payload            `json:"payload"`
+}
+
+type ActionList struct {
+	Actions []*Action `json:"actions"`
+}
+
+/**
+ *  @brief create an action of multi-action transaction
+ *  @param t - the type of action, TxTransfer, TxDeploy or TxInvoke
+ *  @param from - the account which authorizes the action
+ *  @param addr - the target of action
+ *  @param perm - the permission of from authorizes the action
+ *  @param payload - TransferInfo, DeployInfo or InvokeInfo
+ */
+func NewAction(t TxType, from, addr common.AccountName, perm string, payload Payload) (*Action, error) {
+	if t != TxTransfer && t != TxDeploy && t != TxInvoke {
+		return nil, errors.New(fmt.Sprintf("invalid action type:%d", t))
+	}
+	if payload == nil {
+		return nil, errors.New("the action's payload is nil")
+	}
+	if perm == "" {
+		perm = "active"
+	}
+	return &Action{Type: t, From: from, Permission: perm, Addr: addr, Payload: payload}, nil
+}
+
+/**
+ *  @brief create a transaction of ordered actions, the actions are executed atomically
+ *  @param from - the account pays the resources of transaction
+ *  @param perm - the permission of from signs the transaction
+ */
+func NewMultiAction(from common.AccountName, perm string, actions []*Action, nonce uint64, time int64) (*Transaction, error) {
+	if len(actions) == 0 {
+		return nil, errors.New("the transaction has no action")
+	}
+	return NewTransaction(TxMulti, from, from, perm, &ActionList{Actions: actions}, nonce, time)
+}
+
+/**
+ *  @brief the view of an action as a single transaction, it shares the hash, nonce, time and signatures of tx
+ *  @param a - the action of tx
+ */
+func (t *Transaction) ActionTransaction(a *Action) *Transaction {
+	return &Transaction{
+		Version:    t.Version,
+		Type:       a.Type,
+		From:       a.From,
+		Permission: a.Permission,
+		Addr:       a.Addr,
+		Nonce:      t.Nonce,
+		TimeStamp:  t.TimeStamp,
+		Payload:    a.Payload,
+		Signatures: t.Signatures,
+		Hash:       t.Hash,
+
+		ChainID:        t.ChainID,
+		Expiration:     t.Expiration,
+		RefBlockNum:    t.RefBlockNum,
+		RefBlockPrefix: t.RefBlockPrefix,
+
+		Payer:           t.Payer,
+		PayerPermission: t.PayerPermission,
+	}
+}
+
+func (l ActionList) GetObject() interface{} {
+	return l
+}
+
+/**
+ *  @brief converts a structure into a sequence of characters
+ *  @return []byte - a sequence of characters
+ */
+func (l *ActionList) Serialize() ([]byte, error) {
+	p := &pb.ActionList{}
+	for _, a := range l.Actions {
+		payload, err := a.Payload.Serialize()
+		if err != nil {
+			return nil, err
+		}
+		p.Actions = append(p.Actions, &pb.Action{
+			Type:       uint32(a.Type),
+			From:       uint64(a.From),
+			Permission: []byte(a.Permission),
+			Addr:       uint64(a.Addr),
+			Payload:    payload,
+		})
+	}
+	b, err := p.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+/**
+ *  @brief converts a sequence of characters into a structure
+ *  @param data - a sequence of characters
+ */
+func (l *ActionList) Deserialize(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("input data's length is zero")
+	}
+	var list pb.ActionList
+	if err := list.Unmarshal(data); err != nil {
+		return err
+	}
+	l.Actions = nil
+	for _, v := range list.Actions {
+		t := TxType(v.Type)
+		if t == TxMulti {
+			return errors.New("the action can't be a multi-action transaction")
+		}
+		payload, err := newPayload(t)
+		if err != nil {
+			return err
+		}
+		if err := payload.Deserialize(v.Payload); err != nil {
+			return err
+		}
+		l.Actions = append(l.Actions, &Action{
+			Type:       t,
+			From:       common.AccountName(v.From),
+			Permission: string(v.Permission),
+			Addr:       common.AccountName(v.Addr),
+			Payload:    payload,
+		})
+	}
+	return nil
+}
+
+func (l *ActionList) Show() {
+	for i, a := range l.Actions {
+		fmt.Println("\t---------------Action", i, "------------")
+		fmt.Println("\tType           :", a.Type)
+		fmt.Println("\tFrom           :", common.IndexToName(a.From))
+		fmt.Println("\tPermission     :", a.Permission)
+		fmt.Println("\tAddr           :", common.IndexToName(a.Addr))
+		a.Payload.Show()
+	}
+}
+
+func (l *ActionList) JsonString() string {
+	data, _ := json.Marshal(l)
+	return string(data)
+}
@@ -34,25 +34,25 @@ type Block struct {
 	Transactions []*Transaction
 }
 
-func NewBlock(prevHeader *Header, stateHash common.Hash, consensusData ConsensusData, txs []*Transaction) (*Block, error) {
+func NewBlock(prevHeader *Header, stateHash common.Hash, consensusData ConsensusData, txs []*Transaction, receipts []*Receipt, timeStamp int64) (*Block, error) {
 	if nil == prevHeader {
 		return nil, errors.New("invalid parameter preHeader")
 	}
-	timeStamp := time.Now().Unix()
-	var Bloom bloom.Bloom
+	Bloom := CreateBloom(txs, receipts)
 	var hashes []common.Hash
 	for _, t := range txs {
 		hashes = append(hashes, t.Hash)
-		Bloom.Add(t.Hash.Bytes())
-		Bloom.Add(common.IndexToBytes(t.From))
-		Bloom.Add(common.IndexToBytes(t.Addr))
 	}
 	merkleHash, err := trie.GetMerkleRoot(hashes)
 	if err != nil {
 		return nil, err
 	}
+	receiptHash, err := ReceiptsRoot(receipts)
+	if err != nil {
+		return nil, err
+	}
 
-	header, err := NewHeader(VersionHeader, prevHeader.Height+1, prevHeader.Hash, merkleHash, stateHash, consensusData, Bloom, timeStamp)
+	header, err := NewHeader(VersionHeader, prevHeader.Chain(), prevHeader.Height+1, prevHeader.Hash, merkleHash, stateHash, receiptHash, consensusData, Bloom, timeStamp)
 	if err != nil {
 		return nil, err
 	}
@@ -60,6 +60,26 @@ func NewBlock(prevHeader *Header, stateHash common.Hash, consensusData Consensus
 	return &block, nil
 }
 
+/**
+ *  @brief create the bloom of block header from the transactions and the topics of contract events
+ *  @param txs - the transactions of block
+ *  @param receipts - the receipts of transactions
+ */
+func CreateBloom(txs []*Transaction, receipts []*Receipt) bloom.Bloom {
+	var Bloom bloom.Bloom
+	for _, t := range txs {
+		Bloom.Add(t.Hash.Bytes())
+		Bloom.Add(common.IndexToBytes(t.From))
+		Bloom.Add(common.IndexToBytes(t.Addr))
+	}
+	for _, r := range receipts {
+		for _, e := range r.Events {
+			Bloom.Add([]byte(e.Topic))
+		}
+	}
+	return Bloom
+}
+
 func (b *Block) SetSignature(account *account.Account) error {
 	return b.Header.SetSignature(account)
 }
@@ -89,7 +109,7 @@ func GenesesBlockInit() (*Block, error) {
 
 	hash := common.NewHash([]byte("EcoBall Geneses Block"))
 	conData := GenesesBlockInitConsensusData(timeStamp)
-	header, err := NewHeader(VersionHeader, 1, hash, hash, hash, *conData, bloom.Bloom{}, timeStamp)
+	header, err := NewHeader(VersionHeader, common.Hash{}, 1, hash, hash, hash, common.Hash{}, *conData, bloom.Bloom{}, timeStamp)
 	if err != nil {
 		return nil, err
 	}
@@ -28,13 +28,15 @@ type DeployInfo struct {
 	TypeVm   VmType `json:"typeVm"`
 	Describe []byte `json:"describe"`
 	Code     []byte `json:"code"`
+	Abi      *Abi   `json:"abi"`
 }
 
-func NewDeployContract(from, addr common.AccountName, perm string, vm VmType, des string, code []byte, nonce uint64, time int64) (*Transaction, error) {
+func NewDeployContract(from, addr common.AccountName, perm string, vm VmType, des string, code []byte, abi *Abi, nonce uint64, time int64) (*Transaction, error) {
 	deploy := &DeployInfo{
 		TypeVm:   vm,
 		Describe: []byte(des),
 		Code:     code,
+		Abi:      abi,
 	}
 	trans, err := NewTransaction(TxDeploy, from, addr, perm, deploy, nonce, time)
 	if err != nil {
@@ -52,6 +54,7 @@ func (d *DeployInfo) Serialize() ([]byte, error) {
 		TypeVm:   uint32(d.TypeVm),
 		Describe: d.Describe,
 		Code:     d.Code,
+		Abi:      d.Abi.ProtoBuf(),
 	}
 	b, err := p.Marshal()
 	if err != nil {
@@ -75,6 +78,7 @@ func (d *DeployInfo) Deserialize(data []byte) error {
 	d.TypeVm = VmType(deploy.TypeVm)
 	d.Describe = common.CopyBytes(deploy.Describe)
 	d.Code = common.CopyBytes(deploy.Code)
+	d.Abi = NewAbiFromProto(deploy.Abi)
 
 	return nil
 }
@@ -32,12 +32,14 @@ const VersionHeader = 1
 
 type Header struct {
 	Version       uint32
+	ChainID       common.Hash
 	TimeStamp     int64
 	Height        uint64
 	ConsensusData ConsensusData
 	PrevHash      common.Hash
 	MerkleHash    common.Hash
 	StateHash     common.Hash
+	ReceiptHash   common.Hash
 	Bloom         bloom.Bloom
 	Signatures    []common.Signature
 
@@ -47,9 +49,9 @@ type Header struct {
 var log = elog.NewLogger("LedgerImpl", elog.DebugLog)
 
 /**
-* New a Header and compute it's hash
+* New a Header and compute it's hash, the chainID is the hash of geneses block and empty in the geneses block
  */
-func NewHeader(version uint32, height uint64, prevHash, merkleHash, stateHash common.Hash, conData ConsensusData, bloom bloom.Bloom, timeStamp int64) (*Header, error) {
+func NewHeader(version uint32, chainID common.Hash, height uint64, prevHash, merkleHash, stateHash, receiptHash common.Hash, conData ConsensusData, bloom bloom.Bloom, timeStamp int64) (*Header, error) {
 	if version != VersionHeader {
 		return nil, errors.New("version mismatch")
 	}
@@ -58,12 +60,14 @@ func NewHeader(version uint32, height uint64, prevHash, merkleHash, stateHash co
 	}
 	header := Header{
 		Version:       version,
+		ChainID:       chainID,
 		TimeStamp:     timeStamp,
 		Height:        height,
 		ConsensusData: conData,
 		PrevHash:      prevHash,
 		MerkleHash:    merkleHash,
 		StateHash:     stateHash,
+		ReceiptHash:   receiptHash,
 		Bloom:         bloom,
 	}
 	payload, err := header.unSignatureData()
@@ -105,6 +109,16 @@ func (h *Header) InitializeHash() error {
 	return nil
 }
 
+/**
+ *  @brief the ID of chain the header belongs to, it is the hash of geneses block
+ */
+func (h *Header) Chain() common.Hash {
+	if h.Height == 1 {
+		return h.Hash
+	}
+	return h.ChainID
+}
+
 func (h *Header) SetSignature(account *account.Account) error {
 	sigData, err := account.Sign(h.Hash.Bytes())
 	if err != nil {
@@ -141,6 +155,7 @@ func (h *Header) unSignatureData() (*pb.Header, error) {
 	}
 	return &pb.Header{
 		Version:       h.Version,
+		ChainId:       h.ChainID.Bytes(),
 		Timestamp:     h.TimeStamp,
 		Height:        h.Height,
 		ConsensusData: pbCon,
@@ -148,6 +163,7 @@ func (h *Header) unSignatureData() (*pb.Header, error) {
 		MerkleHash:    h.MerkleHash.Bytes(),
 		StateHash:     h.StateHash.Bytes(),
 		Bloom:         h.Bloom.Bytes(),
+		ReceiptHash:   h.ReceiptHash.Bytes(),
 	}, nil
 }
 
@@ -163,6 +179,7 @@ func (h *Header) protoBuf() (*pb.HeaderTx, error) {
 	}
 	return &pb.HeaderTx{
 		Version:       h.Version,
+		ChainId:       h.ChainID.Bytes(),
 		Timestamp:     h.TimeStamp,
 		Height:        h.Height,
 		ConsensusData: pbCon,
@@ -171,6 +188,7 @@ func (h *Header) protoBuf() (*pb.HeaderTx, error) {
 		Sign:          sig,
 		StateHash:     h.StateHash.Bytes(),
 		Bloom:         h.Bloom.Bytes(),
+		ReceiptHash:   h.ReceiptHash.Bytes(),
 		BlockHash:     h.Hash.Bytes(),
 	}, nil
 }
@@ -205,6 +223,7 @@ func (h *Header) Deserialize(data []byte) error {
 	}
 
 	h.Version = pbHeader.Version
+	h.ChainID = common.NewHash(pbHeader.ChainId)
 	h.TimeStamp = pbHeader.Timestamp
 	h.Height = pbHeader.Height
 	h.PrevHash = common.NewHash(pbHeader.PrevHash)
@@ -217,6 +236,7 @@ func (h *Header) Deserialize(data []byte) error {
 		h.Signatures = append(h.Signatures, sig)
 	}
 	h.StateHash = common.NewHash(pbHeader.StateHash)
+	h.ReceiptHash = common.NewHash(pbHeader.ReceiptHash)
 	h.Hash = common.NewHash(pbHeader.BlockHash)
 	h.Bloom = bloom.NewBloom(pbHeader.Bloom)
 
@@ -236,9 +256,11 @@ func (h *Header) show() {
 	fmt.Println("\tHeight         :", h.Height)
 	fmt.Println("\tTime           :", h.TimeStamp)
 	fmt.Println("\tVersion        :", h.Version)
+	fmt.Println("\tChainID        :", h.ChainID.HexString())
 	fmt.Println("\tPrevHash       :", h.PrevHash.HexString())
 	fmt.Println("\tMerkleHash     :", h.MerkleHash.HexString())
 	fmt.Println("\tStateHash      :", h.StateHash.HexString())
+	fmt.Println("\tReceiptHash    :", h.ReceiptHash.HexString())
 	fmt.Println("\tHash           :", h.Hash.HexString())
 	fmt.Println("\tSig Len        :", len(h.Signatures))
 	for i := 0; i < len(h.Signatures); i++ {
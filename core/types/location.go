@@ -0,0 +1,82 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/pb"
+	"github.com/ecoball/go-ecoball/core/trie"
+)
+
+//the block and position which a transaction is packed in
+type TxLocation struct {
+	BlockHash common.Hash `json:"blockHash"`
+	Height    uint64      `json:"height"`
+	Index     uint32      `json:"index"`
+}
+
+/**
+ *  @brief converts a structure into a sequence of characters
+ *  @return []byte - a sequence of characters
+ */
+func (l *TxLocation) Serialize() ([]byte, error) {
+	p := &pb.TxLocation{BlockHash: l.BlockHash.Bytes(), Height: l.Height, Index: l.Index}
+	return p.Marshal()
+}
+
+/**
+ *  @brief converts a sequence of characters into a structure
+ *  @param data - a sequence of characters
+ */
+func (l *TxLocation) Deserialize(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("input data's length is zero")
+	}
+	var p pb.TxLocation
+	if err := p.Unmarshal(data); err != nil {
+		return err
+	}
+	l.BlockHash = common.NewHash(p.BlockHash)
+	l.Height = p.Height
+	l.Index = p.Index
+	return nil
+}
+
+//a packed transaction with its location, receipt and the proof of its inclusion in the block
+type TxInclusion struct {
+	Transaction *Transaction           `json:"transaction"`
+	Location    TxLocation             `json:"location"`
+	Receipt     *Receipt               `json:"receipt"`
+	MerkleHash  common.Hash            `json:"merkleHash"`
+	Proof       []trie.MerkleProofNode `json:"proof"`
+}
+
+/**
+ *  @brief check the transaction is in the block by the proof, the caller must check the header of
+ *  Location.BlockHash has the MerkleHash
+ */
+func (i *TxInclusion) Verify() bool {
+	return trie.VerifyMerkleProof(i.MerkleHash, i.Transaction.Hash, i.Proof)
+}
+
+func (i *TxInclusion) JsonString() string {
+	data, _ := json.Marshal(i)
+	return string(data)
+}
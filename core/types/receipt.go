@@ -0,0 +1,168 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/pb"
+	"github.com/ecoball/go-ecoball/core/trie"
+)
+
+type Event struct {
+	Contract common.AccountName `json:"contract"`
+	Topic    string             `json:"topic"`
+	Data     []byte             `json:"data"`
+}
+
+//the resources billed to an account for a transaction
+type ResourceUsage struct {
+	Account common.AccountName `json:"account"`
+	Cpu     float32            `json:"cpu"`
+	Net     float32            `json:"net"`
+}
+
+type ReceiptStatus uint32
+
+const (
+	ReceiptSuccess ReceiptStatus = 0x01
+	ReceiptFailed  ReceiptStatus = 0x02
+)
+
+type Receipt struct {
+	TxHash common.Hash   `json:"txHash"`
+	Status ReceiptStatus `json:"status"`
+	Error  string        `json:"error"`
+	Cpu    float32       `json:"cpu"`
+	Net    float32       `json:"net"`
+	Result []byte        `json:"result"`
+	Events []*Event      `json:"events"`
+	//the accounts paying for the cpu and net of transaction, the sum is Cpu and Net
+	Usage []*ResourceUsage `json:"usage"`
+}
+
+/**
+ *  @brief create the receipt of a executed transaction
+ *  @param hash - the hash of transaction
+ *  @param result - the result returned by contract
+ *  @param cpu - the cpu used by transaction
+ *  @param net - the net used by transaction
+ *  @param events - the events emitted by contracts
+ *  @param err - the error of execution, nil if succeeded
+ */
+func NewReceipt(hash common.Hash, result []byte, cpu, net float32, events []*Event, err error) *Receipt {
+	r := &Receipt{TxHash: hash, Status: ReceiptSuccess, Cpu: cpu, Net: net, Result: result, Events: events}
+	if err != nil {
+		r.Status = ReceiptFailed
+		r.Error = err.Error()
+	}
+	return r
+}
+
+/**
+ *  @brief bill resources to an account, the usage of the same account is merged
+ *  @param account - the account paying for the resources
+ *  @param cpu - the cpu billed
+ *  @param net - the net billed
+ */
+func (r *Receipt) AddUsage(account common.AccountName, cpu, net float32) {
+	for _, u := range r.Usage {
+		if u.Account == account {
+			u.Cpu += cpu
+			u.Net += net
+			return
+		}
+	}
+	r.Usage = append(r.Usage, &ResourceUsage{Account: account, Cpu: cpu, Net: net})
+}
+
+/**
+ *  @brief compute the merkle root of receipts, every leaf is the hash of serialized receipt without the error
+ *  message, the message is the text of Go errors which is not stable across nodes, only the status is committed
+ *  @param receipts - the receipts of block in the order of transactions
+ */
+func ReceiptsRoot(receipts []*Receipt) (common.Hash, error) {
+	var hashes []common.Hash
+	for _, r := range receipts {
+		leaf := *r
+		leaf.Error = ""
+		data, err := leaf.Serialize()
+		if err != nil {
+			return common.Hash{}, err
+		}
+		hashes = append(hashes, common.SingleHash(data))
+	}
+	return trie.GetMerkleRoot(hashes)
+}
+
+/**
+ *  @brief converts a structure into a sequence of characters
+ *  @return []byte - a sequence of characters
+ */
+func (r *Receipt) Serialize() ([]byte, error) {
+	p := &pb.Receipt{
+		TxHash: r.TxHash.Bytes(),
+		Status: uint32(r.Status),
+		Error:  r.Error,
+		Cpu:    r.Cpu,
+		Net:    r.Net,
+		Result: r.Result,
+	}
+	for _, e := range r.Events {
+		p.Events = append(p.Events, &pb.Event{Contract: uint64(e.Contract), Topic: e.Topic, Data: e.Data})
+	}
+	for _, u := range r.Usage {
+		p.Usage = append(p.Usage, &pb.ResourceUsage{Account: uint64(u.Account), Cpu: u.Cpu, Net: u.Net})
+	}
+	return p.Marshal()
+}
+
+/**
+ *  @brief converts a sequence of characters into a structure
+ *  @param data - a sequence of characters
+ */
+func (r *Receipt) Deserialize(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("input data's length is zero")
+	}
+	var p pb.Receipt
+	if err := p.Unmarshal(data); err != nil {
+		return err
+	}
+	r.TxHash = common.NewHash(p.TxHash)
+	r.Status = ReceiptStatus(p.Status)
+	r.Error = p.Error
+	r.Cpu = p.Cpu
+	r.Net = p.Net
+	r.Result = common.CopyBytes(p.Result)
+	r.Events = nil
+	for _, e := range p.Events {
+		r.Events = append(r.Events, &Event{Contract: common.AccountName(e.Contract), Topic: e.Topic, Data: common.CopyBytes(e.Data)})
+	}
+	r.Usage = nil
+	for _, u := range p.Usage {
+		r.Usage = append(r.Usage, &ResourceUsage{Account: common.AccountName(u.Account), Cpu: u.Cpu, Net: u.Net})
+	}
+	return nil
+}
+
+func (r *Receipt) JsonString() string {
+	data, _ := json.Marshal(r)
+	return string(data)
+}
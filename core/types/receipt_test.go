@@ -0,0 +1,53 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package types_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/types"
+)
+
+func TestReceiptsRoot(t *testing.T) {
+	hash := common.SingleHash([]byte("tx"))
+	root := func(receipts ...*types.Receipt) common.Hash {
+		h, err := types.ReceiptsRoot(receipts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	failed := root(types.NewReceipt(hash, nil, 1, 2, nil, errors.New("out of gas")))
+	reworded := root(types.NewReceipt(hash, nil, 1, 2, nil, errors.New("wasm: out of gas")))
+	if !failed.Equals(&reworded) {
+		t.Fatal("the error message must not be committed in the receipts root")
+	}
+	succeeded := root(types.NewReceipt(hash, nil, 1, 2, nil, nil))
+	if failed.Equals(&succeeded) {
+		t.Fatal("the status must be committed in the receipts root")
+	}
+	returned := root(types.NewReceipt(hash, []byte("result"), 1, 2, nil, nil))
+	if succeeded.Equals(&returned) {
+		t.Fatal("the result must be committed in the receipts root")
+	}
+	used := root(types.NewReceipt(hash, nil, 3, 2, nil, nil))
+	if succeeded.Equals(&used) {
+		t.Fatal("the resources used must be committed in the receipts root")
+	}
+}
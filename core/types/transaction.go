@@ -17,6 +17,7 @@
 package types
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -28,12 +29,22 @@ import (
 
 const VersionTx = 1
 
+const (
+	//the maximum seconds between the time of block packing a transaction and its expiration
+	MaxTxLifetime int64 = 3600
+	//the seconds a transaction lives by default, it leaves a margin for the clock skew of nodes
+	DefaultTxLifetime int64 = 600
+	//the number of recent blocks which can be referenced by a transaction
+	RefBlockWindow uint64 = 1024
+)
+
 type TxType uint32
 
 const (
 	TxDeploy   TxType = 0x01
 	TxInvoke   TxType = 0x02
 	TxTransfer TxType = 0x03
+	TxMulti    TxType = 0x04
 )
 
 type VmType uint32
@@ -61,6 +72,16 @@ type Transaction struct {
 	Payload    Payload            `json:"payload"`
 	Signatures []common.Signature `json:"signatures"`
 	Hash       common.Hash        `json:"hash"`
+	//TaPoS, the transaction is only valid on the chain containing the reference block and before the expiration
+	ChainID        common.Hash `json:"chainId"`
+	Expiration     int64       `json:"expiration"`
+	RefBlockNum    uint64      `json:"refBlockNum"`
+	RefBlockPrefix uint32      `json:"refBlockPrefix"`
+	//the account billed for the cpu and net instead of the sender, it must authorize the transaction too
+	Payer           common.AccountName `json:"payer"`
+	PayerPermission string             `json:"payerPermission"`
+	//the maximum gas of execution, 0 is the limit of chain, the billed account must have cpu for it
+	GasLimit uint64 `json:"gasLimit"`
 }
 
 func NewTransaction(t TxType, from, addr common.AccountName, perm string, payload Payload, nonce uint64, time int64) (*Transaction, error) {
@@ -80,15 +101,99 @@ func NewTransaction(t TxType, from, addr common.AccountName, perm string, payloa
 	if tx.Permission == "" {
 		tx.Permission = "active"
 	}
-	data, err := tx.unSignatureData()
-	if err != nil {
+	if err := tx.updateHash(); err != nil {
 		return nil, err
 	}
-	tx.Hash, err = common.DoubleHash(data)
+	return &tx, nil
+}
+
+/**
+ *  @brief the prefix of block hash referenced by transactions
+ *  @param hash - the hash of block
+ */
+func RefBlockPrefix(hash common.Hash) uint32 {
+	return binary.BigEndian.Uint32(hash.Bytes()[:4])
+}
+
+/**
+ *  @brief bind the transaction to a block of chain, the transaction is invalid on the other chains, the chains
+ *  without the block and after the expiration, the hash is changed so it must be called before signing
+ *  @param header - the header of reference block, usually the head of chain
+ *  @param expiration - the unix time after which the transaction is invalid, no later than MaxTxLifetime after
+ *  the time of block packing it
+ */
+func (t *Transaction) SetReference(header *Header, expiration int64) error {
+	return t.SetChainReference(header.Chain(), header.Height, header.Hash, expiration)
+}
+
+/**
+ *  @brief bind the transaction to a block like SetReference, the clients without the header use the chain ID,
+ *  height and hash of block queried from the node
+ *  @param chainID - the ID of chain, the hash of geneses block
+ *  @param height - the height of reference block
+ *  @param hash - the hash of reference block
+ */
+func (t *Transaction) SetChainReference(chainID common.Hash, height uint64, hash common.Hash, expiration int64) error {
+	if len(t.Signatures) != 0 {
+		return errors.New("the reference block of a signed transaction can't be changed")
+	}
+	t.ChainID = chainID
+	t.RefBlockNum = height
+	t.RefBlockPrefix = RefBlockPrefix(hash)
+	t.Expiration = expiration
+	return t.updateHash()
+}
+
+/**
+ *  @brief bill the cpu and net of transaction to another account, the payer must sign the transaction with
+ *  the permission, the hash is changed so it must be called before signing
+ *  @param payer - the account paying for the resources, 0 for the sender
+ *  @param perm - the permission of payer authorizing the transaction, "active" if empty
+ */
+func (t *Transaction) SetPayer(payer common.AccountName, perm string) error {
+	if len(t.Signatures) != 0 {
+		return errors.New("the payer of a signed transaction can't be changed")
+	}
+	if perm == "" {
+		perm = "active"
+	}
+	t.Payer = payer
+	t.PayerPermission = perm
+	if payer == 0 {
+		t.PayerPermission = ""
+	}
+	return t.updateHash()
+}
+
+/**
+ *  @brief set the maximum gas of execution, the hash is changed so it must be called before signing
+ *  @param limit - the maximum gas, 0 for the limit of chain
+ */
+func (t *Transaction) SetGasLimit(limit uint64) error {
+	if len(t.Signatures) != 0 {
+		return errors.New("the gas limit of a signed transaction can't be changed")
+	}
+	t.GasLimit = limit
+	return t.updateHash()
+}
+
+/**
+ *  @brief the account billed for the resources of transaction, the payer if it is set, else the sender
+ */
+func (t *Transaction) Billed() common.AccountName {
+	if t.Payer != 0 {
+		return t.Payer
+	}
+	return t.From
+}
+
+func (t *Transaction) updateHash() error {
+	data, err := t.unSignatureData()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return &tx, nil
+	t.Hash, err = common.DoubleHash(data)
+	return err
 }
 
 func (t *Transaction) SetSignature(account *account.Account) error {
@@ -119,13 +224,20 @@ func (t *Transaction) unSignatureData() ([]byte, error) {
 		return nil, err
 	}
 	p := &pb.TxPayload{
-		Version:    t.Version,
-		From:       uint64(t.From),
-		Permission: []byte(t.Permission),
-		Addr:       uint64(t.Addr),
-		Payload:    payload,
-		Nonce:      t.Nonce,
-		Timestamp:  t.TimeStamp,
+		Version:         t.Version,
+		From:            uint64(t.From),
+		Permission:      []byte(t.Permission),
+		Addr:            uint64(t.Addr),
+		Payload:         payload,
+		Nonce:           t.Nonce,
+		Timestamp:       t.TimeStamp,
+		ChainId:         t.ChainID.Bytes(),
+		Expiration:      t.Expiration,
+		RefBlockNum:     t.RefBlockNum,
+		RefBlockPrefix:  t.RefBlockPrefix,
+		Payer:           uint64(t.Payer),
+		PayerPermission: []byte(t.PayerPermission),
+		GasLimit:        t.GasLimit,
 	}
 	b, err := p.Marshal()
 	if err != nil {
@@ -146,14 +258,21 @@ func (t *Transaction) protoBuf() (*pb.Transaction, error) {
 	}
 	p := &pb.Transaction{
 		Payload: &pb.TxPayload{
-			Version:    t.Version,
-			Type:       uint32(t.Type),
-			From:       uint64(t.From),
-			Permission: []byte(t.Permission),
-			Addr:       uint64(t.Addr),
-			Payload:    payload,
-			Nonce:      t.Nonce,
-			Timestamp:  t.TimeStamp,
+			Version:         t.Version,
+			Type:            uint32(t.Type),
+			From:            uint64(t.From),
+			Permission:      []byte(t.Permission),
+			Addr:            uint64(t.Addr),
+			Payload:         payload,
+			Nonce:           t.Nonce,
+			Timestamp:       t.TimeStamp,
+			ChainId:         t.ChainID.Bytes(),
+			Expiration:      t.Expiration,
+			RefBlockNum:     t.RefBlockNum,
+			RefBlockPrefix:  t.RefBlockPrefix,
+			Payer:           uint64(t.Payer),
+			PayerPermission: []byte(t.PayerPermission),
+			GasLimit:        t.GasLimit,
 		},
 		Sign: sig,
 		Hash: t.Hash.Bytes(),
@@ -198,17 +317,19 @@ func (t *Transaction) Deserialize(data []byte) error {
 	t.Addr = common.AccountName(tx.Payload.Addr)
 	t.Nonce = tx.Payload.Nonce
 	t.TimeStamp = tx.Payload.Timestamp
+	t.ChainID = common.NewHash(tx.Payload.ChainId)
+	t.Expiration = tx.Payload.Expiration
+	t.RefBlockNum = tx.Payload.RefBlockNum
+	t.RefBlockPrefix = tx.Payload.RefBlockPrefix
+	t.Payer = common.AccountName(tx.Payload.Payer)
+	t.PayerPermission = string(tx.Payload.PayerPermission)
+	t.GasLimit = tx.Payload.GasLimit
 	if t.Payload == nil {
-		switch t.Type {
-		case TxTransfer:
-			t.Payload = new(TransferInfo)
-		case TxDeploy:
-			t.Payload = new(DeployInfo)
-		case TxInvoke:
-			t.Payload = new(InvokeInfo)
-		default:
-			return errors.New("the transaction's payload must not be nil")
+		payload, err := newPayload(t.Type)
+		if err != nil {
+			return err
 		}
+		t.Payload = payload
 	}
 	if err := t.Payload.Deserialize(tx.Payload.Payload); err != nil {
 		return err
@@ -225,12 +346,37 @@ func (t *Transaction) Deserialize(data []byte) error {
 	return nil
 }
 
+// create an empty payload of the transaction type for deserialization
+func newPayload(t TxType) (Payload, error) {
+	switch t {
+	case TxTransfer:
+		return new(TransferInfo), nil
+	case TxDeploy:
+		return new(DeployInfo), nil
+	case TxInvoke:
+		return new(InvokeInfo), nil
+	case TxMulti:
+		return new(ActionList), nil
+	default:
+		return nil, errors.New("the transaction's payload must not be nil")
+	}
+}
+
 func (t *Transaction) Show() {
 	fmt.Println("\t---------------Transaction-------------")
 	fmt.Println("\tVersion        :", t.Version)
 	fmt.Println("\tFrom           :", common.IndexToName(t.From))
 	fmt.Println("\tAddr           :", common.IndexToName(t.Addr))
 	fmt.Println("\tTime           :", t.TimeStamp)
+	fmt.Println("\tChainID        :", t.ChainID.HexString())
+	fmt.Println("\tExpiration     :", t.Expiration)
+	fmt.Println("\tRef Block      :", t.RefBlockNum, t.RefBlockPrefix)
+	if t.Payer != 0 {
+		fmt.Println("\tPayer          :", common.IndexToName(t.Payer), t.PayerPermission)
+	}
+	if t.GasLimit != 0 {
+		fmt.Println("\tGas Limit      :", t.GasLimit)
+	}
 	fmt.Println("\tHash           :", t.Hash.HexString())
 	fmt.Println("\tSig Len        :", len(t.Signatures))
 	for i := 0; i < len(t.Signatures); i++ {
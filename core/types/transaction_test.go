@@ -19,9 +19,12 @@ package types_test
 import (
 	"errors"
 	"fmt"
+	"github.com/ecoball/go-ecoball/common"
 	"github.com/ecoball/go-ecoball/core/types"
 	"github.com/ecoball/go-ecoball/test/example"
+	"math/big"
 	"testing"
+	"time"
 )
 
 func TestTransfer(t *testing.T) {
@@ -87,3 +90,42 @@ func TestInvoke(t *testing.T) {
 	}
 	i2.Show()
 }
+
+func TestMultiAction(t *testing.T) {
+	root := common.NameToIndex("root")
+	worker := common.NameToIndex("worker")
+	transfer, err := types.NewAction(types.TxTransfer, root, worker, "active", types.NewTransferInfo(big.NewInt(100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	invoke, err := types.NewAction(types.TxInvoke, worker, common.NameToIndex("delegate"), "", &types.InvokeInfo{Method: []byte("pledge"), Param: []string{"worker", "worker", "10", "10"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := types.NewAction(types.TxMulti, root, worker, "", &types.ActionList{}); err == nil {
+		t.Fatal("the nested multi-action must be rejected")
+	}
+	tx, err := types.NewMultiAction(root, "active", []*types.Action{transfer, invoke}, 1, time.Now().Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := tx.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx2 := new(types.Transaction)
+	if err := tx2.Deserialize(data); err != nil {
+		t.Fatal(err)
+	}
+	if !tx2.Hash.Equals(&tx.Hash) {
+		t.Fatal("hash mismatch")
+	}
+	list, ok := tx2.Payload.GetObject().(types.ActionList)
+	if !ok || len(list.Actions) != 2 {
+		t.Fatal("actions mismatch")
+	}
+	if list.Actions[1].From != worker || list.Actions[1].Permission != "active" {
+		t.Fatal("action mismatch")
+	}
+	tx2.Show()
+}
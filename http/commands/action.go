@@ -0,0 +1,146 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	innerCommon "github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/common/event"
+	"github.com/ecoball/go-ecoball/core/types"
+	"github.com/ecoball/go-ecoball/http/common"
+)
+
+//an action of multi-action transaction in json, the fields are used by its type
+type actionParam struct {
+	Type        string   `json:"type"`
+	From        string   `json:"from"`
+	Permission  string   `json:"permission"`
+	To          string   `json:"to"`
+	Value       int64    `json:"value"`
+	Code        string   `json:"code"`
+	Description string   `json:"description"`
+	Method      string   `json:"method"`
+	Params      []string `json:"params"`
+}
+
+//send the actions in one transaction, params[0] is the sender and params[1] is the json array of actions
+func SendActions(params []interface{}) *common.Response {
+	if len(params) < 1 {
+		log.Error("invalid arguments")
+		return common.NewResponse(common.INVALID_PARAMS, nil)
+	}
+
+	switch {
+	case len(params) == 2:
+		if errCode := handleSendActions(params); errCode != common.SUCCESS {
+			log.Error(errCode.Info())
+			return common.NewResponse(errCode, nil)
+		}
+
+	default:
+		return common.NewResponse(common.INVALID_PARAMS, nil)
+	}
+
+	return common.NewResponse(common.SUCCESS, "")
+}
+
+func handleSendActions(params []interface{}) common.Errcode {
+	from, ok := params[0].(string)
+	if !ok || from == "" {
+		return common.INVALID_PARAMS
+	}
+	data, ok := params[1].(string)
+	if !ok {
+		return common.INVALID_PARAMS
+	}
+	var list []actionParam
+	if err := json.Unmarshal([]byte(data), &list); err != nil || len(list) == 0 {
+		log.Error("invalid actions:", err)
+		return common.INVALID_PARAMS
+	}
+
+	var actions []*types.Action
+	for _, p := range list {
+		errCode, action := newAction(p)
+		if errCode != common.SUCCESS {
+			return errCode
+		}
+		actions = append(actions, action)
+	}
+
+	nonce, errCode := nextNonce(innerCommon.NameToIndex(from))
+	if errCode != common.SUCCESS {
+		return errCode
+	}
+	transaction, err := types.NewMultiAction(innerCommon.NameToIndex(from), "owner", actions, nonce, time.Now().Unix())
+	if nil != err {
+		return common.INVALID_PARAMS
+	}
+	if errCode := setReference(transaction); errCode != common.SUCCESS {
+		return errCode
+	}
+
+	//send to txpool
+	err = event.Send(event.ActorNil, event.ActorTxPool, transaction)
+	if nil != err {
+		return common.INTERNAL_ERROR
+	}
+
+	return common.SUCCESS
+}
+
+/**
+ *  @brief create the action of transaction, the arguments of invoke are encoded with the abi of contract
+ *  @param p - the action in json, type is transfer, deploy or invoke
+ */
+func newAction(p actionParam) (common.Errcode, *types.Action) {
+	var (
+		t       types.TxType
+		payload types.Payload
+	)
+	switch p.Type {
+	case "transfer":
+		if p.Value <= 0 {
+			return common.INVALID_PARAMS, nil
+		}
+		t, payload = types.TxTransfer, types.NewTransferInfo(big.NewInt(p.Value))
+	case "deploy":
+		code := innerCommon.FromHex(p.Code)
+		if len(code) == 0 {
+			return common.INVALID_PARAMS, nil
+		}
+		t, payload = types.TxDeploy, &types.DeployInfo{TypeVm: types.VmWasm, Describe: []byte(p.Description), Code: code}
+	case "invoke":
+		errCode, args := encodeArguments(p.To, p.Method, p.Params)
+		if errCode != common.SUCCESS {
+			return errCode, nil
+		}
+		t, payload = types.TxInvoke, &types.InvokeInfo{Method: []byte(p.Method), Param: args}
+	default:
+		log.Error("invalid action type:", p.Type)
+		return common.INVALID_PARAMS, nil
+	}
+	action, err := types.NewAction(t, innerCommon.NameToIndex(p.From), innerCommon.NameToIndex(p.To), p.Permission, payload)
+	if err != nil {
+		log.Error(err)
+		return common.INVALID_PARAMS, nil
+	}
+	return common.SUCCESS, action
+}
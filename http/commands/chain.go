@@ -0,0 +1,44 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"github.com/ecoball/go-ecoball/http/common"
+)
+
+//the chain a transaction is bound to, see types.Transaction.SetReference
+type ChainInfo struct {
+	ChainId   string `json:"chainId"`
+	Height    uint64 `json:"height"`
+	HeadHash  string `json:"headHash"`
+	TimeStamp int64  `json:"timeStamp"`
+}
+
+//get the chain ID and the head block of node, the signers of transactions reference them
+func GetChainInfo(params []interface{}) *common.Response {
+	if queryLedger == nil {
+		return common.NewResponse(common.INTERNAL_ERROR, nil)
+	}
+	header := queryLedger.GetCurrentHeader()
+	info := ChainInfo{
+		ChainId:   header.Chain().HexString(),
+		Height:    header.Height,
+		HeadHash:  header.Hash.HexString(),
+		TimeStamp: header.TimeStamp,
+	}
+	return common.NewResponse(common.SUCCESS, info)
+}
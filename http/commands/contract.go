@@ -17,6 +17,7 @@
 package commands
 
 import (
+	"encoding/json"
 	"strings"
 	"time"
 
@@ -26,6 +27,7 @@ import (
 	innerCommon "github.com/ecoball/go-ecoball/common"
 	"github.com/ecoball/go-ecoball/common/elog"
 	"github.com/ecoball/go-ecoball/common/event"
+	"github.com/ecoball/go-ecoball/common/message"
 	"github.com/ecoball/go-ecoball/crypto/secp256k1"
 	"github.com/ecoball/go-ecoball/http/common"
 )
@@ -39,7 +41,7 @@ func SetContract(params []interface{}) *common.Response {
 	}
 
 	switch {
-	case len(params) == 3:
+	case len(params) == 3 || len(params) == 4:
 		if errCode, result := handleSetContract(params); errCode != common.SUCCESS {
 			log.Error(errCode.Info())
 			return common.NewResponse(errCode, nil)
@@ -61,6 +63,7 @@ func handleSetContract(params []interface{}) (common.Errcode, string) {
 		code         []byte
 		contractName string
 		description  string
+		abi          *types.Abi
 		invalid      bool = false
 	)
 
@@ -82,6 +85,19 @@ func handleSetContract(params []interface{}) (common.Errcode, string) {
 		invalid = true
 	}
 
+	//optional abi in json
+	if len(params) == 4 {
+		if v, ok := params[3].(string); ok && v != "" {
+			var err error
+			if abi, err = types.NewAbi([]byte(v)); err != nil {
+				log.Error("invalid abi:", err)
+				invalid = true
+			}
+		} else if !ok {
+			invalid = true
+		}
+	}
+
 	if invalid {
 		return common.INVALID_PARAMS, ""
 	}
@@ -106,10 +122,17 @@ func handleSetContract(params []interface{}) (common.Errcode, string) {
 	//from address
 	//from := account.AddressFromPubKey(common.Account.PublicKey)
 
-	transaction, err := types.NewDeployContract(innerCommon.NameToIndex("root"), innerCommon.NameToIndex(contractName), "owner", types.VmWasm, description, code, 0, time)
+	nonce, errCode := nextNonce(innerCommon.NameToIndex("root"))
+	if errCode != common.SUCCESS {
+		return errCode, ""
+	}
+	transaction, err := types.NewDeployContract(innerCommon.NameToIndex("root"), innerCommon.NameToIndex(contractName), "owner", types.VmWasm, description, code, abi, nonce, time)
 	if nil != err {
 		return common.INVALID_PARAMS, ""
 	}
+	if errCode := setReference(transaction); errCode != common.SUCCESS {
+		return errCode, ""
+	}
 
 	/*err = transaction.SetSignature(&common.Account)
 	if err != nil {
@@ -146,6 +169,58 @@ func InvokeContract(params []interface{}) *common.Response {
 }
 
 func handleInvokeContract(params []interface{}) common.Errcode {
+	contractName, contractMethod, parameters, ok := parseInvokeParams(params)
+	if !ok {
+		return common.INVALID_PARAMS
+	}
+
+	//encode typed arguments with the abi of contract
+	errCode, parameters := encodeArguments(contractName, contractMethod, parameters)
+	if errCode != common.SUCCESS {
+		return errCode
+	}
+
+	//from address
+	//from := account.AddressFromPubKey(common.Account.PublicKey)
+
+	//contract address
+	//address := innerCommon.NewAddress(innerCommon.CopyBytes(innerCommon.FromHex(contractAddress)))
+
+	//time
+	time := time.Now().Unix()
+
+	nonce, errCode := nextNonce(innerCommon.NameToIndex("root"))
+	if errCode != common.SUCCESS {
+		return errCode
+	}
+	transaction, err := types.NewInvokeContract(innerCommon.NameToIndex("root"), innerCommon.NameToIndex(contractName), "owner", contractMethod, parameters, nonce, time)
+	if nil != err {
+		return common.INVALID_PARAMS
+	}
+	if errCode := setReference(transaction); errCode != common.SUCCESS {
+		return errCode
+	}
+
+	/*err = transaction.SetSignature(&common.Account)
+	if err != nil {
+		return common.INVALID_ACCOUNT
+	}*/
+
+	//send to txpool
+	err = event.Send(event.ActorNil, event.ActorTxPool, transaction)
+	if nil != err {
+		return common.INTERNAL_ERROR
+	}
+
+	return common.SUCCESS
+}
+
+/**
+ *  @brief parse the params of invoking contract, params[2] is the arguments of method in a json array
+ *  or separated by space
+ *  @return the contract name, method and arguments, false if the params are invalid
+ */
+func parseInvokeParams(params []interface{}) (string, string, []string, bool) {
 	var (
 		contractName   string
 		contractMethod string
@@ -172,38 +247,51 @@ func handleInvokeContract(params []interface{}) common.Errcode {
 		invalid = true
 	}
 
-	if "" != contractParam {
+	if strings.HasPrefix(contractParam, "[") {
+		if err := json.Unmarshal([]byte(contractParam), &parameters); err != nil {
+			invalid = true
+		}
+	} else if "" != contractParam {
 		parameters = strings.Split(contractParam, " ")
 	}
 
-	if invalid {
-		return common.INVALID_PARAMS
-	}
-
-	//from address
-	//from := account.AddressFromPubKey(common.Account.PublicKey)
-
-	//contract address
-	//address := innerCommon.NewAddress(innerCommon.CopyBytes(innerCommon.FromHex(contractAddress)))
-
-	//time
-	time := time.Now().Unix()
+	return contractName, contractMethod, parameters, !invalid
+}
 
-	transaction, err := types.NewInvokeContract(innerCommon.NameToIndex("root"), innerCommon.NameToIndex(contractName), "owner", contractMethod, parameters, 0, time)
-	if nil != err {
-		return common.INVALID_PARAMS
+/**
+ *  @brief check and encode the arguments of method with the contract ABI, the arguments are
+ *  returned unchanged if the contract has no ABI
+ *  @param contractName - the account of contract
+ *  @param method - the method name
+ *  @param args - the arguments in text form
+ */
+func encodeArguments(contractName, method string, args []string) (common.Errcode, []string) {
+	res, err := event.SendSync(event.ActorLedger, message.GetContract{Index: innerCommon.NameToIndex(contractName)}, 5*time.Second)
+	if err != nil {
+		log.Error("get contract failed:", err)
+		return common.INTERNAL_ERROR, nil
 	}
+	contract, ok := res.(*types.DeployInfo)
+	if !ok {
+		log.Error("get contract failed:", res)
+		return common.INVALID_CONTRACT, nil
+	}
+	return encodeContractArgs(contract, method, args)
+}
 
-	/*err = transaction.SetSignature(&common.Account)
+func encodeContractArgs(contract *types.DeployInfo, method string, args []string) (common.Errcode, []string) {
+	if contract.Abi == nil {
+		return common.SUCCESS, args
+	}
+	abiMethod, err := contract.Abi.Method(method)
 	if err != nil {
-		return common.INVALID_ACCOUNT
-	}*/
-
-	//send to txpool
-	err = event.Send(event.ActorNil, event.ActorTxPool, transaction)
-	if nil != err {
-		return common.INTERNAL_ERROR
+		log.Error(err)
+		return common.INVALID_CONTRACT, nil
 	}
-
-	return common.SUCCESS
+	params, err := abiMethod.EncodeArgs(args)
+	if err != nil {
+		log.Error(err)
+		return common.INVALID_CONTRACT, nil
+	}
+	return common.SUCCESS, params
 }
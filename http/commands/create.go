@@ -4,7 +4,6 @@ import (
 	"time"
 
 	innercommon "github.com/ecoball/go-ecoball/common"
-	"github.com/ecoball/go-ecoball/common/config"
 	"github.com/ecoball/go-ecoball/common/event"
 	"github.com/ecoball/go-ecoball/core/types"
 	"github.com/ecoball/go-ecoball/http/common"
@@ -76,9 +75,18 @@ func handleCreateAccount(params []interface{}) common.Errcode {
 	creatorAccount := innercommon.NameToIndex(creator)
 	timeStamp := time.Now().Unix()
 
+	nonce, errCode := nextNonce(creatorAccount)
+	if errCode != common.SUCCESS {
+		return errCode
+	}
 	invoke, err := types.NewInvokeContract(creatorAccount, creatorAccount, "owner","new_account",
-		[]string{name, innercommon.AddressFromPubKey(innercommon.FromHex(owner)).HexString()}, 0, timeStamp)
-	invoke.SetSignature(&config.Root)
+		[]string{name, innercommon.AddressFromPubKey(innercommon.FromHex(owner)).HexString()}, nonce, timeStamp)
+	if nil != err {
+		return common.INVALID_PARAMS
+	}
+	if errCode := setReference(invoke); errCode != common.SUCCESS {
+		return errCode
+	}
 
 	//send to txpool
 	err = event.Send(event.ActorNil, event.ActorTxPool, invoke)
@@ -0,0 +1,27 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"github.com/ecoball/go-ecoball/http/common"
+	"github.com/ecoball/go-ecoball/smartcontract/nativeservice"
+)
+
+//list the actions of native contracts with their arguments and the permission required
+func ListNativeActions(params []interface{}) *common.Response {
+	return common.NewResponse(common.SUCCESS, nativeservice.Actions())
+}
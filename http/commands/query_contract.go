@@ -0,0 +1,112 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"time"
+
+	innerCommon "github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/ledgerimpl/ledger"
+	"github.com/ecoball/go-ecoball/core/types"
+	"github.com/ecoball/go-ecoball/http/common"
+)
+
+// the ledger read by the queries and the reference of new transactions, which are served without the mailbox
+// of ledger actor
+var queryLedger ledger.Ledger
+
+func SetLedger(l ledger.Ledger) {
+	queryLedger = l
+}
+
+type QueryResult struct {
+	Result interface{}    `json:"result"`
+	Error  string         `json:"error,omitempty"`
+	Events []*types.Event `json:"events"`
+	Cpu    float32        `json:"cpu"`
+	Net    float32        `json:"net"`
+}
+
+// execute a method of contract on the head state without creating a transaction, params are the same as invokeContract
+func QueryContract(params []interface{}) *common.Response {
+	if len(params) != 3 {
+		log.Error("invalid arguments")
+		return common.NewResponse(common.INVALID_PARAMS, nil)
+	}
+	if errCode, result := handleQueryContract(params); errCode != common.SUCCESS {
+		log.Error(errCode.Info())
+		return common.NewResponse(errCode, nil)
+	} else {
+		return common.NewResponse(common.SUCCESS, result)
+	}
+}
+
+func handleQueryContract(params []interface{}) (common.Errcode, *QueryResult) {
+	contractName, contractMethod, parameters, ok := parseInvokeParams(params)
+	if !ok {
+		return common.INVALID_PARAMS, nil
+	}
+	if queryLedger == nil {
+		return common.INTERNAL_ERROR, nil
+	}
+
+	s, err := queryLedger.QueryState()
+	if err != nil {
+		log.Error("copy state failed:", err)
+		return common.INTERNAL_ERROR, nil
+	}
+	contract, err := s.GetContract(innerCommon.NameToIndex(contractName))
+	if err != nil {
+		log.Error("get contract failed:", err)
+		return common.INVALID_CONTRACT, nil
+	}
+	errCode, parameters := encodeContractArgs(contract, contractMethod, parameters)
+	if errCode != common.SUCCESS {
+		return errCode, nil
+	}
+
+	transaction, err := types.NewInvokeContract(innerCommon.NameToIndex("root"), innerCommon.NameToIndex(contractName), "owner", contractMethod, parameters, 0, time.Now().Unix())
+	if nil != err {
+		return common.INVALID_PARAMS, nil
+	}
+	receipt, err := queryLedger.QueryContract(s, transaction)
+	if err != nil {
+		log.Error("query contract failed:", err)
+		return common.INTERNAL_ERROR, nil
+	}
+
+	result := &QueryResult{Error: receipt.Error, Events: receipt.Events, Cpu: receipt.Cpu, Net: receipt.Net}
+	if receipt.Status == types.ReceiptSuccess {
+		result.Result = decodeResult(contract.Abi, contractMethod, receipt.Result)
+	}
+	return common.SUCCESS, result
+}
+
+// decode the result with the return type in ABI, the result is returned in hex if it can't be decoded
+func decodeResult(abi *types.Abi, method string, ret []byte) interface{} {
+	if abi != nil {
+		if abiMethod, err := abi.Method(method); err == nil {
+			if value, err := abiMethod.DecodeResult(ret); err == nil {
+				return value
+			}
+		}
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return innerCommon.ToHex(ret)
+}
@@ -0,0 +1,52 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"time"
+
+	innerCommon "github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/common/event"
+	"github.com/ecoball/go-ecoball/common/message"
+	"github.com/ecoball/go-ecoball/core/types"
+	"github.com/ecoball/go-ecoball/http/common"
+)
+
+//get the receipt of a transaction, params[0] is the hex string of transaction hash
+func GetReceipt(params []interface{}) *common.Response {
+	if len(params) < 1 {
+		log.Error("invalid arguments")
+		return common.NewResponse(common.INVALID_PARAMS, nil)
+	}
+	txHash, ok := params[0].(string)
+	if !ok || txHash == "" {
+		return common.NewResponse(common.INVALID_PARAMS, nil)
+	}
+	hash := innerCommon.HexToHash(txHash)
+
+	res, err := event.SendSync(event.ActorLedger, message.GetReceipt{Key: hash.Bytes()}, 5*time.Second)
+	if err != nil {
+		log.Error("get receipt failed:", err)
+		return common.NewResponse(common.INTERNAL_ERROR, nil)
+	}
+	receipt, ok := res.(*types.Receipt)
+	if !ok {
+		log.Error("get receipt failed:", res)
+		return common.NewResponse(common.INVALID_RECEIPT, nil)
+	}
+	return common.NewResponse(common.SUCCESS, receipt)
+}
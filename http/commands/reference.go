@@ -0,0 +1,65 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"errors"
+	"time"
+
+	innerCommon "github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/common/event"
+	"github.com/ecoball/go-ecoball/common/message"
+	"github.com/ecoball/go-ecoball/core/types"
+	"github.com/ecoball/go-ecoball/http/common"
+)
+
+/**
+ *  @brief the nonce of a new transaction of account, it follows the transactions of account in tx pool
+ *  @param index - the sender of transaction
+ */
+func nextNonce(index innerCommon.AccountName) (uint64, common.Errcode) {
+	res, err := event.SendSync(event.ActorTxPool, message.GetNonce{Index: index}, time.Second*2)
+	if err != nil {
+		log.Error(err)
+		return 0, common.INTERNAL_ERROR
+	}
+	switch v := res.(type) {
+	case uint64:
+		return v, common.SUCCESS
+	case error:
+		log.Error(v)
+		return 0, common.INVALID_ACCOUNT
+	default:
+		log.Error(errors.New("unidentified message"))
+		return 0, common.INTERNAL_ERROR
+	}
+}
+
+/**
+ *  @brief bind the transaction to the head block of ledger before it is signed, the transaction expires
+ *  DefaultTxLifetime later
+ */
+func setReference(tx *types.Transaction) common.Errcode {
+	if queryLedger == nil {
+		return common.INTERNAL_ERROR
+	}
+	if err := tx.SetReference(queryLedger.GetCurrentHeader(), time.Now().Unix()+types.DefaultTxLifetime); err != nil {
+		log.Error(err)
+		return common.INTERNAL_ERROR
+	}
+	return common.SUCCESS
+}
@@ -0,0 +1,47 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"github.com/ecoball/go-ecoball/http/common"
+)
+
+//get the registration and supply of a token, params[0] is the symbol of token
+func GetTokenInfo(params []interface{}) *common.Response {
+	if len(params) < 1 {
+		log.Error("invalid arguments")
+		return common.NewResponse(common.INVALID_PARAMS, nil)
+	}
+	symbol, ok := params[0].(string)
+	if !ok || symbol == "" {
+		return common.NewResponse(common.INVALID_PARAMS, nil)
+	}
+	if queryLedger == nil {
+		return common.NewResponse(common.INTERNAL_ERROR, nil)
+	}
+	s, err := queryLedger.QueryState()
+	if err != nil {
+		log.Error("copy state failed:", err)
+		return common.NewResponse(common.INTERNAL_ERROR, nil)
+	}
+	info, err := s.GetTokenInfo(symbol)
+	if err != nil {
+		log.Error(err)
+		return common.NewResponse(common.INVALID_TOKEN, nil)
+	}
+	return common.NewResponse(common.SUCCESS, info)
+}
@@ -0,0 +1,92 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"time"
+
+	innerCommon "github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/common/event"
+	"github.com/ecoball/go-ecoball/common/message"
+	"github.com/ecoball/go-ecoball/core/types"
+	"github.com/ecoball/go-ecoball/http/common"
+)
+
+//get a packed transaction with its block, position, receipt and merkle proof, params[0] is the hex string of transaction hash
+func GetTransaction(params []interface{}) *common.Response {
+	if len(params) < 1 {
+		log.Error("invalid arguments")
+		return common.NewResponse(common.INVALID_PARAMS, nil)
+	}
+	txHash, ok := params[0].(string)
+	if !ok || txHash == "" {
+		return common.NewResponse(common.INVALID_PARAMS, nil)
+	}
+	hash := innerCommon.HexToHash(txHash)
+
+	res, err := event.SendSync(event.ActorLedger, message.GetTxInclusion{Hash: hash}, 5*time.Second)
+	if err != nil {
+		log.Error("get transaction failed:", err)
+		return common.NewResponse(common.INTERNAL_ERROR, nil)
+	}
+	inclusion, ok := res.(*types.TxInclusion)
+	if !ok {
+		log.Error("get transaction failed:", res)
+		return common.NewResponse(common.INVALID_TRANSACTION, nil)
+	}
+	return common.NewResponse(common.SUCCESS, inclusion)
+}
+
+//send a transaction built and signed by the client to tx pool, params[0] is the hex string of serialized transaction
+func SendRawTransaction(params []interface{}) *common.Response {
+	if len(params) < 1 {
+		log.Error("invalid arguments")
+		return common.NewResponse(common.INVALID_PARAMS, nil)
+	}
+	data, ok := params[0].(string)
+	if !ok || data == "" {
+		return common.NewResponse(common.INVALID_PARAMS, nil)
+	}
+	transaction := new(types.Transaction)
+	if err := transaction.Deserialize(innerCommon.FromHex(data)); err != nil {
+		log.Error("invalid transaction:", err)
+		return common.NewResponse(common.INVALID_TRANSACTION, nil)
+	}
+
+	//send to txpool
+	if err := event.Send(event.ActorNil, event.ActorTxPool, transaction); err != nil {
+		return common.NewResponse(common.INTERNAL_ERROR, nil)
+	}
+	return common.NewResponse(common.SUCCESS, transaction.Hash.HexString())
+}
+
+//get the nonce of the next transaction of account, params[0] is the account name
+func GetNonce(params []interface{}) *common.Response {
+	if len(params) < 1 {
+		log.Error("invalid arguments")
+		return common.NewResponse(common.INVALID_PARAMS, nil)
+	}
+	name, ok := params[0].(string)
+	if !ok || name == "" {
+		return common.NewResponse(common.INVALID_PARAMS, nil)
+	}
+	nonce, errCode := nextNonce(innerCommon.NameToIndex(name))
+	if errCode != common.SUCCESS {
+		return common.NewResponse(errCode, nil)
+	}
+	return common.NewResponse(common.SUCCESS, nonce)
+}
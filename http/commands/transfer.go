@@ -81,10 +81,17 @@ func handleTransfer(params []interface{}) common.Errcode {
 	//time
 	time := time.Now().Unix()
 
-	transaction, err := types.NewTransfer(inner.NameToIndex(from), inner.NameToIndex(to), "owner", value, 0, time)
+	nonce, errCode := nextNonce(inner.NameToIndex(from))
+	if errCode != common.SUCCESS {
+		return errCode
+	}
+	transaction, err := types.NewTransfer(inner.NameToIndex(from), inner.NameToIndex(to), "owner", value, nonce, time)
 	if nil != err {
 		return common.INVALID_PARAMS
 	}
+	if errCode := setReference(transaction); errCode != common.SUCCESS {
+		return errCode
+	}
 
 	/*err = transaction.SetSignature(&common.Account)
 	if err != nil {
@@ -25,6 +25,10 @@ const (
 	GENERATE_KEY_PAIR_FAILED
 	INTERNAL_ERROR
 	SAMEDATA
+	INVALID_CONTRACT
+	INVALID_RECEIPT
+	INVALID_TOKEN
+	INVALID_TRANSACTION
 )
 
 var ErrorCodeInfo = map[Errcode]string{
@@ -34,6 +38,10 @@ var ErrorCodeInfo = map[Errcode]string{
 	GENERATE_KEY_PAIR_FAILED: "generate key pair failed",
 	INTERNAL_ERROR:           "internal error",
 	SAMEDATA:                 "duplicated data",
+	INVALID_CONTRACT:         "invalid contract or method arguments",
+	INVALID_RECEIPT:          "receipt not found",
+	INVALID_TOKEN:            "token not found",
+	INVALID_TRANSACTION:      "transaction not found",
 }
 
 func (this *Errcode) Info() string {
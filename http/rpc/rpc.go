@@ -24,6 +24,7 @@ import (
 
 	"github.com/ecoball/go-ecoball/common/config"
 	"github.com/ecoball/go-ecoball/common/elog"
+	"github.com/ecoball/go-ecoball/core/ledgerimpl/ledger"
 	"github.com/ecoball/go-ecoball/http/commands"
 	"github.com/ecoball/go-ecoball/http/common"
 	nrpc "github.com/ecoball/go-ecoball/net/rpc"
@@ -123,7 +124,8 @@ func Handle(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func StartRPCServer() {
+func StartRPCServer(l ledger.Ledger) {
+	commands.SetLedger(l)
 	http.HandleFunc("/", Handle)
 
 	//add handle
@@ -138,9 +140,36 @@ func StartRPCServer() {
 	//invoke contract
 	httpServer.AddHandleFunc("invokeContract", commands.InvokeContract)
 
+	//send the actions in one transaction, they are executed atomically
+	httpServer.AddHandleFunc("sendActions", commands.SendActions)
+
+	//execute contract on the head state without a transaction
+	httpServer.AddHandleFunc("queryContract", commands.QueryContract)
+
+	//list the actions of native contracts
+	httpServer.AddHandleFunc("listNativeActions", commands.ListNativeActions)
+
+	//get the registration and supply of token
+	httpServer.AddHandleFunc("getTokenInfo", commands.GetTokenInfo)
+
+	//get the chain ID and head block
+	httpServer.AddHandleFunc("getChainInfo", commands.GetChainInfo)
+
 	//create account
 	httpServer.AddHandleFunc("createAccount", commands.CreateAccount)
 
+	//get the receipt of transaction
+	httpServer.AddHandleFunc("getReceipt", commands.GetReceipt)
+
+	//get the transaction with its block, receipt and merkle proof
+	httpServer.AddHandleFunc("getTransaction", commands.GetTransaction)
+
+	//send a transaction signed by the client
+	httpServer.AddHandleFunc("sendRawTransaction", commands.SendRawTransaction)
+
+	//get the nonce of the next transaction of account
+	httpServer.AddHandleFunc("getNonce", commands.GetNonce)
+
 	httpServer.AddHandleFunc("netlistmyid", nrpc.CliServerListMyId)
 	httpServer.AddHandleFunc("netlistmypeer", nrpc.CliServerListMyPeers)
 
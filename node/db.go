@@ -0,0 +1,74 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ecoball/go-ecoball/common/config"
+	"github.com/ecoball/go-ecoball/core/ledgerimpl/transaction"
+	"github.com/ecoball/go-ecoball/core/store"
+	"github.com/urfave/cli"
+)
+
+var (
+	DbCommand = cli.Command{
+		Name:  "db",
+		Usage: "maintain the block chain database, the node must be stopped",
+		Subcommands: []cli.Command{
+			{
+				Name:   "prune",
+				Usage:  "delete the states of the blocks out of retention and compact the database",
+				Action: pruneDB,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "db",
+						Value: store.PathBlock,
+						Usage: "block chain database path of node",
+					},
+					cli.IntFlag{
+						Name:  "keep",
+						Value: config.StateRetention,
+						Usage: "the number of recent block states kept, state_retention of config by default",
+					},
+				},
+			},
+		},
+	}
+)
+
+func pruneDB(c *cli.Context) error {
+	keep := c.Int("keep")
+	if keep <= 0 {
+		fmt.Println("Invalid retention: ", keep)
+		return errors.New("the retention of pruning must be more than 0")
+	}
+	chain, err := transaction.NewTransactionChain(c.String("db"), nil)
+	if err != nil {
+		fmt.Println("open block chain database failed:", err)
+		return err
+	}
+	defer chain.Close()
+	nodes, err := chain.PruneState(uint64(keep))
+	if err != nil {
+		fmt.Println("prune database failed:", err)
+		return err
+	}
+	fmt.Println("pruned", nodes, "state nodes, the states of the last", keep, "blocks are kept")
+	return nil
+}
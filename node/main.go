@@ -52,6 +52,7 @@ func main() {
 	//commands
 	app.Commands = []cli.Command{
 		RunCommand,
+		DbCommand,
 	}
 
 	//flags
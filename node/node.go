@@ -43,6 +43,12 @@ var (
 		Name:   "run",
 		Usage:  "run node",
 		Action: runNode,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "verify-db",
+				Usage: "check the head block and its state can be loaded from the database before starting",
+			},
+		},
 	}
 )
 
@@ -56,6 +62,11 @@ func runNode(c *cli.Context) error {
 	if err != nil {
 		log.Fatal(err)
 	}
+	if c.Bool("verify-db") {
+		if err := l.VerifyDB(); err != nil {
+			log.Fatal("verify database failed:", err)
+		}
+	}
 	log.Info("consensus", config.ConsensusAlgorithm)
 	//start consensus
 	switch config.ConsensusAlgorithm {
@@ -92,7 +103,7 @@ func runNode(c *cli.Context) error {
 	go spectator.Bystander(l)
 
 	//start http server
-	go rpc.StartRPCServer()
+	go rpc.StartRPCServer(l)
 
 	//wait single to exit
 	wait()
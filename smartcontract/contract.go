@@ -19,20 +19,62 @@ package smartcontract
 import (
 	"errors"
 	"fmt"
+	"github.com/ecoball/go-ecoball/common"
 	"github.com/ecoball/go-ecoball/core/state"
 	"github.com/ecoball/go-ecoball/core/types"
 	"github.com/ecoball/go-ecoball/smartcontract/nativeservice"
 	"github.com/ecoball/go-ecoball/smartcontract/wasmservice"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/exec"
 )
 
+// MaxCallDepth is the maximum depth of nested contract calls made by AbaCallContract
+const MaxCallDepth = 8
+
 type ContractService interface {
-	Execute() ([]byte, error)
+	Execute(gasLimit uint64) (ret []byte, gasUsed uint64, err error)
+	Events() []*types.Event
+	//the contract which accepted to pay for the resources of the invoke, 0 if none
+	Payer() common.AccountName
 }
 
+/**
+ *  @brief create the contract service of an invoke transaction, the inline actions queued by the
+ *  contracts are executed after the invoked method returns
+ *  @param s - the state which the contracts run on
+ *  @param tx - the invoke transaction
+ */
 func NewContractService(s *state.State, tx *types.Transaction) (ContractService, error) {
+	return NewTracedContractService(s, tx, nil)
+}
+
+/**
+ *  @brief create the contract service of an invoke transaction, every wasm contract executed by the
+ *  transaction, including the called contracts and inline actions, reports to the tracer
+ *  @param tracer - the tracer of execution, nil if not traced
+ */
+func NewTracedContractService(s *state.State, tx *types.Transaction, tracer exec.Tracer) (ContractService, error) {
 	if s == nil || tx == nil {
 		return nil, errors.New("the contract service's ledger interface or tx is nil")
 	}
+	ctx := &wasmservice.CallContext{Tracer: tracer, Invoked: tx.Addr}
+	ctx.Call = func(tx *types.Transaction, depth int, gasLimit uint64) ([]byte, uint64, error) {
+		service, err := newContractService(s, tx, ctx, depth)
+		if err != nil {
+			return nil, 0, err
+		}
+		return service.Execute(gasLimit)
+	}
+	service, err := newContractService(s, tx, ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &transactionService{service: service, ctx: ctx}, nil
+}
+
+func newContractService(s *state.State, tx *types.Transaction, ctx *wasmservice.CallContext, depth int) (ContractService, error) {
+	if depth > MaxCallDepth {
+		return nil, errors.New(fmt.Sprintf("the contract call exceeds the maximum depth %d", MaxCallDepth))
+	}
 	contract, err := s.GetContract(tx.Addr)
 	if err != nil {
 		return nil, err
@@ -41,17 +83,15 @@ func NewContractService(s *state.State, tx *types.Transaction) (ContractService,
 	if !ok {
 		return nil, errors.New("transaction type error[invoke]")
 	}
-	fmt.Println("method:", string(invoke.Method))
-	fmt.Println("param:", invoke.Param)
 	switch contract.TypeVm {
 	case types.VmNative:
-		service, err := nativeservice.NewNativeService(s, tx.Addr, string(invoke.Method), invoke.Param)
+		service, err := nativeservice.NewNativeService(s, tx, string(invoke.Method), invoke.Param, depth)
 		if err != nil {
 			return nil, err
 		}
 		return service, nil
 	case types.VmWasm:
-		service, err := wasmservice.NewWasmService(s, tx, contract, &invoke)
+		service, err := wasmservice.NewWasmService(s, tx, contract, &invoke, ctx, depth)
 		if err != nil {
 			return nil, err
 		}
@@ -60,3 +100,39 @@ func NewContractService(s *state.State, tx *types.Transaction) (ContractService,
 		return nil, errors.New("unknown virtual machine")
 	}
 }
+
+type transactionService struct {
+	service ContractService
+	ctx     *wasmservice.CallContext
+}
+
+/**
+ *  @brief execute the invoked method and then the queued inline actions in order, each inline action runs
+ *  at the depth below the contract queuing it, the transaction fails and all its changes are reverted by
+ *  the caller if any action fails
+ *  @param gasLimit - the gas budget shared by the method and all inline actions
+ */
+func (t *transactionService) Execute(gasLimit uint64) ([]byte, uint64, error) {
+	ret, gasUsed, err := t.service.Execute(gasLimit)
+	if err != nil {
+		return nil, gasUsed, err
+	}
+	for len(t.ctx.Actions) > 0 {
+		action := t.ctx.Actions[0]
+		t.ctx.Actions = t.ctx.Actions[1:]
+		_, used, err := t.ctx.Call(action.Tx, action.Depth, gasLimit-gasUsed)
+		gasUsed += used
+		if err != nil {
+			return nil, gasUsed, err
+		}
+	}
+	return ret, gasUsed, nil
+}
+
+func (t *transactionService) Events() []*types.Event {
+	return t.ctx.Events
+}
+
+func (t *transactionService) Payer() common.AccountName {
+	return t.ctx.Payer
+}
@@ -16,4 +16,497 @@
 
 package smartcontract_test
 
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
 
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/common/config"
+	"github.com/ecoball/go-ecoball/core/ledgerimpl/transaction"
+	"github.com/ecoball/go-ecoball/core/state"
+	"github.com/ecoball/go-ecoball/core/types"
+	"github.com/ecoball/go-ecoball/smartcontract"
+	"github.com/ecoball/go-ecoball/smartcontract/wasmservice"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/exec"
+)
+
+const (
+	i32 byte = 0x7f
+	i64 byte = 0x7e
+)
+
+//a function of the wasm module built by tests, code is nil for the host functions imported from env
+type wasmFunc struct {
+	name    string
+	params  []byte
+	results []byte
+	code    []byte
+}
+
+//a string put into the linear memory at offset
+type wasmData struct {
+	offset int32
+	value  string
+}
+
+func uleb(v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return buf[:binary.PutUvarint(buf[:], v)]
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func vec(items ...[]byte) []byte {
+	return append(uleb(uint64(len(items))), bytes.Join(items, nil)...)
+}
+
+func section(id byte, items ...[]byte) []byte {
+	payload := vec(items...)
+	return append(append([]byte{id}, uleb(uint64(len(payload)))...), payload...)
+}
+
+func wasmName(s string) []byte {
+	return append(uleb(uint64(len(s))), s...)
+}
+
+//the instruction i32.const
+func constI32(v int32) []byte {
+	return append([]byte{0x41}, sleb(int64(v))...)
+}
+
+//the instruction i64.const
+func constI64(v int64) []byte {
+	return append([]byte{0x42}, sleb(v)...)
+}
+
+//the instruction call
+func call(index int) []byte {
+	return append([]byte{0x10}, uleb(uint64(index))...)
+}
+
+//join the instructions of a function body
+func instrs(list ...[]byte) []byte {
+	return bytes.Join(list, nil)
+}
+
+//the instructions which push the offset and length of the strings in memory
+func stringArgs(data ...wasmData) []byte {
+	var code []byte
+	for _, d := range data {
+		code = append(code, constI32(d.offset)...)
+		code = append(code, constI32(int32(len(d.value)))...)
+	}
+	return code
+}
+
+/**
+ *  @brief build a wasm module with one page of memory, every function has its own type, the imported functions
+ *  come first in the index space and the others are exported by name
+ */
+func buildWasm(imports, exports []wasmFunc, data []wasmData) []byte {
+	var typeList, importList, funcList, exportList, codeList, dataList [][]byte
+	for i, f := range append(imports, exports...) {
+		typeList = append(typeList, append(append([]byte{0x60}, vec(split(f.params)...)...), vec(split(f.results)...)...))
+		if i < len(imports) {
+			importList = append(importList, append(append(append(wasmName("env"), wasmName(f.name)...), 0x00), uleb(uint64(i))...))
+			continue
+		}
+		funcList = append(funcList, uleb(uint64(i)))
+		exportList = append(exportList, append(append(wasmName(f.name), 0x00), uleb(uint64(i))...))
+		body := append(append([]byte{0x00}, f.code...), 0x0b)
+		codeList = append(codeList, append(uleb(uint64(len(body))), body...))
+	}
+	for _, d := range data {
+		entry := append(append([]byte{0x00}, constI32(d.offset)...), 0x0b)
+		dataList = append(dataList, append(entry, wasmName(d.value)...))
+	}
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	module = append(module, section(1, typeList...)...)
+	module = append(module, section(2, importList...)...)
+	module = append(module, section(3, funcList...)...)
+	module = append(module, section(5, []byte{0x00, 0x01})...)
+	module = append(module, section(7, exportList...)...)
+	module = append(module, section(10, codeList...)...)
+	return append(module, section(11, dataList...)...)
+}
+
+func split(types []byte) [][]byte {
+	var list [][]byte
+	for _, t := range types {
+		list = append(list, []byte{t})
+	}
+	return list
+}
+
+/**
+ *  @brief create a chain with the geneses block and deploy the wasm contract into the account
+ */
+func deployWasm(t *testing.T, path string, account common.AccountName, code []byte) *transaction.ChainTx {
+	if err := wasmservice.VerifyModule(code); err != nil {
+		t.Fatal(err)
+	}
+	os.RemoveAll(path)
+	c, err := transaction.NewTransactionChain(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.GenesesBlockInit(); err != nil {
+		t.Fatal(err)
+	}
+	addr := common.NewAddress(common.FromHex("01ca5cdd56d99a0023166b337ffc7fd0d2c42330"))
+	if _, err := c.StateDB.AddAccount(account, addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.StateDB.SetContract(account, types.VmWasm, []byte("test contract"), code, nil); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+/**
+ *  @brief invoke the method of contract by a transaction of root signed by root
+ */
+func invoke(t *testing.T, s *state.State, contract common.AccountName, method string, params []string) ([]byte, error) {
+	tx, err := types.NewInvokeContract(common.NameToIndex("root"), contract, state.Active, method, params, 0, time.Now().Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.SetSignature(&config.Root); err != nil {
+		t.Fatal(err)
+	}
+	service, err := smartcontract.NewContractService(s, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret, _, err := service.Execute(state.TxGasLimit)
+	return ret, err
+}
+
+//the value returned by a method of type i32
+func result(ret []byte) int32 {
+	return int32(binary.LittleEndian.Uint32(ret))
+}
+
+func TestContractAuthorization(t *testing.T) {
+	root := common.NameToIndex("root")
+	thief := common.NameToIndex("thief")
+	contract, method := wasmData{0, "token"}, wasmData{16, "transfer"}
+	args := wasmData{32, `["root","thief","ABA","100"]`}
+	code := buildWasm([]wasmFunc{
+		{name: "AbaSendInline", params: []byte{i32, i32, i32, i32, i32, i32}, results: []byte{i32}},
+		{name: "AbaCallContract", params: []byte{i32, i32, i32, i32, i32, i32, i32, i32}, results: []byte{i32}},
+	}, []wasmFunc{
+		{name: "steal_inline", results: []byte{i32}, code: instrs(stringArgs(contract, method, args), call(0))},
+		{name: "steal_call", results: []byte{i32}, code: instrs(stringArgs(contract, method, args), constI32(512), constI32(64), call(1))},
+	}, []wasmData{contract, method, args})
+	c := deployWasm(t, "/tmp/contract_auth", thief, code)
+	s := c.StateDB
+	balance, err := s.AccountGetBalance(root, state.AbaToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the contract invoked by root can't transfer the token of root without the permission granted
+	if _, err := invoke(t, s, thief, "steal_inline", nil); err == nil {
+		t.Fatal("the inline action must fail without the permission of root")
+	}
+	ret, err := invoke(t, s, thief, "steal_call", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result(ret) != -1 {
+		t.Fatal("the contract call must fail without the permission of root")
+	}
+	if value, _ := s.AccountGetBalance(root, state.AbaToken); value.Cmp(balance) != 0 {
+		t.Fatal("the token of root is moved by the contract:", value)
+	}
+
+	//the contract acts for root once root grants its active permission to the contract
+	perm := state.NewPermission(state.Active, state.Owner, 1, nil, []state.AccFactor{{Actor: thief, Weight: 1, Permission: state.Active}})
+	if err := s.AddPermission(root, perm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := invoke(t, s, thief, "steal_inline", nil); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := s.AccountGetBalance(thief, state.AbaToken); value.Int64() != 100 {
+		t.Fatal("the token is not transferred by the granted contract:", value)
+	}
+}
+
+func TestContractToken(t *testing.T) {
+	root := common.NameToIndex("root")
+	bank := common.NameToIndex("bank")
+	symbol, to := wasmData{0, "BNK"}, wasmData{16, "root"}
+	code := buildWasm([]wasmFunc{
+		{name: "AbaTokenCreate", params: []byte{i32, i32, i64}, results: []byte{i32}},
+		{name: "AbaTransfer", params: []byte{i32, i32, i32, i32, i64}, results: []byte{i32}},
+		{name: "AbaAccountGetBalance", params: []byte{i32, i32, i32, i32}, results: []byte{i64}},
+	}, []wasmFunc{
+		{name: "create", results: []byte{i32}, code: instrs(stringArgs(symbol), constI64(1000), call(0))},
+		{name: "pay", results: []byte{i32}, code: instrs(stringArgs(to, symbol), constI64(300), call(1))},
+		{name: "balance", results: []byte{i64}, code: instrs(stringArgs(to, symbol), call(2))},
+	}, []wasmData{symbol, to})
+	c := deployWasm(t, "/tmp/contract_token", bank, code)
+	s := c.StateDB
+
+	for i, want := range []int32{0, -1} {
+		ret, err := invoke(t, s, bank, "create", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result(ret) != want {
+			t.Fatal("create token", i, "returns", result(ret))
+		}
+	}
+	for i, want := range []int32{0, 0, 0, -1} {
+		hash := s.GetHashRoot()
+		ret, err := invoke(t, s, bank, "pay", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result(ret) != want {
+			t.Fatal("transfer", i, "returns", result(ret))
+		}
+		if current := s.GetHashRoot(); want != 0 && !current.Equals(&hash) {
+			t.Fatal("the failed transfer changes the state")
+		}
+	}
+	if value, _ := s.AccountGetBalance(bank, "BNK"); value.Int64() != 100 {
+		t.Fatal("the balance of contract is wrong:", value)
+	}
+	if value, _ := s.AccountGetBalance(root, "BNK"); value.Int64() != 900 {
+		t.Fatal("the balance of root is wrong:", value)
+	}
+	ret, err := invoke(t, s, bank, "balance", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value := binary.LittleEndian.Uint64(ret); value != 900 {
+		t.Fatal("the balance of root read by contract is wrong:", value)
+	}
+}
+
+func TestContractBlockInfo(t *testing.T) {
+	info := common.NameToIndex("info")
+	code := buildWasm([]wasmFunc{
+		{name: "AbaGetCurrentHeight", results: []byte{i64}},
+		{name: "AbaGetBlockTime", results: []byte{i64}},
+	}, []wasmFunc{
+		{name: "height", results: []byte{i64}, code: call(0)},
+		{name: "time", results: []byte{i64}, code: call(1)},
+	}, nil)
+	c := deployWasm(t, "/tmp/contract_info", info, code)
+	s := c.StateDB
+
+	s.SetBlockInfo(7, 1530000000)
+	for method, want := range map[string]uint64{"height": 7, "time": 1530000000} {
+		ret, err := invoke(t, s, info, method, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value := binary.LittleEndian.Uint64(ret); value != want {
+			t.Fatal("the method", method, "returns", value)
+		}
+	}
+}
+
+func TestContractCall(t *testing.T) {
+	caller := common.NameToIndex("caller")
+	callee := common.NameToIndex("callee")
+	name, value, fail, self, recurse := wasmData{0, "callee"}, wasmData{16, "value"}, wasmData{32, "fail"}, wasmData{48, "caller"}, wasmData{64, "recurse"}
+	symbol := wasmData{80, "CLE"}
+	imports := []wasmFunc{
+		{name: "AbaCallContract", params: []byte{i32, i32, i32, i32, i32, i32, i32, i32}, results: []byte{i32}},
+		{name: "AbaSendInline", params: []byte{i32, i32, i32, i32, i32, i32}, results: []byte{i32}},
+		{name: "AbaTokenCreate", params: []byte{i32, i32, i64}, results: []byte{i32}},
+	}
+	//the result of callee is written at 512, i32.load reads it back
+	load := instrs(constI32(512), []byte{0x28, 0x02, 0x00})
+	callerCode := buildWasm(imports, []wasmFunc{
+		{name: "value", results: []byte{i32}, code: instrs(stringArgs(name, value), constI32(0), constI32(0), constI32(512), constI32(8), call(0), []byte{0x1a}, load)},
+		{name: "fail", results: []byte{i32}, code: instrs(stringArgs(name, fail), constI32(0), constI32(0), constI32(512), constI32(8), call(0))},
+		{name: "recurse", results: []byte{i32}, code: instrs(stringArgs(self, recurse), constI32(0), constI32(0), constI32(512), constI32(8), call(0), []byte{0x1a}, load, constI32(1), []byte{0x6a})},
+		{name: "inline", results: []byte{i32}, code: instrs(stringArgs(name, value), constI32(0), constI32(0), call(1))},
+		{name: "inline_fail", results: []byte{i32}, code: instrs(stringArgs(name, fail), constI32(0), constI32(0), call(1))},
+	}, []wasmData{name, value, fail, self, recurse})
+	calleeCode := buildWasm(imports, []wasmFunc{
+		{name: "value", results: []byte{i32}, code: instrs(stringArgs(symbol), constI64(100), call(2), []byte{0x1a}, constI32(42))},
+		{name: "fail", results: []byte{i32}, code: instrs(stringArgs(symbol), constI64(100), call(2), []byte{0x00})},
+	}, []wasmData{symbol})
+	c := deployWasm(t, "/tmp/contract_call", caller, callerCode)
+	s := c.StateDB
+	addr := common.NewAddress(common.FromHex("01ca5cdd56d99a0023166b337ffc7fd0d2c42331"))
+	if _, err := s.AddAccount(callee, addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetContract(callee, types.VmWasm, []byte("callee contract"), calleeCode, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	//the failed callee is reverted and the caller gets -1
+	ret, err := invoke(t, s, caller, "fail", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result(ret) != -1 || s.TokenExisted("CLE") {
+		t.Fatal("the failed call returns", result(ret))
+	}
+
+	//the inline action runs after the method returns and its failure fails the transaction
+	if _, err := invoke(t, s, caller, "inline", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !s.TokenExisted("CLE") {
+		t.Fatal("the inline action is not executed")
+	}
+	if _, err := invoke(t, s, caller, "inline_fail", nil); err == nil {
+		t.Fatal("the transaction must fail with the failed inline action")
+	}
+
+	ret, err = invoke(t, s, caller, "value", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result(ret) != 42 {
+		t.Fatal("the call returns", result(ret))
+	}
+	//every level adds 1 to the result of the nested call until the call exceeds the maximum depth
+	ret, err = invoke(t, s, caller, "recurse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result(ret) != smartcontract.MaxCallDepth+1 {
+		t.Fatal("the nested calls run", result(ret), "levels")
+	}
+}
+
+func TestContractQuery(t *testing.T) {
+	writer := common.NameToIndex("writer")
+	key, contract, method := wasmData{0, "k"}, wasmData{16, "writer"}, wasmData{32, "write"}
+	code := buildWasm([]wasmFunc{
+		{name: "AbaStoreSet", params: []byte{i32, i32, i32, i32}, results: []byte{i32}},
+		{name: "AbaStoreGet", params: []byte{i32, i32, i32, i32}, results: []byte{i32}},
+		{name: "AbaCallContract", params: []byte{i32, i32, i32, i32, i32, i32, i32, i32}, results: []byte{i32}},
+	}, []wasmFunc{
+		{name: "write", results: []byte{i32}, code: instrs(stringArgs(key, key), call(0))},
+		{name: "read", results: []byte{i32}, code: instrs(stringArgs(key), constI32(64), constI32(8), call(1))},
+		{name: "call_write", results: []byte{i32}, code: instrs(stringArgs(contract, method), constI32(0), constI32(0), constI32(64), constI32(8), call(2))},
+	}, []wasmData{key, contract, method})
+	c := deployWasm(t, "/tmp/contract_query", writer, code)
+
+	s, err := c.QueryState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []string{"write", "call_write"} {
+		tx, err := types.NewInvokeContract(common.NameToIndex("root"), writer, state.Active, method, nil, 0, time.Now().Unix())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.QueryContract(s, tx); err == nil {
+			t.Fatal("the query of method", method, "must fail on writing")
+		}
+	}
+	tx, err := types.NewInvokeContract(common.NameToIndex("root"), writer, state.Active, "read", nil, 0, time.Now().Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := c.QueryContract(s, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result(receipt.Result) != -1 {
+		t.Fatal("the key is written by the query")
+	}
+}
+
+func TestContractEvent(t *testing.T) {
+	root := common.NameToIndex("root")
+	emitter := common.NameToIndex("emitter")
+	topic, small, large := wasmData{0, "paid"}, wasmData{16, "small"}, wasmData{32, string(bytes.Repeat([]byte{'x'}, 200))}
+	emit := instrs(stringArgs(topic, small), call(0))
+	var flood [][]byte
+	for i := 0; i < wasmservice.MaxEvents; i++ {
+		flood = append(flood, emit, []byte{0x1a})
+	}
+	code := buildWasm([]wasmFunc{
+		{name: "AbaEmitEvent", params: []byte{i32, i32, i32, i32}, results: []byte{i32}},
+	}, []wasmFunc{
+		{name: "emit", results: []byte{i32}, code: emit},
+		{name: "emit_large", results: []byte{i32}, code: instrs(stringArgs(topic, large), call(0))},
+		{name: "flood", results: []byte{i32}, code: instrs(append(flood, emit)...)},
+	}, []wasmData{topic, small, large})
+	c := deployWasm(t, "/tmp/contract_event", emitter, code)
+
+	//the event is charged by its length
+	execute := func(method string) ([]byte, uint64) {
+		tx, err := types.NewInvokeContract(root, emitter, state.Active, method, nil, 0, time.Now().Unix())
+		if err != nil {
+			t.Fatal(err)
+		}
+		service, err := smartcontract.NewContractService(c.StateDB, tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret, gas, err := service.Execute(state.TxGasLimit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ret, gas
+	}
+	_, smallGas := execute("emit")
+	_, largeGas := execute("emit_large")
+	if largeGas-smallGas != uint64(len(large.value)-len(small.value))*exec.GasEventByte {
+		t.Fatal("the event must be charged by its length:", smallGas, largeGas)
+	}
+	if ret, _ := execute("flood"); result(ret) != -1 {
+		t.Fatal("a transaction can't emit more than", wasmservice.MaxEvents, "events")
+	}
+
+	//the event is recorded in the receipt and its topic in the bloom of block
+	nonce, err := c.StateDB.GetNonce(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := types.NewInvokeContract(root, emitter, state.Active, "emit", nil, nonce, time.Now().Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.SetReference(c.CurrentHeader, time.Now().Unix()+100); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.SetSignature(&config.Root); err != nil {
+		t.Fatal(err)
+	}
+	block, err := c.NewBlock(nil, []*types.Transaction{tx}, types.ConsensusData{Type: types.ConSolo, Payload: &types.SoloData{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SaveBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := c.GetReceipt(tx.Hash.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipt.Events) != 1 || receipt.Events[0].Contract != emitter || receipt.Events[0].Topic != topic.value || string(receipt.Events[0].Data) != small.value {
+		t.Fatal("the event is not recorded in the receipt:", receipt.JsonString())
+	}
+	if !block.Bloom.Test([]byte(topic.value)) {
+		t.Fatal("the topic of event is not in the bloom of block")
+	}
+}
@@ -1,14 +1,12 @@
 package nativeservice
 
 import (
-	"encoding/json"
 	"errors"
-	"fmt"
 	"github.com/ecoball/go-ecoball/common"
 	"github.com/ecoball/go-ecoball/common/config"
 	"github.com/ecoball/go-ecoball/common/elog"
 	"github.com/ecoball/go-ecoball/core/state"
-	"strconv"
+	"github.com/ecoball/go-ecoball/core/types"
 )
 
 var log = elog.NewLogger("native", config.LogLevel)
@@ -18,85 +16,54 @@ type NativeService struct {
 	owner  common.AccountName
 	method string
 	params []string
+	tx     *types.Transaction
+	depth  int
 }
 
-func NewNativeService(s *state.State, owner common.AccountName, method string, params []string) (*NativeService, error) {
-	ns := &NativeService{state: s, owner: owner, method: method, params: params}
+/**
+ *  @brief create the service of a native action
+ *  @param depth - the call depth, the actions sent by contracts have a depth greater than 0
+ */
+func NewNativeService(s *state.State, tx *types.Transaction, method string, params []string, depth int) (*NativeService, error) {
+	ns := &NativeService{state: s, owner: tx.Addr, method: method, params: params, tx: tx, depth: depth}
 	return ns, nil
 }
 
-func (ns *NativeService) Execute() ([]byte, error) {
-	switch ns.owner {
-	case common.NameToIndex("root"):
-		return ns.RootExecute()
-	case common.NameToIndex("delegate"):
-		return ns.SystemExecute(ns.owner)
-	default:
-		return nil, errors.New("unknown native contract's owner")
+/**
+ *  @brief execute the native action registered by the contract, the arguments are decoded and the permission
+ *  required by the action is checked before the handler is called, by the signatures of transaction or
+ *  by the contract sending the action
+ */
+func (ns *NativeService) Execute(gasLimit uint64) ([]byte, uint64, error) {
+	if gasLimit < state.GasNative {
+		return nil, gasLimit, errors.New("native contract: out of gas")
 	}
-	return nil, nil
-}
-
-func (ns *NativeService) RootExecute() ([]byte, error) {
-	switch ns.method {
-	case "new_account":
-		index := common.NameToIndex(ns.params[0])
-		addr := common.FormHexString(ns.params[1])
-		if _, err := ns.state.AddAccount(index, addr); err != nil {
-			return nil, err
-		}
-	case "set_account":
-		index := common.NameToIndex(ns.params[0])
-		perm := state.Permission{Keys: make(map[string]state.KeyFactor, 1), Accounts: make(map[string]state.AccFactor, 1)}
-		if err := json.Unmarshal([]byte(ns.params[1]), &perm); err != nil {
-			fmt.Println(ns.params[1])
-			return nil, err
-		}
-		if err := ns.state.AddPermission(index, perm); err != nil {
-			return nil, err
-		}
-	default:
-		return nil, errors.New(fmt.Sprintf("unknown method:%s", ns.method))
+	action, err := GetAction(ns.owner, ns.method)
+	if err != nil {
+		return nil, 0, err
+	}
+	args, err := action.Decode(ns.params)
+	if err != nil {
+		return nil, state.GasNative, err
+	}
+	if ns.depth > 0 {
+		err = action.AuthorizeContract(ns.state, ns.owner, args, ns.tx.From)
+	} else {
+		err = action.Authorize(ns.state, ns.owner, args, ns.tx.Signatures)
 	}
-	return nil, nil
+	if err != nil {
+		return nil, state.GasNative, err
+	}
+	log.Debug("native action:", action.Contract, action.Name, args)
+	ret, err := action.Handler(ns.state, args)
+	return ret, state.GasNative, err
 }
 
-func (ns *NativeService) SystemExecute(index common.AccountName) ([]byte, error) {
-	switch ns.method {
-	case "pledge":
-		from := common.NameToIndex(ns.params[0])
-		to := common.NameToIndex(ns.params[1])
-		cpu, err := strconv.ParseUint(ns.params[2], 10, 64)
-		if err != nil {
-			return nil, err
-		}
-		net, err := strconv.ParseUint(ns.params[3], 10, 64)
-		if err != nil {
-			return nil, err
-		}
+func (ns *NativeService) Events() []*types.Event {
+	return nil
+}
 
-		log.Debug(from, to, cpu, net)
-		if err := ns.state.SetResourceLimits(from, to, cpu, net); err != nil {
-			return nil, err
-		}
-		return nil, nil
-	case "cancel_pledge":
-		from := common.NameToIndex(ns.params[0])
-		to := common.NameToIndex(ns.params[1])
-		cpu, err := strconv.ParseUint(ns.params[2], 10, 64)
-		if err != nil {
-			return nil, err
-		}
-		net, err := strconv.ParseUint(ns.params[3], 10, 64)
-		if err != nil {
-			return nil, err
-		}
-		log.Debug(from, to, cpu, net)
-		if err := ns.state.CancelDelegate(from, to, cpu, net); err != nil {
-			return nil, err
-		}
-	default:
-		return nil, errors.New(fmt.Sprintf("unknown method:%s", ns.method))
-	}
-	return nil, nil
+//the native contracts never pay for the resources of transaction
+func (ns *NativeService) Payer() common.AccountName {
+	return 0
 }
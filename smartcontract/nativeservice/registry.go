@@ -0,0 +1,210 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package nativeservice
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/state"
+)
+
+type ArgType string
+
+const (
+	ArgName       ArgType = "name"
+	ArgString     ArgType = "string"
+	ArgAddress    ArgType = "address"
+	ArgUint64     ArgType = "uint64"
+	ArgPermission ArgType = "permission"
+)
+
+type Arg struct {
+	Name string  `json:"name"`
+	Type ArgType `json:"type"`
+}
+
+//the handler of native action, the arguments are decoded in the types declared by the action
+type Handler func(s *state.State, args []interface{}) ([]byte, error)
+
+type Action struct {
+	Contract string `json:"contract"`
+	Name     string `json:"name"`
+	Args     []Arg  `json:"args"`
+	//the signatures of transaction must satisfy the permission of the account given by the argument Authorizer,
+	//the contract account itself if Authorizer is empty
+	Permission string  `json:"permission"`
+	Authorizer string  `json:"authorizer,omitempty"`
+	Handler    Handler `json:"-"`
+}
+
+var (
+	actions = make(map[common.AccountName]map[string]*Action)
+	mutex   sync.RWMutex
+)
+
+/**
+ *  @brief register a native action of system contract, the contract is executed natively when the account
+ *  deploys a contract of types.VmNative
+ *  @param action - the action, the contract and name of action must be unique
+ */
+func Register(action Action) error {
+	if action.Contract == "" || action.Name == "" || action.Handler == nil {
+		return errors.New("invalid native action")
+	}
+	if action.Authorizer != "" {
+		if i := action.argIndex(action.Authorizer); i < 0 || action.Args[i].Type != ArgName {
+			return errors.New(fmt.Sprintf("the authorizer %s of native action %s is not an argument of name", action.Authorizer, action.Name))
+		}
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	contract := common.NameToIndex(action.Contract)
+	if actions[contract] == nil {
+		actions[contract] = make(map[string]*Action)
+	}
+	if _, ok := actions[contract][action.Name]; ok {
+		return errors.New(fmt.Sprintf("the native action %s.%s is registered", action.Contract, action.Name))
+	}
+	actions[contract][action.Name] = &action
+	return nil
+}
+
+/**
+ *  @brief find the native action of contract
+ *  @param contract - the account of contract
+ *  @param name - the action name
+ */
+func GetAction(contract common.AccountName, name string) (*Action, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if _, ok := actions[contract]; !ok {
+		return nil, errors.New(fmt.Sprintf("unknown native contract:%s", common.IndexToName(contract)))
+	}
+	action, ok := actions[contract][name]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("unknown method:%s", name))
+	}
+	return action, nil
+}
+
+/**
+ *  @brief list all registered native actions ordered by contract and name
+ */
+func Actions() []*Action {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	var list []*Action
+	for _, contract := range actions {
+		for _, action := range contract {
+			list = append(list, action)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Contract != list[j].Contract {
+			return list[i].Contract < list[j].Contract
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+func (a *Action) argIndex(name string) int {
+	for i, arg := range a.Args {
+		if arg.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+/**
+ *  @brief decode the arguments in text form with the types declared by the action
+ *  @param params - the arguments of transaction
+ */
+func (a *Action) Decode(params []string) ([]interface{}, error) {
+	if len(params) != len(a.Args) {
+		return nil, errors.New(fmt.Sprintf("the action %s needs %d arguments, but %d are given", a.Name, len(a.Args), len(params)))
+	}
+	args := make([]interface{}, len(params))
+	for i, arg := range a.Args {
+		value, err := decodeArg(arg.Type, params[i])
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid argument %s of action %s: %s", arg.Name, a.Name, err.Error()))
+		}
+		args[i] = value
+	}
+	return args, nil
+}
+
+func decodeArg(t ArgType, param string) (interface{}, error) {
+	switch t {
+	case ArgName:
+		if param == "" {
+			return nil, errors.New("empty name")
+		}
+		return common.NameToIndex(param), nil
+	case ArgString:
+		return param, nil
+	case ArgAddress:
+		if param == "" {
+			return nil, errors.New("empty address")
+		}
+		return common.FormHexString(param), nil
+	case ArgUint64:
+		return strconv.ParseUint(param, 10, 64)
+	case ArgPermission:
+		perm := state.Permission{Keys: make(map[string]state.KeyFactor, 1), Accounts: make(map[string]state.AccFactor, 1)}
+		if err := json.Unmarshal([]byte(param), &perm); err != nil {
+			return nil, err
+		}
+		return perm, nil
+	default:
+		return nil, errors.New(fmt.Sprintf("unknown argument type %s", t))
+	}
+}
+
+/**
+ *  @brief check the signatures satisfy the permission required by the action
+ *  @param contract - the account of contract
+ *  @param args - the decoded arguments
+ */
+func (a *Action) Authorize(s *state.State, contract common.AccountName, args []interface{}, signatures []common.Signature) error {
+	return s.CheckPermission(a.authorizer(contract, args), a.Permission, signatures)
+}
+
+/**
+ *  @brief check the contract sending the action is granted the permission required by the action
+ *  @param contract - the account of contract
+ *  @param args - the decoded arguments
+ *  @param sender - the contract sending the action
+ */
+func (a *Action) AuthorizeContract(s *state.State, contract common.AccountName, args []interface{}, sender common.AccountName) error {
+	return s.CheckContractPermission(a.authorizer(contract, args), a.Permission, sender)
+}
+
+func (a *Action) authorizer(contract common.AccountName, args []interface{}) common.AccountName {
+	if a.Authorizer != "" {
+		return args[a.argIndex(a.Authorizer)].(common.AccountName)
+	}
+	return contract
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package nativeservice_test
+
+import (
+	"testing"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/state"
+	"github.com/ecoball/go-ecoball/smartcontract/nativeservice"
+)
+
+func TestRegistry(t *testing.T) {
+	names := make(map[string]bool)
+	for _, action := range nativeservice.Actions() {
+		names[action.Contract+"."+action.Name] = true
+	}
+	for _, name := range []string{"root.new_account", "root.set_account", "delegate.pledge", "delegate.cancel_pledge"} {
+		if !names[name] {
+			t.Fatal("the system action is not registered:", name)
+		}
+	}
+
+	action, err := nativeservice.GetAction(common.NameToIndex("delegate"), "pledge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := action.Decode([]string{"root", "worker1", "10", "20"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args[0].(common.AccountName) != common.NameToIndex("root") || args[3].(uint64) != 20 {
+		t.Fatal("decode arguments failed:", args)
+	}
+	if _, err := action.Decode([]string{"root", "worker1", "ten", "20"}); err == nil {
+		t.Fatal("decode an invalid uint64")
+	}
+	if _, err := action.Decode([]string{"root", "worker1"}); err == nil {
+		t.Fatal("decode with missing arguments")
+	}
+	if _, err := nativeservice.GetAction(common.NameToIndex("delegate"), "unknown"); err == nil {
+		t.Fatal("get an unknown action")
+	}
+
+	handler := func(s *state.State, args []interface{}) ([]byte, error) { return nil, nil }
+	if err := nativeservice.Register(nativeservice.Action{Contract: "delegate", Name: "pledge", Handler: handler}); err == nil {
+		t.Fatal("register a duplicated action")
+	}
+	bad := nativeservice.Action{Contract: "test", Name: "bad", Args: []nativeservice.Arg{{Name: "value", Type: nativeservice.ArgUint64}}, Authorizer: "value", Handler: handler}
+	if err := nativeservice.Register(bad); err == nil {
+		t.Fatal("register an action authorized by an argument which is not a name")
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package nativeservice
+
+import (
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/state"
+)
+
+//the actions of system contracts deployed on root and delegate
+func init() {
+	for _, action := range []Action{
+		{
+			Contract:   "root",
+			Name:       "new_account",
+			Args:       []Arg{{"name", ArgName}, {"address", ArgAddress}},
+			Permission: state.Active,
+			Handler:    newAccount,
+		},
+		{
+			Contract:   "root",
+			Name:       "set_account",
+			Args:       []Arg{{"name", ArgName}, {"permission", ArgPermission}},
+			Permission: state.Owner,
+			Authorizer: "name",
+			Handler:    setAccount,
+		},
+		{
+			Contract:   "delegate",
+			Name:       "pledge",
+			Args:       []Arg{{"from", ArgName}, {"to", ArgName}, {"cpu", ArgUint64}, {"net", ArgUint64}},
+			Permission: state.Active,
+			Authorizer: "from",
+			Handler:    pledge,
+		},
+		{
+			Contract:   "delegate",
+			Name:       "cancel_pledge",
+			Args:       []Arg{{"from", ArgName}, {"to", ArgName}, {"cpu", ArgUint64}, {"net", ArgUint64}},
+			Permission: state.Active,
+			Authorizer: "from",
+			Handler:    cancelPledge,
+		},
+	} {
+		if err := Register(action); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func newAccount(s *state.State, args []interface{}) ([]byte, error) {
+	if _, err := s.AddAccount(args[0].(common.AccountName), args[1].(common.Address)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func setAccount(s *state.State, args []interface{}) ([]byte, error) {
+	if err := s.AddPermission(args[0].(common.AccountName), args[1].(state.Permission)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func pledge(s *state.State, args []interface{}) ([]byte, error) {
+	if err := s.SetResourceLimits(args[0].(common.AccountName), args[1].(common.AccountName), args[2].(uint64), args[3].(uint64)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func cancelPledge(s *state.State, args []interface{}) ([]byte, error) {
+	if err := s.CancelDelegate(args[0].(common.AccountName), args[1].(common.AccountName), args[2].(uint64), args[3].(uint64)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
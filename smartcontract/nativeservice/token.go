@@ -0,0 +1,123 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package nativeservice
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/state"
+)
+
+//the actions of the token contract, the amount of token is in the smallest unit
+func init() {
+	for _, action := range []Action{
+		{
+			Contract:   "token",
+			Name:       "create",
+			Args:       []Arg{{"issuer", ArgName}, {"symbol", ArgString}, {"precision", ArgUint64}, {"max_supply", ArgUint64}},
+			Permission: state.Active,
+			Authorizer: "issuer",
+			Handler:    createToken,
+		},
+		{
+			Contract:   "token",
+			Name:       "issue",
+			Args:       []Arg{{"issuer", ArgName}, {"symbol", ArgString}, {"to", ArgName}, {"amount", ArgUint64}},
+			Permission: state.Active,
+			Authorizer: "issuer",
+			Handler:    issueToken,
+		},
+		{
+			Contract:   "token",
+			Name:       "transfer",
+			Args:       []Arg{{"from", ArgName}, {"to", ArgName}, {"symbol", ArgString}, {"amount", ArgUint64}},
+			Permission: state.Active,
+			Authorizer: "from",
+			Handler:    transferToken,
+		},
+		{
+			Contract:   "token",
+			Name:       "retire",
+			Args:       []Arg{{"owner", ArgName}, {"symbol", ArgString}, {"amount", ArgUint64}},
+			Permission: state.Active,
+			Authorizer: "owner",
+			Handler:    retireToken,
+		},
+		{
+			Contract:   "token",
+			Name:       "close",
+			Args:       []Arg{{"owner", ArgName}, {"symbol", ArgString}},
+			Permission: state.Active,
+			Authorizer: "owner",
+			Handler:    closeBalance,
+		},
+	} {
+		if err := Register(action); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func createToken(s *state.State, args []interface{}) ([]byte, error) {
+	precision := args[2].(uint64)
+	if precision > 18 {
+		return nil, errors.New(fmt.Sprintf("the precision %d of token exceeds 18", precision))
+	}
+	if err := s.CreateToken(args[1].(string), args[0].(common.AccountName), uint32(precision), new(big.Int).SetUint64(args[3].(uint64))); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func issueToken(s *state.State, args []interface{}) ([]byte, error) {
+	issuer, symbol := args[0].(common.AccountName), args[1].(string)
+	info, err := s.GetTokenInfo(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if info.Issuer != issuer {
+		return nil, errors.New(fmt.Sprintf("the issuer of token %s is %s", symbol, common.IndexToName(info.Issuer)))
+	}
+	if err := s.IssueToken(symbol, args[2].(common.AccountName), new(big.Int).SetUint64(args[3].(uint64))); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func transferToken(s *state.State, args []interface{}) ([]byte, error) {
+	if err := s.TransferToken(args[2].(string), args[0].(common.AccountName), args[1].(common.AccountName), new(big.Int).SetUint64(args[3].(uint64))); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func retireToken(s *state.State, args []interface{}) ([]byte, error) {
+	if err := s.RetireToken(args[1].(string), args[0].(common.AccountName), new(big.Int).SetUint64(args[2].(uint64))); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func closeBalance(s *state.State, args []interface{}) ([]byte, error) {
+	if err := s.CloseBalance(args[1].(string), args[0].(common.AccountName)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
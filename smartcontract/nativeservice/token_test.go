@@ -0,0 +1,124 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package nativeservice_test
+
+import (
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ecoball/go-ecoball/account"
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/common/config"
+	"github.com/ecoball/go-ecoball/core/state"
+	"github.com/ecoball/go-ecoball/core/types"
+	"github.com/ecoball/go-ecoball/smartcontract/nativeservice"
+)
+
+func TestToken(t *testing.T) {
+	os.RemoveAll("/tmp/native_token")
+	s, err := state.NewState("/tmp/native_token", common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, worker := common.NameToIndex("root"), common.NameToIndex("worker")
+	if _, err := s.AddAccount(root, common.AddressFromPubKey(config.Root.PublicKey)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddAccount(worker, common.AddressFromPubKey(config.Worker1.PublicKey)); err != nil {
+		t.Fatal(err)
+	}
+	//execute the action of token contract with a transaction signed by signer
+	execute := func(signer *account.Account, method string, params ...string) error {
+		tx, err := types.NewInvokeContract(root, common.NameToIndex("token"), state.Active, method, params, 0, time.Now().Unix())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.SetSignature(signer); err != nil {
+			t.Fatal(err)
+		}
+		ns, err := nativeservice.NewNativeService(s, tx, method, params, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = ns.Execute(state.TxGasLimit)
+		return err
+	}
+	balance := func(index common.AccountName) *big.Int {
+		value, err := s.AccountGetBalance(index, "BNK")
+		if err != nil {
+			return new(big.Int)
+		}
+		return value
+	}
+	supply := func() *big.Int {
+		info, err := s.GetTokenInfo("BNK")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Supply
+	}
+
+	if err := execute(&config.Worker1, "create", "root", "BNK", "2", "1000"); err == nil {
+		t.Fatal("the token must be created with the permission of issuer")
+	}
+	if err := execute(&config.Root, "create", "root", "BNK", "19", "1000"); err == nil {
+		t.Fatal("the precision can't exceed 18")
+	}
+	if err := execute(&config.Root, "create", "root", "BNK", "2", "1000"); err != nil {
+		t.Fatal(err)
+	}
+	if err := execute(&config.Root, "create", "root", "BNK", "2", "1000"); err == nil {
+		t.Fatal("the token can't be created twice")
+	}
+
+	if err := execute(&config.Worker1, "issue", "root", "BNK", "worker", "100"); err == nil {
+		t.Fatal("the token must be issued with the permission of issuer")
+	}
+	if err := execute(&config.Worker1, "issue", "worker", "BNK", "worker", "100"); err == nil {
+		t.Fatal("the token can only be issued by its issuer")
+	}
+	if err := execute(&config.Root, "issue", "root", "BNK", "worker", "1001"); err == nil {
+		t.Fatal("the supply can't exceed the maximum supply")
+	}
+	if err := execute(&config.Root, "issue", "root", "BNK", "nobody", "100"); err == nil {
+		t.Fatal("the token can't be issued to an account which doesn't exist")
+	}
+	if supply().Sign() != 0 {
+		t.Fatal("the supply is changed by a failed issue:", supply())
+	}
+	if err := execute(&config.Root, "issue", "root", "BNK", "worker", "600"); err != nil {
+		t.Fatal(err)
+	}
+	if supply().Cmp(big.NewInt(600)) != 0 || balance(worker).Cmp(big.NewInt(600)) != 0 {
+		t.Fatal("issue token failed:", supply(), balance(worker))
+	}
+
+	if err := execute(&config.Root, "transfer", "worker", "root", "BNK", "100"); err == nil {
+		t.Fatal("the token must be transferred with the permission of sender")
+	}
+	if err := execute(&config.Worker1, "transfer", "worker", "root", "BNK", "601"); err == nil {
+		t.Fatal("the sender can't transfer more than its balance")
+	}
+	if err := execute(&config.Worker1, "transfer", "worker", "root", "BNK", "100"); err != nil {
+		t.Fatal(err)
+	}
+	if balance(worker).Cmp(big.NewInt(500)) != 0 || balance(root).Cmp(big.NewInt(100)) != 0 || supply().Cmp(big.NewInt(600)) != 0 {
+		t.Fatal("transfer token failed:", balance(worker), balance(root), supply())
+	}
+}
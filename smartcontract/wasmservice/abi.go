@@ -0,0 +1,101 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasmservice
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ecoball/go-ecoball/core/types"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/wasm"
+)
+
+/**
+ *  @brief the wasm value types of an ABI type, int32 and int64 are passed by value, the others as an (offset, length) pair
+ *  @param t - the ABI type
+ */
+func abiValueTypes(t string) []wasm.ValueType {
+	switch t {
+	case types.AbiInt32:
+		return []wasm.ValueType{wasm.ValueTypeI32}
+	case types.AbiInt64:
+		return []wasm.ValueType{wasm.ValueTypeI64}
+	default:
+		return []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32}
+	}
+}
+
+func sameValueTypes(a, b []wasm.ValueType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+ *  @brief check every method of ABI is exported by the module with the matching signature
+ *  @param code - the wasm module
+ *  @param abi - the ABI of contract
+ */
+func VerifyAbi(code []byte, abi *types.Abi) error {
+	if err := abi.Check(); err != nil {
+		return err
+	}
+	m, err := wasm.ReadModule(bytes.NewReader(code), nil)
+	if err != nil {
+		return err
+	}
+	if m.Export == nil || m.Function == nil || m.Types == nil {
+		return errors.New("module has no exported function")
+	}
+	var imports int
+	if m.Import != nil {
+		for _, v := range m.Import.Entries {
+			if v.Kind == wasm.ExternalFunction {
+				imports++
+			}
+		}
+	}
+	for _, method := range abi.Methods {
+		entry, ok := m.Export.Entries[method.Name]
+		if !ok || entry.Kind != wasm.ExternalFunction {
+			return errors.New(fmt.Sprintf("abi method %s is not exported by the module", method.Name))
+		}
+		index := int(entry.Index) - imports
+		if index < 0 || index >= len(m.Function.Types) || int(m.Function.Types[index]) >= len(m.Types.Entries) {
+			return errors.New(fmt.Sprintf("abi method %s is not a function of the module", method.Name))
+		}
+		sig := m.Types.Entries[m.Function.Types[index]]
+		var params, returns []wasm.ValueType
+		for _, p := range method.Params {
+			params = append(params, abiValueTypes(p.Type)...)
+		}
+		for _, r := range method.Returns {
+			returns = append(returns, abiValueTypes(r)...)
+		}
+		if !sameValueTypes(params, sig.ParamTypes) || !sameValueTypes(returns, sig.ReturnTypes) {
+			return errors.New(fmt.Sprintf("abi method %s does not match the signature %v -> %v", method.Name, sig.ParamTypes, sig.ReturnTypes))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasmservice
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/common/config"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/exec"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/wasm"
+	"github.com/hashicorp/golang-lru"
+)
+
+//the compiled modules of contracts keyed by the hash of code, the compiled module is shared by all invokes of the contract
+var moduleCache *lru.Cache
+var moduleMutex sync.Mutex
+
+/**
+ *  @brief get the compiled module of the contract code from the cache, the module is read and compiled on the
+ *  first use, the host functions of module must be resolved by the service executing it
+ *  @param code - the wasm module
+ */
+func loadModule(code []byte) (*exec.CompiledModule, error) {
+	moduleMutex.Lock()
+	defer moduleMutex.Unlock()
+	if moduleCache == nil {
+		size := config.WasmModuleCache
+		if size <= 0 {
+			size = 1
+		}
+		cache, err := lru.New(size)
+		if err != nil {
+			return nil, err
+		}
+		moduleCache = cache
+	}
+	hash := common.SingleHash(code)
+	if v, ok := moduleCache.Get(hash); ok {
+		return v.(*exec.CompiledModule), nil
+	}
+
+	//the imports are resolved with the host functions of a service without state, so the cached module
+	//keeps no service alive
+	m, err := wasm.ReadModuleWithHosts(bytes.NewReader(code), importer, staticHosts)
+	if err != nil {
+		return nil, err
+	}
+	if m.Export == nil {
+		return nil, errors.New("module has no export section")
+	}
+	if m.Start != nil {
+		return nil, errors.New("module start function is not supported")
+	}
+	compiled, err := exec.CompileModule(m)
+	if err != nil {
+		return nil, err
+	}
+	moduleCache.Add(hash, compiled)
+	return compiled, nil
+}
+
+/**
+ *  @brief remove all compiled modules from the cache
+ */
+func PurgeModuleCache() {
+	moduleMutex.Lock()
+	defer moduleMutex.Unlock()
+	if moduleCache != nil {
+		moduleCache.Purge()
+	}
+}
@@ -0,0 +1,38 @@
+package wasmservice_test
+
+import (
+	"testing"
+
+	"github.com/ecoball/go-ecoball/core/state"
+	"github.com/ecoball/go-ecoball/smartcontract/wasmservice"
+)
+
+func benchmarkExecute(b *testing.B, cached bool) {
+	code, err := wasmservice.ReadWasm("../../vm/wasmvm/exec/testdata/call.wasm")
+	if err != nil {
+		b.Fatal(err)
+	}
+	wasmservice.PurgeModuleCache()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !cached {
+			wasmservice.PurgeModuleCache()
+		}
+		ws := &wasmservice.WasmService{
+			Code:   code,
+			Method: "fac10",
+		}
+		ws.RegisterApi()
+		if _, _, err := ws.Execute(state.TxGasLimit); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkExecuteUncached(b *testing.B) {
+	benchmarkExecute(b, false)
+}
+
+func BenchmarkExecuteCached(b *testing.B) {
+	benchmarkExecute(b, true)
+}
@@ -0,0 +1,216 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasmservice
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/state"
+	"github.com/ecoball/go-ecoball/core/types"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/exec"
+)
+
+/**
+ *  @brief the context shared by all contracts executed in one transaction
+ *  Call - run the contract method described by tx at the given call depth
+ *  Actions - the inline actions queued by contracts, they are executed after the current call finishes
+ *  Events - the events emitted by contracts, they are recorded in the receipt of transaction
+ *  Tracer - the tracer of every wasm vm created for the transaction, nil if not traced
+ *  Invoked - the contract invoked by the transaction, only it can accept to pay for the resources
+ *  Payer - the contract which accepted to pay for the resources by AbaAcceptCharge, 0 if none
+ */
+type CallContext struct {
+	Call    func(tx *types.Transaction, depth int, gasLimit uint64) ([]byte, uint64, error)
+	Actions []Action
+	Events  []*types.Event
+	Tracer  exec.Tracer
+	Invoked common.AccountName
+	Payer   common.AccountName
+}
+
+//the maximum events recorded by a transaction, it is part of consensus
+const MaxEvents = 64
+
+//an inline action queued by a contract, it is executed at the depth below the contract
+type Action struct {
+	Tx    *types.Transaction
+	Depth int
+}
+
+/**
+ *  @brief build the transaction of a contract call made by this contract, the action is sent by the
+ *  contract account without the signatures of the original transaction, so the callee only acts
+ *  with the permissions granted to this contract
+ */
+func (ws *WasmService) newAction(account, accountLen, method, methodLen, args, argsLen int32) (*types.Transaction, error) {
+	if ws.ctx == nil || ws.tx == nil {
+		return nil, errors.New("contract call is not supported in this context")
+	}
+	name, err := ws.readString(account, accountLen)
+	if err != nil {
+		return nil, err
+	}
+	methodName, err := ws.readString(method, methodLen)
+	if err != nil {
+		return nil, err
+	}
+	var params []string
+	if argsLen > 0 {
+		data, err := ws.readBytes(args, argsLen)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &params); err != nil {
+			return nil, err
+		}
+	}
+	tx := *ws.tx
+	tx.From = ws.tx.Addr
+	tx.Permission = state.Active
+	tx.Addr = common.NameToIndex(name)
+	tx.Payload = &types.InvokeInfo{Method: []byte(methodName), Param: params}
+	tx.Signatures = nil
+	tx.Payer = 0
+	tx.PayerPermission = ""
+	return &tx, nil
+}
+
+/**
+ *  @brief call a method of another contract and wait for its result, the changes of the callee
+ *  are reverted if it fails, the gas used by the callee is charged to the caller, the caller traps
+ *  too if the callee writes during a query
+ *  @param args - the parameters of method encoded as a json array of strings, argsLen is 0 for no parameter
+ *  @param ret - the buffer receiving the result of callee
+ *  @return the length of result, -1 if the call failed
+ */
+func (ws *WasmService) AbaCallContract(account, accountLen, method, methodLen, args, argsLen, ret, retLen int32) int32 {
+	tx, err := ws.newAction(account, accountLen, method, methodLen, args, argsLen)
+	if err != nil {
+		log.Error("AbaCallContract error:", err)
+		return -1
+	}
+	snapshot, err := ws.state.Snapshot()
+	if err != nil {
+		log.Error("AbaCallContract error:", err)
+		return -1
+	}
+	events := len(ws.ctx.Events)
+	result, gasUsed, err := ws.ctx.Call(tx, ws.depth+1, ws.vm.GasLimit()-ws.vm.GasUsed())
+	if err != nil {
+		log.Error("AbaCallContract error:", err)
+		ws.state.RevertToSnapshot(snapshot)
+		ws.ctx.Events = ws.ctx.Events[:events]
+		ws.vm.UseGas(gasUsed)
+		if err == state.ErrReadOnly {
+			panic(err)
+		}
+		return -1
+	}
+	ws.vm.UseGas(gasUsed)
+	if err := ws.writeValue(ret, retLen, result); err != nil {
+		log.Error("AbaCallContract error:", err)
+		return -1
+	}
+	return int32(len(result))
+}
+
+/**
+ *  @brief queue a contract call which is executed after the current call finishes,
+ *  the whole transaction fails if the inline action fails
+ *  @param args - the parameters of method encoded as a json array of strings, argsLen is 0 for no parameter
+ */
+func (ws *WasmService) AbaSendInline(account, accountLen, method, methodLen, args, argsLen int32) int32 {
+	tx, err := ws.newAction(account, accountLen, method, methodLen, args, argsLen)
+	if err != nil {
+		log.Error("AbaSendInline error:", err)
+		return -1
+	}
+	ws.ctx.Actions = append(ws.ctx.Actions, Action{Tx: tx, Depth: ws.depth + 1})
+	return 0
+}
+
+/**
+ *  @brief record an event of the contract in the receipt of transaction, the topic is added
+ *  into the bloom of block header, every byte of topic and data is charged exec.GasEventByte and
+ *  a transaction records MaxEvents at most
+ *  @param topic - the topic of event
+ *  @param data - the data of event
+ */
+func (ws *WasmService) AbaEmitEvent(topic, topicLen, data, dataLen int32) int32 {
+	if ws.ctx == nil || ws.tx == nil {
+		log.Error("AbaEmitEvent error: event is not supported in this context")
+		return -1
+	}
+	if len(ws.ctx.Events) >= MaxEvents {
+		log.Error("AbaEmitEvent error: the transaction has recorded", MaxEvents, "events")
+		return -1
+	}
+	topicStr, err := ws.readString(topic, topicLen)
+	if err != nil {
+		log.Error("AbaEmitEvent error:", err)
+		return -1
+	}
+	value, err := ws.readBytes(data, dataLen)
+	if err != nil {
+		log.Error("AbaEmitEvent error:", err)
+		return -1
+	}
+	ws.vm.UseGas(uint64(len(topicStr)+len(value)) * exec.GasEventByte)
+	ws.ctx.Events = append(ws.ctx.Events, &types.Event{Contract: ws.tx.Addr, Topic: topicStr, Data: value})
+	return 0
+}
+
+/**
+ *  @brief accept to pay for the cpu and net of the invoke instead of the sender, only the contract invoked by
+ *  the transaction or action can accept, the called contracts and inline actions can't, the payer of
+ *  transaction pays if the invoke fails
+ *  @return 0 if accepted, -1 if not allowed
+ */
+func (ws *WasmService) AbaAcceptCharge() int32 {
+	if ws.ctx == nil || ws.tx == nil {
+		log.Error("AbaAcceptCharge error: charge is not supported in this context")
+		return -1
+	}
+	if ws.depth != 0 || ws.tx.Addr != ws.ctx.Invoked {
+		log.Error("AbaAcceptCharge error: only the invoked contract can pay for the resources")
+		return -1
+	}
+	ws.ctx.Payer = ws.tx.Addr
+	return 0
+}
+
+/**
+ *  @brief the contract which accepted to pay for the resources of the invoke, 0 if none
+ */
+func (ws *WasmService) Payer() common.AccountName {
+	if ws.ctx == nil {
+		return 0
+	}
+	return ws.ctx.Payer
+}
+
+/**
+ *  @brief the events emitted by all contracts of the transaction
+ */
+func (ws *WasmService) Events() []*types.Event {
+	if ws.ctx == nil {
+		return nil
+	}
+	return ws.ctx.Events
+}
@@ -0,0 +1,186 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasmservice
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ecoball/go-ecoball/core/types"
+)
+
+// wasmPageSize is the size of a page of linear memory, see exec.VM.GrowMemory
+const wasmPageSize = 65536
+
+var ErrMemoryAccess = errors.New("wasm: out of bounds memory access")
+
+/**
+ *  @brief check that the range [offset, offset+length) lies in the linear memory of the contract
+ *  @param offset - the start address in linear memory
+ *  @param length - the number of bytes
+ */
+func (ws *WasmService) checkBounds(offset, length int32) error {
+	if ws.vm == nil || offset < 0 || length < 0 {
+		return ErrMemoryAccess
+	}
+	if int64(offset)+int64(length) > int64(len(ws.vm.Memory())) {
+		return ErrMemoryAccess
+	}
+	return nil
+}
+
+/**
+ *  @brief copy length bytes at offset out of the linear memory of the contract
+ *  @param offset - the start address in linear memory
+ *  @param length - the number of bytes
+ */
+func (ws *WasmService) readBytes(offset, length int32) ([]byte, error) {
+	if err := ws.checkBounds(offset, length); err != nil {
+		return nil, err
+	}
+	data := make([]byte, length)
+	copy(data, ws.vm.Memory()[offset:offset+length])
+	return data, nil
+}
+
+func (ws *WasmService) readString(offset, length int32) (string, error) {
+	data, err := ws.readBytes(offset, length)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+/**
+ *  @brief copy data into the linear memory of the contract at offset
+ *  @param offset - the start address in linear memory
+ *  @param data - the bytes to write
+ */
+func (ws *WasmService) writeBytes(offset int32, data []byte) error {
+	if int64(len(data)) > int64(^uint32(0)>>1) {
+		return ErrMemoryAccess
+	}
+	if err := ws.checkBounds(offset, int32(len(data))); err != nil {
+		return err
+	}
+	copy(ws.vm.Memory()[offset:], data)
+	ws.vm.TraceMemoryWrite(uint32(offset), data)
+	return nil
+}
+
+/**
+ *  @brief copy data into a buffer of the contract, the data is truncated to the buffer size
+ *  @param offset - the address of buffer in linear memory
+ *  @param length - the size of buffer
+ */
+func (ws *WasmService) writeValue(offset, length int32, data []byte) error {
+	if err := ws.checkBounds(offset, length); err != nil {
+		return err
+	}
+	if int64(len(data)) > int64(length) {
+		data = data[:length]
+	}
+	return ws.writeBytes(offset, data)
+}
+
+func (ws *WasmService) readUint32(offset int32) (uint32, error) {
+	data, err := ws.readBytes(offset, 4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(data), nil
+}
+
+func (ws *WasmService) writeUint32(offset int32, value uint32) error {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, value)
+	return ws.writeBytes(offset, data)
+}
+
+func (ws *WasmService) readUint64(offset int32) (uint64, error) {
+	data, err := ws.readBytes(offset, 8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(data), nil
+}
+
+func (ws *WasmService) writeUint64(offset int32, value uint64) error {
+	data := make([]byte, 8)
+	binary.LittleEndian.PutUint64(data, value)
+	return ws.writeBytes(offset, data)
+}
+
+/**
+ *  @brief encode the invoke parameters into new pages at the end of the linear memory,
+ *  int32 and int64 parameters of the ABI method are passed by value, every other parameter
+ *  is passed as an (offset, length) pair and is followed by a NUL byte, the memory can't grow beyond
+ *  the maximum declared by the module or MaxMemoryPages
+ *  @param params - the parameters of InvokeInfo
+ *  @param method - the ABI of method, nil if the contract has no ABI
+ *  @return the arguments of the method
+ */
+func (ws *WasmService) writeArguments(params []string, method *types.AbiMethod) ([]uint64, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+	if method != nil && len(method.Params) != len(params) {
+		return nil, errors.New(fmt.Sprintf("method %s needs %d arguments, but got %d", method.Name, len(method.Params), len(params)))
+	}
+	byValue := func(i int) bool {
+		return method != nil && (method.Params[i].Type == types.AbiInt32 || method.Params[i].Type == types.AbiInt64)
+	}
+	var size int64
+	for i, v := range params {
+		if !byValue(i) {
+			size += int64(len(v)) + 1
+		}
+	}
+	if int64(len(ws.vm.Memory()))+size > int64(^uint32(0)>>1) {
+		return nil, errors.New("invoke arguments are too large")
+	}
+	pages := int32((size + wasmPageSize - 1) / wasmPageSize)
+	grown := ws.vm.GrowMemory(pages)
+	if grown < 0 {
+		return nil, errors.New("invoke arguments exceed the memory limit of contract")
+	}
+	offset := grown * wasmPageSize
+
+	var args []uint64
+	for i, v := range params {
+		if byValue(i) {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			if method.Params[i].Type == types.AbiInt32 {
+				args = append(args, uint64(uint32(n)))
+			} else {
+				args = append(args, uint64(n))
+			}
+			continue
+		}
+		if err := ws.writeBytes(offset, append([]byte(v), 0)); err != nil {
+			return nil, err
+		}
+		args = append(args, uint64(uint32(offset)), uint64(len(v)))
+		offset += int32(len(v)) + 1
+	}
+	return args, nil
+}
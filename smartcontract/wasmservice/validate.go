@@ -0,0 +1,209 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball library.
+//
+// The go-ecoball library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasmservice
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/ecoball/go-ecoball/vm/wasmvm/disasm"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/validate"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/wasm"
+)
+
+//the limits of wasm modules, they are part of consensus so every node must use the same values
+const (
+	MaxCodeSize    = 262144 //the maximum size in bytes of a deployed wasm module
+	MaxMemoryPages = 16     //the maximum pages of linear memory, a page is 64KB
+	MaxTableSize   = 1024   //the maximum elements of table
+	MaxFunctions   = 4096   //the maximum functions defined by a module
+)
+
+func isFloat(t wasm.ValueType) bool {
+	return t == wasm.ValueTypeF32 || t == wasm.ValueTypeF64
+}
+
+func hasFloat(types []wasm.ValueType) bool {
+	for _, t := range types {
+		if isFloat(t) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ *  @brief check the module before it is deployed, the module must pass validate.VerifyModule, stay in the
+ *  limits of chain, import only the host functions registered by RegisterApi and use no floating point,
+ *  which is non-deterministic across nodes
+ *  @param code - the wasm module
+ */
+func VerifyModule(code []byte) error {
+	if len(code) > MaxCodeSize {
+		return errors.New(fmt.Sprintf("the size of module %d exceeds the limit %d", len(code), MaxCodeSize))
+	}
+	//resolve the imports with the host functions
+	m, err := wasm.ReadModuleWithHosts(bytes.NewReader(code), importer, staticHosts)
+	if err != nil {
+		return err
+	}
+	if m.Start != nil {
+		return errors.New("module start function is not supported")
+	}
+	if err := verifyLimits(m); err != nil {
+		return err
+	}
+	imports, err := verifyImports(m)
+	if err != nil {
+		return err
+	}
+	if err := validate.VerifyModule(m); err != nil {
+		return err
+	}
+	return verifyNoFloat(m, imports)
+}
+
+func verifyLimits(m *wasm.Module) error {
+	if m.Function != nil && len(m.Function.Types) > MaxFunctions {
+		return errors.New(fmt.Sprintf("the module defines %d functions, exceeds the limit %d", len(m.Function.Types), MaxFunctions))
+	}
+	if m.Memory != nil {
+		for _, mem := range m.Memory.Entries {
+			if err := checkLimits("memory pages", mem.Limits, MaxMemoryPages); err != nil {
+				return err
+			}
+		}
+	}
+	if m.Table != nil {
+		for _, table := range m.Table.Entries {
+			if err := checkLimits("table size", table.Limits, MaxTableSize); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkLimits(name string, limits wasm.ResizableLimits, max int) error {
+	if uint64(limits.Initial) > uint64(max) {
+		return errors.New(fmt.Sprintf("the initial %s %d exceeds the limit %d", name, limits.Initial, max))
+	}
+	if limits.Flags&0x1 != 0 && uint64(limits.Maximum) > uint64(max) {
+		return errors.New(fmt.Sprintf("the maximum %s %d exceeds the limit %d", name, limits.Maximum, max))
+	}
+	return nil
+}
+
+/**
+ *  @brief check every function import is a host function with the same signature, the host function
+ *  takes and returns int32, uint32, int64 or uint64
+ *  @return the number of imported functions
+ */
+func verifyImports(m *wasm.Module) (int, error) {
+	if m.Import == nil {
+		return 0, nil
+	}
+	var imports int
+	for _, entry := range m.Import.Entries {
+		if entry.ModuleName != "env" || entry.Kind != wasm.ExternalFunction {
+			return 0, errors.New(fmt.Sprintf("unsupported import %s.%s", entry.ModuleName, entry.FieldName))
+		}
+		imports++
+		host := staticHosts.GetValue(entry.FieldName)
+		if !host.IsValid() {
+			return 0, errors.New(fmt.Sprintf("unknown host function %s", entry.FieldName))
+		}
+		index := entry.Type.(wasm.FuncImport).Type
+		if m.Types == nil || int(index) >= len(m.Types.Entries) {
+			return 0, errors.New(fmt.Sprintf("invalid type of import %s", entry.FieldName))
+		}
+		sig := m.Types.Entries[index]
+		if !sameHostTypes(host.Type(), sig) {
+			return 0, errors.New(fmt.Sprintf("import %s %v -> %v does not match the host function %v", entry.FieldName, sig.ParamTypes, sig.ReturnTypes, host.Type()))
+		}
+	}
+	return imports, nil
+}
+
+func hostValueType(k reflect.Kind) (wasm.ValueType, bool) {
+	switch k {
+	case reflect.Int32, reflect.Uint32:
+		return wasm.ValueTypeI32, true
+	case reflect.Int64, reflect.Uint64:
+		return wasm.ValueTypeI64, true
+	default:
+		return 0, false
+	}
+}
+
+func sameHostTypes(fn reflect.Type, sig wasm.FunctionSig) bool {
+	if fn.NumIn() != len(sig.ParamTypes) || fn.NumOut() != len(sig.ReturnTypes) {
+		return false
+	}
+	for i := 0; i < fn.NumIn(); i++ {
+		if t, ok := hostValueType(fn.In(i).Kind()); !ok || t != sig.ParamTypes[i] {
+			return false
+		}
+	}
+	for i := 0; i < fn.NumOut(); i++ {
+		if t, ok := hostValueType(fn.Out(i).Kind()); !ok || t != sig.ReturnTypes[i] {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+ *  @brief reject the floating point types in signatures, globals and locals and every floating point opcode
+ *  @param imports - the number of imported functions, which are at the start of the function index space
+ */
+func verifyNoFloat(m *wasm.Module, imports int) error {
+	if m.Types != nil {
+		for _, sig := range m.Types.Entries {
+			if hasFloat(sig.ParamTypes) || hasFloat(sig.ReturnTypes) {
+				return errors.New(fmt.Sprintf("floating point is not supported: function type %v -> %v", sig.ParamTypes, sig.ReturnTypes))
+			}
+		}
+	}
+	if m.Global != nil {
+		for _, g := range m.Global.Globals {
+			if isFloat(g.Type.Type) {
+				return errors.New("floating point is not supported: global variable")
+			}
+		}
+	}
+	for i := imports; i < len(m.FunctionIndexSpace); i++ {
+		fn := m.FunctionIndexSpace[i]
+		for _, local := range fn.Body.Locals {
+			if isFloat(local.Type) {
+				return errors.New(fmt.Sprintf("floating point is not supported: local variable of function %d", i))
+			}
+		}
+		d, err := disasm.Disassemble(fn, m)
+		if err != nil {
+			return err
+		}
+		for _, instr := range d.Code {
+			if isFloat(instr.Op.Returns) || hasFloat(instr.Op.Args) {
+				return errors.New(fmt.Sprintf("floating point is not supported: %s in function %d", instr.Op.Name, i))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package wasmservice_test
+
+import (
+	"testing"
+
+	"github.com/ecoball/go-ecoball/smartcontract/wasmservice"
+)
+
+func uleb(v uint64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+		if v == 0 {
+			return b
+		}
+	}
+}
+
+func vec(items ...[]byte) []byte {
+	b := uleb(uint64(len(items)))
+	for _, item := range items {
+		b = append(b, item...)
+	}
+	return b
+}
+
+func name(s string) []byte {
+	return append(uleb(uint64(len(s))), s...)
+}
+
+func section(id byte, payload []byte) []byte {
+	return append(append([]byte{id}, uleb(uint64(len(payload)))...), payload...)
+}
+
+func module(sections ...[]byte) []byte {
+	m := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	for _, s := range sections {
+		m = append(m, s...)
+	}
+	return m
+}
+
+//a function type of the value types, 0x7f is i32, 0x7e is i64, 0x7d is f32 and 0x7c is f64
+func sig(params, results []byte) []byte {
+	b := append([]byte{0x60}, uleb(uint64(len(params)))...)
+	b = append(b, params...)
+	b = append(b, uleb(uint64(len(results)))...)
+	return append(b, results...)
+}
+
+//the body of function with the locals and instructions, the end is appended
+func body(locals []byte, code ...byte) []byte {
+	b := append(locals, code...)
+	b = append(b, 0x0b)
+	return append(uleb(uint64(len(b))), b...)
+}
+
+//the sections of n functions of type 0 with the same body
+func functions(n int, fn []byte) [][]byte {
+	indices := make([][]byte, n)
+	bodies := make([][]byte, n)
+	for i := range indices {
+		indices[i] = uleb(0)
+		bodies[i] = fn
+	}
+	return [][]byte{section(3, vec(indices...)), section(10, vec(bodies...))}
+}
+
+func TestVerifyModule(t *testing.T) {
+	i32 := sig(nil, []byte{0x7f})
+	noLocals := []byte{0x00}
+	ret0 := body(noLocals, 0x41, 0x00)
+	one := functions(1, ret0)
+	memory := func(limits ...byte) []byte {
+		return section(5, vec(limits))
+	}
+	importFunc := func(module, field string, typ uint64) []byte {
+		return section(2, vec(append(append(append(name(module), name(field)...), 0x00), uleb(typ)...)))
+	}
+	padding := section(0, append(name("padding"), make([]byte, wasmservice.MaxCodeSize)...))
+	many := functions(wasmservice.MaxFunctions+1, ret0)
+
+	cases := []struct {
+		name  string
+		code  []byte
+		valid bool
+	}{
+		{"valid", module(section(1, vec(i32)), one[0], memory(0x00, 0x01), one[1]), true},
+		{"host import", module(section(1, vec(i32)), importFunc("env", "AbaAcceptCharge", 0), one[0], one[1]), true},
+		{"memory in limit", module(section(1, vec(i32)), one[0], memory(0x01, 0x01, wasmservice.MaxMemoryPages), one[1]), true},
+
+		{"f32 opcode", module(section(1, vec(i32)), one[0], section(10, vec(body(noLocals, 0x43, 0x00, 0x00, 0x80, 0x3f, 0x1a, 0x41, 0x00)))), false},
+		{"f64 opcode", module(section(1, vec(i32)), one[0], section(10, vec(body(noLocals, 0x44, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f, 0xaa, 0x1a, 0x41, 0x00)))), false},
+		{"float conversion", module(section(1, vec(i32)), one[0], section(10, vec(body(noLocals, 0x41, 0x01, 0xb2, 0xa8)))), false},
+		{"float signature", module(section(1, vec(sig([]byte{0x7c}, nil))), section(3, vec(uleb(0))), section(10, vec(body(noLocals)))), false},
+		{"float local", module(section(1, vec(i32)), one[0], section(10, vec(body([]byte{0x01, 0x01, 0x7d}, 0x41, 0x00)))), false},
+		{"float global", module(section(1, vec(i32)), one[0], section(6, vec([]byte{0x7d, 0x00, 0x43, 0x00, 0x00, 0x00, 0x00, 0x0b})), one[1]), false},
+
+		{"initial memory", module(section(1, vec(i32)), one[0], memory(0x00, wasmservice.MaxMemoryPages+1), one[1]), false},
+		{"maximum memory", module(section(1, vec(i32)), one[0], memory(0x01, 0x01, wasmservice.MaxMemoryPages+1), one[1]), false},
+		{"table size", module(section(1, vec(i32)), one[0], section(4, vec(append([]byte{0x70, 0x00}, uleb(wasmservice.MaxTableSize+1)...))), one[1]), false},
+		{"functions", module(section(1, vec(i32)), many[0], many[1]), false},
+		{"code size", module(section(1, vec(i32)), one[0], one[1], padding), false},
+		{"start function", module(section(1, vec(sig(nil, nil))), section(3, vec(uleb(0))), section(8, uleb(0)), section(10, vec(body(noLocals)))), false},
+
+		{"unknown host function", module(section(1, vec(i32)), importFunc("env", "AbaUnknown", 0), one[0], one[1]), false},
+		{"host signature", module(section(1, vec(i32, sig([]byte{0x7f}, []byte{0x7f}))), importFunc("env", "AbaAcceptCharge", 1), one[0], one[1]), false},
+		{"other module", module(section(1, vec(i32)), importFunc("other", "f", 0), one[0], one[1]), false},
+		{"memory import", module(section(1, vec(i32)), section(2, vec(append(append(name("env"), name("memory")...), 0x02, 0x00, 0x01))), one[0], one[1]), false},
+	}
+	for _, c := range cases {
+		err := wasmservice.VerifyModule(c.code)
+		if c.valid && err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		} else if !c.valid && err == nil {
+			t.Errorf("%s: the module must be rejected", c.name)
+		}
+	}
+}
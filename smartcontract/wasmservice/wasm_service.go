@@ -17,7 +17,6 @@
 package wasmservice
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -31,6 +30,7 @@ import (
 	"github.com/ecoball/go-ecoball/vm/wasmvm/validate"
 	"github.com/ecoball/go-ecoball/vm/wasmvm/wasm"
 	"io/ioutil"
+	"math/big"
 	"os"
 )
 
@@ -40,82 +40,102 @@ type WasmService struct {
 	state  *state.State
 	tx     *types.Transaction
 	Code   []byte
-	Args   []uint64
+	Args   []string
 	Method string
+	Abi    *types.Abi
+	vm     *exec.VM
+	ctx    *CallContext
+	depth  int
+	hosts  *wasm.NativeFuns
 }
 
-func NewWasmService(s *state.State, tx *types.Transaction, contract *types.DeployInfo, invoke *types.InvokeInfo) (*WasmService, error) {
+func NewWasmService(s *state.State, tx *types.Transaction, contract *types.DeployInfo, invoke *types.InvokeInfo, ctx *CallContext, depth int) (*WasmService, error) {
 	if contract == nil {
 		return nil, errors.New("contract is nil")
 	}
 
-	params, err := ParseArguments(invoke.Param)
-	if err != nil {
-		return nil, err
-	}
 	ws := &WasmService{
 		state:  s,
 		tx:     tx,
 		Code:   contract.Code,
-		Args:   params,
+		Args:   invoke.Param,
 		Method: string(invoke.Method),
+		Abi:    contract.Abi,
+		ctx:    ctx,
+		depth:  depth,
 	}
 	ws.RegisterApi()
 	return ws, nil
 }
 
-func ParseArguments(param []string) ([]uint64, error) {
-	var args []uint64
-	for _, v := range param {
-		arg, err := common.StringToPointer(v)
-		if err != nil {
-			return nil, err
-		}
-		args = append(args, arg)
-	}
-	return args, nil
-}
-
 func ReadWasm(file string) ([]byte, error) {
 	raw, err := ioutil.ReadFile(file)
 	if err != nil {
-		fmt.Println(err)
 		return nil, err
 	}
 	return raw, nil
 }
 
-func (ws *WasmService) Execute() ([]byte, error) {
-	bf := bytes.NewBuffer(ws.Code)
-	m, err := wasm.ReadModule(bf, importer)
+/**
+ *  @brief execute the contract method with a gas budget, the execution traps with exec.ErrOutOfGas when the budget is exhausted
+ *  @param gasLimit - the maximum gas the invoke may consume
+ *  @return the result of method and the gas consumed
+ */
+func (ws *WasmService) Execute(gasLimit uint64) ([]byte, uint64, error) {
+	compiled, err := loadModule(ws.Code)
 	if err != nil {
 		log.Error("could not read module:", err)
-		return nil, err
+		return nil, 0, err
 	}
+	m := compiled.Module()
 
-	if m.Export == nil {
-		log.Warn("module has no export section")
+	if ws.hosts == nil {
+		ws.RegisterApi()
 	}
-
-	vm, err := exec.NewVM(m)
+	vm, err := compiled.NewVM(ws.hosts.GetValue)
 	if err != nil {
-		fmt.Printf("could not create VM: %v", err)
+		log.Error("could not create VM:", err)
+		return nil, 0, err
+	}
+	vm.RecoverPanic = true
+	vm.MaxMemoryPages = MaxMemoryPages
+	if ws.ctx != nil && ws.ctx.Tracer != nil {
+		vm.SetTracer(ws.ctx.Tracer)
 	}
+	ws.vm = vm
 	entry, ok := m.Export.Entries[ws.Method]
-
 	if ok == false {
-		fmt.Printf("method does not exist!")
+		return nil, 0, errors.New(fmt.Sprintf("method %s does not exist", ws.Method))
 	}
 	index := int64(entry.Index)
-	fIdx := m.Function.Types[int(index)]
-	fType := m.Types.Entries[int(fIdx)]
+	fType := m.GetFunction(int(index)).Sig
+	var method *types.AbiMethod
+	if ws.Abi != nil {
+		if method, err = ws.Abi.Method(ws.Method); err != nil {
+			return nil, 0, err
+		}
+	}
+	args, err := ws.writeArguments(ws.Args, method)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	res, err := vm.ExecCode(index, ws.Args...)
+	vm.SetGasLimit(gasLimit)
+	res, err := vm.ExecCode(index, args...)
 	if err != nil {
-		fmt.Printf("err=%v", err)
+		log.Error("execute method", ws.Method, "failed:", err, "gas used:", vm.GasUsed())
+		return nil, vm.GasUsed(), err
 	}
-	fmt.Printf("res:%[1]v (%[1]T)\n", res)
-	switch fType.ReturnTypes[0] {
+	log.Debug("result:", res, "gas used:", vm.GasUsed())
+	ret, err := ws.result(fType.ReturnTypes, res)
+	return ret, vm.GasUsed(), err
+}
+
+func (ws *WasmService) result(returns []wasm.ValueType, res interface{}) ([]byte, error) {
+	if len(returns) == 0 {
+		return nil, nil
+	}
+	switch returns[0] {
 	case wasm.ValueTypeI32:
 		return util.Int32ToBytes(res.(uint32)), nil
 	case wasm.ValueTypeI64:
@@ -146,8 +166,17 @@ func importer(name string) (*wasm.Module, error) {
 	return m, nil
 }
 
+//the host functions of a service without state, the imports of modules are read and verified with them, the table
+//is built once and only read after that
+var staticHosts = new(WasmService).hostFuns()
+
 func (ws *WasmService) RegisterApi() {
-	functions := wasm.InitNativeFuns()
+	ws.hosts = ws.hostFuns()
+}
+
+//the host functions bound to the service
+func (ws *WasmService) hostFuns() *wasm.NativeFuns {
+	functions := wasm.NewNativeFuns()
 	functions.Register("AbaLog", ws.AbaLog)
 	functions.Register("Println", ws.Println)
 	functions.Register("RequirePermission", ws.RequirePermission)
@@ -155,125 +184,303 @@ func (ws *WasmService) RegisterApi() {
 	functions.Register("AbaAccountAdd", ws.AbaAccountAdd)
 	functions.Register("AbaStoreSet", ws.AbaStoreSet)
 	functions.Register("AbaStoreGet", ws.AbaStoreGet)
+	functions.Register("AbaStoreDelete", ws.AbaStoreDelete)
+	functions.Register("AbaStoreNext", ws.AbaStoreNext)
+	functions.Register("AbaAccountGetBalance", ws.AbaAccountGetBalance)
+	functions.Register("AbaTransfer", ws.AbaTransfer)
+	functions.Register("AbaTokenCreate", ws.AbaTokenCreate)
+	functions.Register("AbaGetCurrentHeight", ws.AbaGetCurrentHeight)
+	functions.Register("AbaGetBlockTime", ws.AbaGetBlockTime)
+	functions.Register("AbaCallContract", ws.AbaCallContract)
+	functions.Register("AbaSendInline", ws.AbaSendInline)
+	functions.Register("AbaEmitEvent", ws.AbaEmitEvent)
+	functions.Register("AbaAcceptCharge", ws.AbaAcceptCharge)
+	return functions
 }
-func (ws *WasmService) Println(str string) int32 {
-	fmt.Println(str)
+func (ws *WasmService) Println(str, length int32) int32 {
+	msg, err := ws.readString(str, length)
+	if err != nil {
+		log.Error("Println error:", err)
+		return -1
+	}
+	log.Info(msg)
 	return 0
 }
-func (ws *WasmService) AbaLog(pointer uint64) int32 {
-	fmt.Println("AbaLog:---------")
-	str := common.PointerToString(pointer)
-	fmt.Printf(str)
+func (ws *WasmService) AbaLog(str, length int32) int32 {
+	msg, err := ws.readString(str, length)
+	if err != nil {
+		log.Error("AbaLog error:", err)
+		return -1
+	}
+	log.Info("AbaLog:", msg)
 	return 0
 }
 
-func (ws *WasmService) AbaAccountAdd(user, addr uint64) int32 {
-	name := common.PointerToString(user)
+func (ws *WasmService) AbaAccountAdd(user, userLen, addr, addrLen int32) int32 {
+	ws.requireWritable()
+	name, err := ws.readString(user, userLen)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
 	log.Debug("AbaAccountAdd:", name)
-	address := common.FormHexString(common.PointerToString(addr))
-	_, err := ws.state.AddAccount(common.NameToIndex(name), address)
+	hexAddr, err := ws.readString(addr, addrLen)
 	if err != nil {
 		log.Error(err)
 		return -1
 	}
+	address := common.FormHexString(hexAddr)
+	if _, err := ws.state.AddAccount(common.NameToIndex(name), address); err != nil {
+		log.Error(err)
+		return -1
+	}
 	return 0
 }
-func (ws *WasmService) AbaStoreSet(key, value uint64) int32 {
-	keyStr := common.PointerToString(key)
-	valueStr := common.PointerToString(value)
-	log.Debug("AbaStoreSet:", keyStr, valueStr)
-	if err := ws.state.StoreSet(common.NameToIndex("root"), []byte(keyStr), []byte(valueStr)); err != nil {
+func (ws *WasmService) AbaStoreSet(key, keyLen, value, valueLen int32) int32 {
+	ws.requireWritable()
+	keyData, err := ws.readBytes(key, keyLen)
+	if err != nil {
+		log.Error("AbaStoreSet error:", err)
+		return 1
+	}
+	valueData, err := ws.readBytes(value, valueLen)
+	if err != nil {
+		log.Error("AbaStoreSet error:", err)
+		return 1
+	}
+	log.Debug("AbaStoreSet:", string(keyData), string(valueData))
+	if err := ws.state.StoreSet(ws.tx.Addr, keyData, valueData); err != nil {
 		log.Error("AbaStoreSet error:", err)
 		return 1
 	}
 	return 0
 }
-func (ws *WasmService) AbaStoreGet(key uint64) int32 {
-	keyStr := common.PointerToString(key)
-	value, err := ws.state.StoreGet(common.NameToIndex("root"), []byte(keyStr))
+
+/**
+ *  @brief copy the value of key in the contract storage into the buffer of the contract,
+ *  at most valueLen bytes are copied
+ *  @return the length of the value, -1 if the key does not exist
+ */
+func (ws *WasmService) AbaStoreGet(key, keyLen, value, valueLen int32) int32 {
+	keyData, err := ws.readBytes(key, keyLen)
 	if err != nil {
-		return 0
+		log.Error("AbaStoreGet error:", err)
+		return -1
+	}
+	data, err := ws.state.StoreGet(ws.tx.Addr, keyData)
+	if err != nil {
+		log.Error("AbaStoreGet error:", err)
+		return -1
+	}
+	if data == nil {
+		return -1
+	}
+	if err := ws.writeValue(value, valueLen, data); err != nil {
+		log.Error("AbaStoreGet error:", err)
+		return -1
+	}
+	return int32(len(data))
+}
+func (ws *WasmService) AbaStoreDelete(key, keyLen int32) int32 {
+	ws.requireWritable()
+	keyData, err := ws.readBytes(key, keyLen)
+	if err != nil {
+		log.Error("AbaStoreDelete error:", err)
+		return 1
+	}
+	if err := ws.state.StoreDelete(ws.tx.Addr, keyData); err != nil {
+		log.Error("AbaStoreDelete error:", err)
+		return 1
 	}
-	fmt.Println("AbaStoreGet:", string(value))
 	return 0
 }
-func (ws *WasmService) AddPermission(user, perm uint64) int32 {
-	name := common.PointerToString(user)
+
+/**
+ *  @brief iterate the keys of the contract storage which start with prefix in byte order,
+ *  the next key after the given one is copied into the buffer of the contract, every storage
+ *  entry visited is charged exec.GasStorageEntry
+ *  @param key - the previous key, keyLen is -1 to get the first key
+ *  @return the length of the next key, -1 if there is no more key
+ */
+func (ws *WasmService) AbaStoreNext(prefix, prefixLen, key, keyLen, next, nextLen int32) int32 {
+	prefixData, err := ws.readBytes(prefix, prefixLen)
+	if err != nil {
+		log.Error("AbaStoreNext error:", err)
+		return -1
+	}
+	var keyData []byte
+	if keyLen >= 0 {
+		if keyData, err = ws.readBytes(key, keyLen); err != nil {
+			log.Error("AbaStoreNext error:", err)
+			return -1
+		}
+	}
+	data, visited, err := ws.state.StoreNext(ws.tx.Addr, prefixData, keyData)
+	ws.vm.UseGas(uint64(visited) * exec.GasStorageEntry)
+	if err != nil {
+		log.Error("AbaStoreNext error:", err)
+		return -1
+	}
+	if data == nil {
+		return -1
+	}
+	if err := ws.writeValue(next, nextLen, data); err != nil {
+		log.Error("AbaStoreNext error:", err)
+		return -1
+	}
+	return int32(len(data))
+}
+func (ws *WasmService) AddPermission(user, userLen, perm, permLen int32) int32 {
+	ws.requireWritable()
+	name, err := ws.readString(user, userLen)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+	data, err := ws.readBytes(perm, permLen)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
 	permission := state.Permission{Keys: make(map[string]state.KeyFactor, 1), Accounts: make(map[string]state.AccFactor, 1)}
-	if err := json.Unmarshal([]byte(common.PointerToString(perm)), &permission); err != nil {
+	if err := json.Unmarshal(data, &permission); err != nil {
+		log.Error(err)
+		return -1
+	}
+	index := common.NameToIndex(name)
+	if err := ws.authorize(index, state.Owner); err != nil {
 		log.Error(err)
 		return -1
 	}
-	if err := ws.state.AddPermission(common.NameToIndex(name), permission); err != nil {
+	if err := ws.state.AddPermission(index, permission); err != nil {
 		log.Error(err)
 		return -1
 	}
 	return 0
 }
-func (ws *WasmService) RequirePermission(perm string) int32 {
-	log.Debug("RequirePermission:", perm)
-	if err := ws.state.CheckPermission(ws.tx.Addr, perm, ws.tx.Signatures); err != nil {
+func (ws *WasmService) RequirePermission(perm, permLen int32) int32 {
+	permission, err := ws.readString(perm, permLen)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+	log.Debug("RequirePermission:", permission)
+	if err := ws.authorize(ws.tx.Addr, permission); err != nil {
 		log.Error(err)
 		return -1
 	}
 	return 0
 }
 
-/*
-func (ws *WasmService) AbaAccountGetBalance(indexAcc, indexToken common.AccountName) uint64 {
-	value, err := ws.ledger.AccountGetBalance(indexAcc, common.IndexToName(indexToken))
+//the permission of account is satisfied by the signatures of transaction, or by the contract sending the action
+//for the called contracts and inline actions
+func (ws *WasmService) authorize(index common.AccountName, permission string) error {
+	if ws.depth > 0 {
+		return ws.state.CheckContractPermission(index, permission, ws.tx.From)
+	}
+	return ws.state.CheckPermission(index, permission, ws.tx.Signatures)
+}
+
+/**
+ *  @brief get the balance of a token in an account
+ *  @return the balance, 0 if the account or token does not exist
+ */
+func (ws *WasmService) AbaAccountGetBalance(user, userLen, token, tokenLen int32) uint64 {
+	name, err := ws.readString(user, userLen)
+	if err != nil {
+		log.Error(err)
+		return 0
+	}
+	tokenName, err := ws.readString(token, tokenLen)
 	if err != nil {
+		log.Error(err)
+		return 0
+	}
+	balance, err := ws.state.AccountGetBalance(common.NameToIndex(name), tokenName)
+	if err != nil || !balance.IsUint64() {
 		return 0
 	}
-	return value
+	return balance.Uint64()
 }
 
-func (ws *WasmService) AbaAccountAddBalance(indexAcc, indexToken common.AccountName, value uint64) int32 {
-	if err := ws.ledger.AccountAddBalance(indexAcc, common.IndexToName(indexToken), value); err != nil {
+/**
+ *  @brief transfer token from the contract account to another account, nothing is changed if the transfer fails
+ *  @param value - the amount of token
+ */
+func (ws *WasmService) AbaTransfer(to, toLen, token, tokenLen int32, value uint64) int32 {
+	ws.requireWritable()
+	name, err := ws.readString(to, toLen)
+	if err != nil {
 		log.Error(err)
 		return -1
 	}
-	return 0
-}
-
-func (ws *WasmService) AbaAccountSubBalance(indexAcc, indexToken common.AccountName, value uint64) int32 {
-	if err := ws.ledger.AccountSubBalance(indexAcc, common.IndexToName(indexToken), value); err != nil {
+	tokenName, err := ws.readString(token, tokenLen)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+	log.Debug("AbaTransfer:", common.IndexToName(ws.tx.Addr), name, tokenName, value)
+	index := common.NameToIndex(name)
+	if _, err := ws.state.GetAccountByName(index); err != nil {
+		log.Error(err)
+		return -1
+	}
+	amount := new(big.Int).SetUint64(value)
+	if err := ws.atomic(func() error {
+		if err := ws.state.AccountSubBalance(ws.tx.Addr, tokenName, amount); err != nil {
+			return err
+		}
+		return ws.state.AccountAddBalance(index, tokenName, amount)
+	}); err != nil {
 		log.Error(err)
 		return -1
 	}
 	return 0
 }
 
-func (ws *WasmService) TokenCreate(indexAcc, indexToken common.AccountName, maximum uint64) int32 {
-	if err := ws.ledger.TokenCreate(indexAcc, common.IndexToName(indexToken), maximum); err != nil {
+/**
+ *  @brief create a new token, the whole supply is issued to the contract account, the token is not
+ *  created if the issue fails
+ *  @param maxSupply - the maximum supply of token
+ */
+func (ws *WasmService) AbaTokenCreate(token, tokenLen int32, maxSupply uint64) int32 {
+	ws.requireWritable()
+	tokenName, err := ws.readString(token, tokenLen)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+	if err := ws.atomic(func() error {
+		return ws.state.TokenCreate(ws.tx.Addr, tokenName, new(big.Int).SetUint64(maxSupply))
+	}); err != nil {
 		log.Error(err)
 		return -1
 	}
 	return 0
 }
 
-func (ws *WasmService) TokenIsExisted(indexToken common.AccountName) int32 {
-	ret := ws.ledger.TokenIsExisted(common.IndexToName(indexToken))
-	if ret {
-		return 1
-	} else {
-		return 0
+//a contract executed for a query traps on any write, so the query fails instead of going on after the write is refused
+func (ws *WasmService) requireWritable() {
+	if ws.state.ReadOnly() {
+		panic(state.ErrReadOnly)
 	}
 }
-func (ws *WasmService) AbaAdd(a int32, b int32) int32 {
-	return a + b
-}
-func (ws *WasmService) AbaLogString(str string) int32 {
-	fmt.Println(str)
-	return 0
-}
 
-func (ws *WasmService) AbaLogInt(value uint64) int32 {
-	fmt.Println("value:", value)
-	return 0
+//apply the changes of a host function together, the state is reverted if any of them fails
+func (ws *WasmService) atomic(change func() error) error {
+	snapshot, err := ws.state.Snapshot()
+	if err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		ws.state.RevertToSnapshot(snapshot)
+		return err
+	}
+	return nil
 }
 func (ws *WasmService) AbaGetCurrentHeight() uint64 {
-	return ws.ledger.GetCurrentHeight()
+	return ws.state.BlockHeight()
+}
+func (ws *WasmService) AbaGetBlockTime() int64 {
+	return ws.state.BlockTime()
 }
-
-*/
@@ -2,10 +2,10 @@ package wasmservice_test
 
 import (
 	"fmt"
-	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/state"
 	"github.com/ecoball/go-ecoball/smartcontract/wasmservice"
+	"sync"
 	"testing"
-	"github.com/ecoball/go-ecoball/test/example"
 )
 
 func TestLog(t *testing.T) {
@@ -18,7 +18,7 @@ func TestLog(t *testing.T) {
 		Method: "main",
 	}
 	ws.RegisterApi()
-	fmt.Println(ws.Execute())
+	fmt.Println(ws.Execute(state.TxGasLimit))
 }
 
 func TestAbaLog(t *testing.T) {
@@ -26,35 +26,61 @@ func TestAbaLog(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	arg, err := common.StringToPointer("Hello World!")
-	if err != nil {
-		t.Fatal(err)
-	}
 	ws := &wasmservice.WasmService{
 		Code:   code,
-		Args:   []uint64{arg},
+		Args:   []string{"Hello World!"},
 		Method: "AbaLog",
 	}
 	ws.RegisterApi()
-	fmt.Println(ws.Execute())
+	fmt.Println(ws.Execute(state.TxGasLimit))
 }
 
 func TestNewAccount(t *testing.T) {
-	code, err := wasmservice.ReadWasm("../../test/root/new_account.wasm")
-	if err != nil {
-		t.Fatal(err)
-	}
-	arg1, err := common.StringToPointer("worker")
-	arg2, err := common.StringToPointer("0x011d09d7f87741494bdc69b67b8b2dc4ecd49c7e")
+	code, err := wasmservice.ReadWasm("../../test/root/root.wasm")
 	if err != nil {
 		t.Fatal(err)
 	}
 	ws := &wasmservice.WasmService{
 		Code:   code,
-		Args:   []uint64{arg1, arg2},
+		Args:   []string{"worker", "0x011d09d7f87741494bdc69b67b8b2dc4ecd49c7e"},
 		Method: "new_account",
 	}
 	ws.RegisterApi()
-	fmt.Println(ws.Execute())
+	fmt.Println(ws.Execute(state.TxGasLimit))
 }
 
+//the services share no host table, run with -race
+func TestConcurrentServices(t *testing.T) {
+	code, err := wasmservice.ReadWasm("../../test/aba_log/aba_log.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := wasmservice.VerifyModule(code); err != nil {
+				errs <- err
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			ws := &wasmservice.WasmService{
+				Code:   code,
+				Args:   []string{"Hello World!"},
+				Method: "AbaLog",
+			}
+			ws.RegisterApi()
+			if _, _, err := ws.Execute(state.TxGasLimit); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
@@ -1,3 +1,7 @@
-int AbaLog(char *buf) {
-  AbaLog(buf);
+__attribute__((import_module("env"), import_name("AbaLog")))
+int aba_log(char *buf, int len);
+
+__attribute__((export_name("AbaLog")))
+int log_message(char *buf, int len) {
+  return aba_log(buf, len);
 }
@@ -1,3 +1,7 @@
+static int add(int a, int b) {
+	return a + b;
+}
+
 int main() {
-	return AbaAdd(11, 22);
+	return add(11, 22);
 }
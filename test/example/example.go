@@ -2,7 +2,6 @@ package example
 
 import (
 	"fmt"
-	"github.com/ecoball/go-ecoball/account"
 	"github.com/ecoball/go-ecoball/common"
 	"github.com/ecoball/go-ecoball/common/config"
 	"github.com/ecoball/go-ecoball/core/state"
@@ -17,7 +16,8 @@ import (
 var log = elog.NewLogger("example", elog.InfoLog)
 
 func ExampleAddAccount(state *state.State) error {
-	from := common.NewAddress(common.FromHex("01b1a6569a557eafcccc71e0d02461fd4b601aea"))
+	//the transactions of examples are signed by root
+	from := common.AddressFromPubKey(config.Root.PublicKey)
 	addr := common.NewAddress(common.FromHex("01ca5cdd56d99a0023166b337ffc7fd0d2c42330"))
 	indexFrom := common.NameToIndex("from")
 	indexAddr := common.NameToIndex("addr")
@@ -31,15 +31,13 @@ func ExampleAddAccount(state *state.State) error {
 }
 
 func ExampleTestInvoke(method string) *types.Transaction {
+	//the contract of ExampleTestDeploy is deployed to the account of sender
 	indexFrom := common.NameToIndex("from")
-	indexAddr := common.NameToIndex("addr")
-	invoke, err := types.NewInvokeContract(indexFrom, indexAddr, "", method, []string{"01b1a6569a557eafcccc71e0d02461fd4b601aea", "Token.Test", "20000"}, 0, time.Now().Unix())
+	invoke, err := types.NewInvokeContract(indexFrom, indexFrom, "", method, []string{"01b1a6569a557eafcccc71e0d02461fd4b601aea", "Token.Test", "20000"}, 0, time.Now().Unix())
 	if err != nil {
 		panic(err)
-		return nil
 	}
-	acc := account.Account{PrivateKey: common.FromHex(config.RootPrivkey), PublicKey: common.FromHex(config.RootPubkey), Alg: 0}
-	if err := invoke.SetSignature(&acc); err != nil {
+	if err := invoke.SetSignature(&config.Root); err != nil {
 		panic(err)
 	}
 	return invoke
@@ -48,13 +46,11 @@ func ExampleTestInvoke(method string) *types.Transaction {
 func ExampleTestDeploy(code []byte) *types.Transaction {
 	indexFrom := common.NameToIndex("from")
 	indexAddr := common.NameToIndex("addr")
-	deploy, err := types.NewDeployContract(indexFrom, indexAddr, "", types.VmWasm, "test deploy", code, 0, time.Now().Unix())
+	deploy, err := types.NewDeployContract(indexFrom, indexAddr, "", types.VmWasm, "test deploy", code, nil, 0, time.Now().Unix())
 	if err != nil {
 		panic(err)
-		return nil
 	}
-	acc := account.Account{PrivateKey: common.FromHex(config.RootPrivkey), PublicKey: common.FromHex(config.RootPubkey), Alg: 0}
-	if err := deploy.SetSignature(&acc); err != nil {
+	if err := deploy.SetSignature(&config.Root); err != nil {
 		panic(err)
 	}
 	return deploy
@@ -69,9 +65,7 @@ func ExampleTestTx() *types.Transaction {
 		fmt.Println(err)
 		return nil
 	}
-	fmt.Println(config.RootPrivkey)
-	acc := account.Account{PrivateKey: common.FromHex(config.RootPrivkey), PublicKey: common.FromHex(config.RootPubkey), Alg: 0}
-	if err := tx.SetSignature(&acc); err != nil {
+	if err := tx.SetSignature(&config.Root); err != nil {
 		fmt.Println(err)
 		return nil
 	}
@@ -1,3 +1,4 @@
 int main() {
-	Println("Hello World!");
+	Println("Hello World!", 12);
+	return 0;
 }
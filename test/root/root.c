@@ -1,30 +1,19 @@
-int new_account(char* account, char *addr) {
-    if (RequirePermission("owner") != 0) {
+int new_account(char *account, int accountLen, char *addr, int addrLen) {
+    if (RequirePermission("owner", 5) != 0) {
         return -1;
     }
-    if (AbaAccountAdd(account, addr) != 0) {
+    if (AbaAccountAdd(account, accountLen, addr, addrLen) != 0) {
         return -1;
     }
     return 0;
 }
 
-int set_account(char* account, char *perm) {
-    if (RequirePermission("active") != 0) {
+int set_account(char *account, int accountLen, char *perm, int permLen) {
+    if (RequirePermission("active", 6) != 0) {
         return -1;
     }
-    if (AddPermission(account, perm) != 0) {
+    if (AddPermission(account, accountLen, perm, permLen) != 0) {
         return -1;
     }
     return 0;
 }
-
-int set_contract(char* account, unsigned int type, char* description, char *code) {
-    if (CheckPermission(account, "active") != 0) {
-        return -1;
-    }
-    if (SetContract(account, type, description, code) != 0) {
-        return -1;
-    }
-    return 0;
-}
-
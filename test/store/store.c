@@ -1,7 +1,9 @@
-int StoreSet(char *key, char *value) {
-    return AbaStoreSet(key, value);
+char buffer[256];
+
+int StoreSet(char *key, int keyLen, char *value, int valueLen) {
+    return AbaStoreSet(key, keyLen, value, valueLen);
 }
 
-char* StoreGet(char *key) {
-    return AbaStoreGet(key);
+int StoreGet(char *key, int keyLen) {
+    return AbaStoreGet(key, keyLen, buffer, sizeof(buffer));
 }
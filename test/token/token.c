@@ -1,55 +1,25 @@
-
-typedef struct {
-    char * account;
-    char * name;
-    unsigned long long value;
-} tsToken;
-
-typedef struct {
-    char *account;
-    char *token;
-    unsigned long long value;
-} tsAccount;
-
-int create(char * account, char * token, unsigned long long value) {
-    if (RequirePermission("active") != 0) {
+/*
+ * the supply of token is stored under the name of token, the balance of account under the name of account,
+ * the values are decimal strings
+ */
+int create(char *account, int accountLen, char *token, int tokenLen, char *value, int valueLen) {
+    if (RequirePermission("active", 6) != 0) {
         return -1;
     }
-    tsToken t = {0};
-    t.account = account;
-    t.name = token;
-    t.value = value;
-    if(AbaStoreGet(token) != 0) {
+    if (AbaStoreGet(token, tokenLen, 0, 0) >= 0) {
         return -1;
     }
-    AbaStoreSet(token, t);
-    return 0;
-}
-
-int transfer(char * from, char * to, char *token, unsigned long long value) {
-    if (CheckPermission(from, "active") != 0) {
+    if (AbaStoreSet(token, tokenLen, value, valueLen) != 0) {
         return -1;
     }
-    sub_balance(from, token, value);
-    add_balance(to, token, value);
-}
-
-int sub_balance(char *account, char *token, unsigned long long value) {
-    tsToken t = {0};
-    tsAccount *acc = AbaStoreGet(account);
-    acc->value = acc->value - value;
-    AbaStoreSet(account, acc);
+    if (AbaStoreSet(account, accountLen, value, valueLen) != 0) {
+        return -1;
+    }
+    return 0;
 }
 
-int add_balance(char *account, char *token, unsigned long long value) {
-    tsToken t = {0};
-    tsAccount *acc = AbaStoreGet(account);
-    acc->value = acc->value + value;
-    AbaStoreSet(account, acc);
-}
+char buffer[256];
 
-tsAccount* balance(char *account, char *token) {
-    tsToken t = {0};
-    tsAccount *acc = AbaStoreGet(account);
-    return acc;
+int balance(char *account, int accountLen) {
+    return AbaStoreGet(account, accountLen, buffer, sizeof(buffer));
 }
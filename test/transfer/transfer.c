@@ -1,9 +1,9 @@
 int main() {
-  unsigned long long balance = AbaAccountGetBalance("Aba","01b1a6569a557eafcccc71e0d02461fd4b601aea");
-  AbaLogInt(balance);
-  int ret = AbaAccountAddBalance(100, "Aba", "01b1a6569a557eafcccc71e0d02461fd4b601aea");
-  if (ret == -1) {
-    AbaLogString("Failed");
+  unsigned long long balance = AbaAccountGetBalance("root", 4, "ABA", 3);
+  if (balance < 100 || AbaTransfer("delegate", 8, "ABA", 3, 100) != 0) {
+    AbaLog("Failed", 6);
+    return -1;
   }
-  AbaLogString("Success");
+  AbaLog("Success", 7);
+  return 0;
 }
@@ -61,6 +61,13 @@ func (l *PoolActor) Receive(ctx actor.Context) {
 		txs := types.NewTxsList()
 		txs.Copy(l.txPool.PengdingTx)
 		ctx.Sender().Tell(txs)
+	case message.GetNonce:
+		nonce, err := l.nextNonce(msg.Index)
+		if err != nil {
+			ctx.Sender().Tell(err)
+		} else {
+			ctx.Sender().Tell(nonce)
+		}
 	case *types.Block:
 		log.Debug("new block delete transactions")
 		l.handleNewBlock(msg)
@@ -107,8 +114,17 @@ func (this *PoolActor) handleTransaction(tx *types.Transaction) error {
 		}
 	}
 
-	//Verify by adding to the transaction pool
-	this.txPool.PengdingTx.Push(tx)
+	//Verify by adding to the queue of sender, it is pending if executable
+	queue, err := this.accountQueue(tx.From)
+	if err != nil {
+		log.Warn("get the nonce of account failed: ", err)
+		return err
+	}
+	if err := queue.add(tx); err != nil {
+		log.Warn(tx.Hash.HexString(), " ", err)
+		return err
+	}
+	queue.promote(this.txPool.PengdingTx)
 
 	//Broadcast transactions on p2p
 	if err := event.Send(event.ActorNil, event.ActorP2P, tx); nil != err {
@@ -122,5 +138,58 @@ func (this *PoolActor) handleTransaction(tx *types.Transaction) error {
 func (this *PoolActor) handleNewBlock(block *types.Block) {
 	for _, v := range block.Transactions {
 		this.txPool.PengdingTx.Delete(v.Hash)
+		if queue, ok := this.txPool.queues[v.From]; ok {
+			this.deleteTransactions(queue.forward(v.Nonce + 1))
+		}
+	}
+	for index, queue := range this.txPool.queues {
+		this.deleteTransactions(queue.expire(block.TimeStamp))
+		queue.promote(this.txPool.PengdingTx)
+		if len(queue.txs) == 0 {
+			delete(this.txPool.queues, index)
+		}
+	}
+}
+
+func (this *PoolActor) deleteTransactions(txs []*types.Transaction) {
+	for _, tx := range txs {
+		this.txPool.PengdingTx.Delete(tx.Hash)
+	}
+}
+
+//get the queue of sender, the nonce of a new queue is read from ledger
+func (this *PoolActor) accountQueue(index common.AccountName) (*accountQueue, error) {
+	if queue, ok := this.txPool.queues[index]; ok {
+		return queue, nil
+	}
+	nonce, err := ledgerNonce(index)
+	if err != nil {
+		return nil, err
+	}
+	queue := newAccountQueue(nonce)
+	this.txPool.queues[index] = queue
+	return queue, nil
+}
+
+//the nonce of the next transaction of account, following the executable transactions in the pool
+func (this *PoolActor) nextNonce(index common.AccountName) (uint64, error) {
+	if queue, ok := this.txPool.queues[index]; ok {
+		return queue.next(), nil
+	}
+	return ledgerNonce(index)
+}
+
+func ledgerNonce(index common.AccountName) (uint64, error) {
+	res, err := event.SendSync(event.ActorLedger, message.GetNonce{Index: index}, time.Second*2)
+	if err != nil {
+		return 0, err
+	}
+	switch v := res.(type) {
+	case uint64:
+		return v, nil
+	case error:
+		return 0, v
+	default:
+		return 0, errors.New("unidentified message")
 	}
 }
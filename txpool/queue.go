@@ -0,0 +1,107 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ecoball/go-ecoball/core/types"
+)
+
+// the transactions whose nonce is maxNonceGap or more after the nonce of account are rejected
+const maxNonceGap = 64
+
+//the transactions of an account in the pool, the ones following the nonce of account without gap are
+//executable and pending, the others are held until the gap is filled
+type accountQueue struct {
+	nonce uint64 //the nonce of account in ledger
+	txs   map[uint64]*types.Transaction
+}
+
+func newAccountQueue(nonce uint64) *accountQueue {
+	return &accountQueue{nonce: nonce, txs: make(map[uint64]*types.Transaction)}
+}
+
+func (q *accountQueue) add(tx *types.Transaction) error {
+	if tx.Nonce < q.nonce {
+		return errors.New(fmt.Sprintf("the nonce %d is lower than the nonce of account %d", tx.Nonce, q.nonce))
+	}
+	if tx.Nonce >= q.nonce+maxNonceGap {
+		return errors.New(fmt.Sprintf("the nonce %d is too far from the nonce of account %d", tx.Nonce, q.nonce))
+	}
+	if _, ok := q.txs[tx.Nonce]; ok {
+		return errors.New(fmt.Sprintf("the nonce %d is used by another transaction in the pool", tx.Nonce))
+	}
+	q.txs[tx.Nonce] = tx
+	return nil
+}
+
+//the nonce of the next transaction after the executable ones
+func (q *accountQueue) next() uint64 {
+	nonce := q.nonce
+	for q.txs[nonce] != nil {
+		nonce++
+	}
+	return nonce
+}
+
+/**
+ *  @brief update the nonce of account after a block is saved, the transactions before it are removed
+ *  @param nonce - the nonce following a transaction packed in block
+ */
+func (q *accountQueue) forward(nonce uint64) []*types.Transaction {
+	if nonce <= q.nonce {
+		return nil
+	}
+	var removed []*types.Transaction
+	for n, tx := range q.txs {
+		if n < nonce {
+			removed = append(removed, tx)
+			delete(q.txs, n)
+		}
+	}
+	q.nonce = nonce
+	return removed
+}
+
+//remove the transactions expired at the time, the transactions after them are held again
+func (q *accountQueue) expire(now int64) []*types.Transaction {
+	var removed []*types.Transaction
+	for n, tx := range q.txs {
+		if tx.Expiration <= now {
+			removed = append(removed, tx)
+			delete(q.txs, n)
+		}
+	}
+	return removed
+}
+
+/**
+ *  @brief update the pending list with the executable transactions of account
+ *  @param pending - the pending list of pool
+ */
+func (q *accountQueue) promote(pending *types.TxsList) {
+	next := q.next()
+	for n, tx := range q.txs {
+		if n < next {
+			pending.Push(tx)
+		} else {
+			pending.Delete(tx.Hash)
+		}
+	}
+}
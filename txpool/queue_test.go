@@ -0,0 +1,86 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ecoball/go-ecoball/common"
+	"github.com/ecoball/go-ecoball/core/types"
+)
+
+func newNonceTx(t *testing.T, nonce uint64, expiration int64) *types.Transaction {
+	tx, err := types.NewTransfer(common.NameToIndex("root"), common.NameToIndex("delegate"), "active", big.NewInt(1), nonce, time.Now().Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Expiration = expiration
+	return tx
+}
+
+func TestAccountQueue(t *testing.T) {
+	now := time.Now().Unix()
+	pending := types.NewTxsList()
+	queue := newAccountQueue(5)
+	if err := queue.add(newNonceTx(t, 4, now+100)); err == nil {
+		t.Fatal("the nonce lower than account must be rejected")
+	}
+	if err := queue.add(newNonceTx(t, 5+maxNonceGap, now+100)); err == nil {
+		t.Fatal("the nonce too far must be rejected")
+	}
+	tx5, tx6, tx7, tx8 := newNonceTx(t, 5, now+100), newNonceTx(t, 6, now+10), newNonceTx(t, 7, now+100), newNonceTx(t, 8, now+100)
+	for _, tx := range []*types.Transaction{tx5, tx7, tx8} {
+		if err := queue.add(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := queue.add(newNonceTx(t, 7, now+200)); err == nil {
+		t.Fatal("the nonce used in pool must be rejected")
+	}
+	queue.promote(pending)
+	if len(pending.Txs) != 1 || !pending.Same(tx5.Hash) || queue.next() != 6 {
+		t.Fatal("only the transaction before the gap is pending")
+	}
+
+	//fill the gap, the held transactions are promoted
+	if err := queue.add(tx6); err != nil {
+		t.Fatal(err)
+	}
+	queue.promote(pending)
+	if len(pending.Txs) != 4 || queue.next() != 9 {
+		t.Fatal("the transactions after the gap are not promoted")
+	}
+
+	//tx5 is packed
+	for _, tx := range queue.forward(6) {
+		pending.Delete(tx.Hash)
+	}
+	if len(pending.Txs) != 3 || pending.Same(tx5.Hash) {
+		t.Fatal("the packed transaction is not removed")
+	}
+
+	//tx6 expires, the transactions after it are held again
+	for _, tx := range queue.expire(now + 10) {
+		pending.Delete(tx.Hash)
+	}
+	queue.promote(pending)
+	if len(pending.Txs) != 0 || queue.next() != 6 || len(queue.txs) != 2 {
+		t.Fatal("the transactions after the expired one must be held")
+	}
+}
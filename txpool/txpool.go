@@ -17,6 +17,7 @@
 package txpool
 
 import (
+	"github.com/ecoball/go-ecoball/common"
 	"github.com/ecoball/go-ecoball/common/elog"
 	"github.com/ecoball/go-ecoball/core/types"
 )
@@ -25,12 +26,14 @@ var log = elog.NewLogger("TxPool", elog.DebugLog)
 
 type TxPool struct {
 	PengdingTx *types.TxsList //Unpackaged list of legitimate transactions
+	//the transactions of every sender ordered by nonce, including the ones not executable yet
+	queues map[common.AccountName]*accountQueue
 }
 
 //start transaction pool
 func Start() (pool *TxPool, err error) {
 	//transaction pool
-	pool = &TxPool{PengdingTx: types.NewTxsList()}
+	pool = &TxPool{PengdingTx: types.NewTxsList(), queues: make(map[common.AccountName]*accountQueue)}
 
 	//transaction pool actor
 	if _, err = NewTxPoolActor(pool); nil != err {
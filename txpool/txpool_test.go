@@ -16,7 +16,6 @@
 package txpool_test
 
 import (
-	"encoding/hex"
 	"fmt"
 	"math/big"
 	"testing"
@@ -50,15 +49,13 @@ func TestTxPool(t *testing.T) {
 }
 
 func newTx(t *testing.T) *types.Transaction {
-	fromUser, _ := hex.DecodeString("01b1a6569a557eafcccc71e0d02461fd4b601aea")
-	toUser, _ := hex.DecodeString("01ca5cdd56d99a0023166b337ffc7fd0d2c42330")
-	from := common.NewAddress(fromUser)
-	to := common.NewAddress(toUser)
+	from := common.NameToIndex("root")
+	to := common.NameToIndex("delegate")
 	value := big.NewInt(100)
 	timeStamp := time.Now().Unix()
 	fmt.Println(timeStamp)
 	//生成结构体，会自动计算哈希值
-	tx, err := types.NewTransfer(from, to, value, 0, timeStamp)
+	tx, err := types.NewTransfer(from, to, "active", value, 0, timeStamp)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -23,6 +23,9 @@ import (
 type Instr struct {
 	Op ops.Op
 
+	// Offset is the byte offset of the operator in the function body.
+	Offset int
+
 	// Immediates are arguments to an operator in the bytecode stream itself.
 	// Valid value types are:
 	// - (u)(int/float)(32/64)
@@ -107,6 +110,7 @@ func Disassemble(fn wasm.Function, module *wasm.Module) (*Disassembly, error) {
 	var lastOpReturn bool
 
 	for {
+		offset := len(code) - reader.Len()
 		op, err := reader.ReadByte()
 		if err == io.EOF {
 			break
@@ -121,6 +125,7 @@ func Disassemble(fn wasm.Function, module *wasm.Module) (*Disassembly, error) {
 		}
 		instr := Instr{
 			Op:         opStr,
+			Offset:     offset,
 			Immediates: [](interface{}){},
 		}
 		if op == ops.End || op == ops.Else {
@@ -0,0 +1,171 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package exec
+
+import (
+	"math"
+	"reflect"
+
+	"github.com/ecoball/go-ecoball/vm/wasmvm/disasm"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/exec/internal/compile"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/wasm"
+)
+
+// CompiledModule is a module whose functions are disassembled and compiled
+// once, it is immutable and can be instantiated by many VMs concurrently.
+type CompiledModule struct {
+	module  *wasm.Module
+	funcs   []function
+	globals []uint64
+	// the import names of host functions by the index in function index space
+	hosts map[int]string
+}
+
+// HostResolver returns the host function bound to an instance for the
+// import name, an invalid value keeps the function the module is read with.
+type HostResolver func(name string) reflect.Value
+
+// CompileModule compiles every function of module and evaluates the initial
+// values of globals.
+func CompileModule(module *wasm.Module) (*CompiledModule, error) {
+	if module.Memory != nil && len(module.Memory.Entries) > 1 {
+		return nil, ErrMultipleLinearMemories
+	}
+	c := &CompiledModule{
+		module:  module,
+		funcs:   make([]function, len(module.FunctionIndexSpace)),
+		globals: make([]uint64, len(module.GlobalIndexSpace)),
+		hosts:   make(map[int]string),
+	}
+
+	// the imported functions are at the start of the function index space
+	// in the order of import entries
+	if module.Import != nil {
+		var i int
+		for _, entry := range module.Import.Entries {
+			if entry.Kind != wasm.ExternalFunction {
+				continue
+			}
+			if entry.ModuleName == "env" {
+				c.hosts[i] = entry.FieldName
+			}
+			i++
+		}
+	}
+
+	for i, fn := range module.FunctionIndexSpace {
+		// Skip native methods as they need not be
+		// disassembled; simply add them at the end
+		// of the `funcs` array as is, as specified
+		// in the spec. See the "host functions"
+		// section of:
+		// https://webassembly.github.io/spec/core/exec/modules.html#allocation
+		if fn.IsHost() {
+			c.funcs[i] = goFunction{
+				typ: fn.Host.Type(),
+				val: fn.Host,
+			}
+			continue
+		}
+
+		disassembly, err := disasm.Disassemble(fn, module)
+		if err != nil {
+			return nil, err
+		}
+
+		totalLocalVars := 0
+		totalLocalVars += len(fn.Sig.ParamTypes)
+		for _, entry := range fn.Body.Locals {
+			totalLocalVars += int(entry.Count)
+		}
+		code, table, offsets := compile.Compile(disassembly.Code)
+		c.funcs[i] = compiledFunction{
+			code:           code,
+			branchTables:   table,
+			offsets:        offsets,
+			maxDepth:       disassembly.MaxDepth,
+			totalLocalVars: totalLocalVars,
+			args:           len(fn.Sig.ParamTypes),
+			returns:        len(fn.Sig.ReturnTypes) != 0,
+		}
+	}
+
+	for i, global := range module.GlobalIndexSpace {
+		val, err := module.ExecInitExpr(global.Init)
+		if err != nil {
+			return nil, err
+		}
+		switch v := val.(type) {
+		case int32:
+			c.globals[i] = uint64(v)
+		case int64:
+			c.globals[i] = uint64(v)
+		case float32:
+			c.globals[i] = uint64(math.Float32bits(v))
+		case float64:
+			c.globals[i] = uint64(math.Float64bits(v))
+		}
+	}
+	return c, nil
+}
+
+// Module returns the module compiled.
+func (c *CompiledModule) Module() *wasm.Module {
+	return c.module
+}
+
+// NewVM creates a VM with a fresh linear memory and globals, the compiled
+// code is shared with the other VMs of the module. The host functions are
+// resolved by resolve if it is not nil. If the module defines a start
+// function, it will be executed.
+func (c *CompiledModule) NewVM(resolve HostResolver) (*VM, error) {
+	var vm VM
+	module := c.module
+
+	if module.Memory != nil && len(module.Memory.Entries) != 0 {
+		vm.memory = make([]byte, uint(module.Memory.Entries[0].Limits.Initial)*wasmPageSize)
+		copy(vm.memory, module.LinearMemoryIndexSpace[0])
+	}
+
+	vm.funcs = make([]function, len(c.funcs))
+	copy(vm.funcs, c.funcs)
+	if resolve != nil {
+		for i, name := range c.hosts {
+			host := resolve(name)
+			if !host.IsValid() {
+				continue
+			}
+			vm.funcs[i] = goFunction{
+				typ: host.Type(),
+				val: host,
+			}
+		}
+	}
+	vm.globals = make([]uint64, len(c.globals))
+	copy(vm.globals, c.globals)
+	vm.newFuncTable()
+	vm.module = module
+
+	if module.Start != nil {
+		_, err := vm.ExecCode(int64(module.Start.Index))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &vm, nil
+}
@@ -20,6 +20,7 @@ type function interface {
 type compiledFunction struct {
 	code           []byte
 	branchTables   []*compile.BranchTable
+	offsets        map[int64]int // byte offset in the function body of the instruction at each address of code
 	maxDepth       int  // maximum stack depth reached while executing the function body
 	totalLocalVars int  // number of local variables used by the function
 	args           int  // number of arguments the function accepts
@@ -32,6 +33,7 @@ type goFunction struct {
 }
 
 func (fn goFunction) call(vm *VM, index int64) {
+	vm.UseGas(GasHostCall)
 	numIn := fn.typ.NumIn()
 	args := make([]reflect.Value, numIn)
 
@@ -59,6 +61,9 @@ func (fn goFunction) call(vm *VM, index int64) {
 	}
 
 	rtrns := fn.val.Call(args)
+	if vm.tracer != nil {
+		vm.traceHostCall(index, args, rtrns)
+	}
 	for i, out := range rtrns {
 		kind := out.Kind()
 		switch kind {
@@ -0,0 +1,116 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package exec
+
+import (
+	"errors"
+
+	"github.com/ecoball/go-ecoball/vm/wasmvm/exec/internal/compile"
+	ops "github.com/ecoball/go-ecoball/vm/wasmvm/wasm/operators"
+)
+
+// ErrOutOfGas is the error value used while trapping the VM when the
+// gas consumed by the executed code exceeds the limit set by SetGasLimit.
+var ErrOutOfGas = errors.New("exec: out of gas")
+
+const (
+	// GasDefault is charged for every opcode without an explicit entry in the cost table.
+	GasDefault uint64 = 1
+	// GasHostCall is charged for every call into a host function.
+	GasHostCall uint64 = 100
+	// GasMemoryPage is charged for every page of linear memory added by grow_memory.
+	GasMemoryPage uint64 = 1000
+	// GasStorageEntry is charged by host functions for every storage entry they visit.
+	GasStorageEntry uint64 = 50
+	// GasEventByte is charged by host functions for every byte of event they record.
+	GasEventByte uint64 = 10
+)
+
+// gasTable holds the cost of every opcode, including the internal opcodes
+// emitted by the compiler, so that loops always consume gas on each jump.
+var gasTable [256]uint64
+
+func init() {
+	for i := range gasTable {
+		gasTable[i] = GasDefault
+	}
+
+	for _, op := range []byte{ops.I32Mul, ops.I64Mul, ops.F32Mul, ops.F64Mul} {
+		gasTable[op] = 3
+	}
+	for _, op := range []byte{
+		ops.I32DivS, ops.I32DivU, ops.I32RemS, ops.I32RemU,
+		ops.I64DivS, ops.I64DivU, ops.I64RemS, ops.I64RemU,
+		ops.F32Div, ops.F64Div, ops.F32Sqrt, ops.F64Sqrt,
+	} {
+		gasTable[op] = 5
+	}
+	for _, op := range []byte{
+		ops.I32Load, ops.I64Load, ops.F32Load, ops.F64Load,
+		ops.I32Load8s, ops.I32Load8u, ops.I32Load16s, ops.I32Load16u,
+		ops.I64Load8s, ops.I64Load8u, ops.I64Load16s, ops.I64Load16u,
+		ops.I64Load32s, ops.I64Load32u,
+		ops.I32Store, ops.I64Store, ops.F32Store, ops.F64Store,
+		ops.I32Store8, ops.I32Store16, ops.I64Store8, ops.I64Store16, ops.I64Store32,
+	} {
+		gasTable[op] = 3
+	}
+	gasTable[ops.Call] = 10
+	gasTable[ops.CallIndirect] = 15
+	gasTable[ops.BrTable] = 3
+	gasTable[ops.GrowMemory] = 10
+
+	gasTable[compile.OpJmp] = 2
+	gasTable[compile.OpJmpZ] = 2
+	gasTable[compile.OpJmpNz] = 2
+}
+
+// GasCost returns the cost charged for executing the given opcode.
+func GasCost(op byte) uint64 {
+	return gasTable[op]
+}
+
+// SetGasLimit enables gas metering for the VM. Every following call of
+// ExecCode traps with ErrOutOfGas once more than limit gas has been used.
+func (vm *VM) SetGasLimit(limit uint64) {
+	vm.metered = true
+	vm.gasLimit = limit
+	vm.gasUsed = 0
+}
+
+// GasUsed returns the amount of gas consumed since the last SetGasLimit.
+func (vm *VM) GasUsed() uint64 {
+	return vm.gasUsed
+}
+
+// GasLimit returns the gas limit set by SetGasLimit.
+func (vm *VM) GasLimit() uint64 {
+	return vm.gasLimit
+}
+
+// UseGas charges cost to the VM and traps with ErrOutOfGas if the limit
+// is exceeded. Host functions use it to charge for the work they do.
+func (vm *VM) UseGas(cost uint64) {
+	if !vm.metered {
+		return
+	}
+	if vm.gasLimit-vm.gasUsed < cost {
+		vm.gasUsed = vm.gasLimit
+		panic(ErrOutOfGas)
+	}
+	vm.gasUsed += cost
+}
@@ -0,0 +1,79 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package exec_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ecoball/go-ecoball/vm/wasmvm/exec"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/wasm"
+)
+
+func loopVM(t *testing.T) (*exec.VM, int64) {
+	f, err := os.Open("testdata/loop.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	m, err := wasm.ReadModule(f, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm, err := exec.NewVM(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm.RecoverPanic = true
+	return vm, int64(m.Export.Entries["loop"].Index)
+}
+
+func TestGasMetering(t *testing.T) {
+	vm, index := loopVM(t)
+	vm.SetGasLimit(1000000)
+	res, err := vm.ExecCode(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.(uint32) != 10 {
+		t.Fatalf("unexpected result: %v", res)
+	}
+	used := vm.GasUsed()
+	if used == 0 {
+		t.Fatal("no gas used by loop")
+	}
+
+	vm, index = loopVM(t)
+	vm.SetGasLimit(1000000)
+	if _, err := vm.ExecCode(index); err != nil {
+		t.Fatal(err)
+	}
+	if vm.GasUsed() != used {
+		t.Fatalf("gas usage is not deterministic: %d != %d", vm.GasUsed(), used)
+	}
+}
+
+func TestOutOfGas(t *testing.T) {
+	vm, index := loopVM(t)
+	vm.SetGasLimit(10)
+	if _, err := vm.ExecCode(index); err != exec.ErrOutOfGas {
+		t.Fatalf("expected %v, got %v", exec.ErrOutOfGas, err)
+	}
+	if vm.GasUsed() != vm.GasLimit() {
+		t.Fatalf("gas used %d should equal limit %d", vm.GasUsed(), vm.GasLimit())
+	}
+}
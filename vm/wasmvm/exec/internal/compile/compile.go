@@ -132,11 +132,14 @@ type block struct {
 }
 
 // Compile rewrites WebAssembly bytecode from its disassembly.
+// The returned map holds the byte offset in the function body of the
+// instruction compiled at each address of the bytecode.
 // TODO(vibhavp): Add options for optimizing code. Operators like i32.reinterpret/f32
 // are no-ops, and can be safely removed.
-func Compile(disassembly []disasm.Instr) ([]byte, []*BranchTable) {
+func Compile(disassembly []disasm.Instr) ([]byte, []*BranchTable, map[int64]int) {
 	buffer := new(bytes.Buffer)
 	branchTables := []*BranchTable{}
+	offsets := make(map[int64]int)
 
 	curBlockDepth := -1
 	blocks := make(map[int]*block) // maps nesting depths (labels) to blocks
@@ -146,6 +149,9 @@ func Compile(disassembly []disasm.Instr) ([]byte, []*BranchTable) {
 		if instr.Unreachable {
 			continue
 		}
+		// block, loop and end may emit nothing, the next instruction
+		// compiled at the same address overwrites their offset
+		offsets[int64(buffer.Len())] = instr.Offset
 		switch instr.Op.Code {
 		case ops.I32Load, ops.I64Load, ops.F32Load, ops.F64Load, ops.I32Load8s, ops.I32Load8u, ops.I32Load16s, ops.I32Load16u, ops.I64Load8s, ops.I64Load8u, ops.I64Load16s, ops.I64Load16u, ops.I64Load32s, ops.I64Load32u, ops.I32Store, ops.I64Store, ops.F32Store, ops.F64Store, ops.I32Store8, ops.I32Store16, ops.I64Store8, ops.I64Store16, ops.I64Store32:
 			// memory_immediate has two fields, the alignment and the offset.
@@ -333,7 +339,7 @@ func Compile(disassembly []disasm.Instr) ([]byte, []*BranchTable) {
 	for _, table := range branchTables {
 		table.patchedAddrs = nil
 	}
-	return buffer.Bytes(), branchTables
+	return buffer.Bytes(), branchTables, offsets
 }
 
 // replace the address starting at start with addr
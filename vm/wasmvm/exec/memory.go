@@ -119,7 +119,9 @@ func (vm *VM) f32Store() {
 	if !vm.inBounds(3) {
 		panic(ErrOutOfBoundsMemoryAccess)
 	}
-	endianess.PutUint32(vm.curMem(), v)
+	addr := vm.fetchBaseAddr()
+	endianess.PutUint32(vm.memory[addr:], v)
+	vm.traceStore(addr, 4)
 }
 
 func (vm *VM) f32Load() {
@@ -134,7 +136,9 @@ func (vm *VM) f64Store() {
 	if !vm.inBounds(7) {
 		panic(ErrOutOfBoundsMemoryAccess)
 	}
-	endianess.PutUint64(vm.curMem(), v)
+	addr := vm.fetchBaseAddr()
+	endianess.PutUint64(vm.memory[addr:], v)
+	vm.traceStore(addr, 8)
 }
 
 func (vm *VM) f64Load() {
@@ -149,7 +153,9 @@ func (vm *VM) i32Store() {
 	if !vm.inBounds(3) {
 		panic(ErrOutOfBoundsMemoryAccess)
 	}
-	endianess.PutUint32(vm.curMem(), v)
+	addr := vm.fetchBaseAddr()
+	endianess.PutUint32(vm.memory[addr:], v)
+	vm.traceStore(addr, 4)
 }
 
 func (vm *VM) i32Store8() {
@@ -157,7 +163,9 @@ func (vm *VM) i32Store8() {
 	if !vm.inBounds(0) {
 		panic(ErrOutOfBoundsMemoryAccess)
 	}
-	vm.memory[vm.fetchBaseAddr()] = v
+	addr := vm.fetchBaseAddr()
+	vm.memory[addr] = v
+	vm.traceStore(addr, 1)
 }
 
 func (vm *VM) i32Store16() {
@@ -165,7 +173,9 @@ func (vm *VM) i32Store16() {
 	if !vm.inBounds(1) {
 		panic(ErrOutOfBoundsMemoryAccess)
 	}
-	endianess.PutUint16(vm.curMem(), v)
+	addr := vm.fetchBaseAddr()
+	endianess.PutUint16(vm.memory[addr:], v)
+	vm.traceStore(addr, 2)
 }
 
 func (vm *VM) i64Store() {
@@ -173,7 +183,9 @@ func (vm *VM) i64Store() {
 	if !vm.inBounds(7) {
 		panic(ErrOutOfBoundsMemoryAccess)
 	}
-	endianess.PutUint64(vm.curMem(), v)
+	addr := vm.fetchBaseAddr()
+	endianess.PutUint64(vm.memory[addr:], v)
+	vm.traceStore(addr, 8)
 }
 
 func (vm *VM) i64Store8() {
@@ -181,7 +193,9 @@ func (vm *VM) i64Store8() {
 	if !vm.inBounds(0) {
 		panic(ErrOutOfBoundsMemoryAccess)
 	}
-	vm.memory[vm.fetchBaseAddr()] = v
+	addr := vm.fetchBaseAddr()
+	vm.memory[addr] = v
+	vm.traceStore(addr, 1)
 }
 
 func (vm *VM) i64Store16() {
@@ -189,7 +203,9 @@ func (vm *VM) i64Store16() {
 	if !vm.inBounds(1) {
 		panic(ErrOutOfBoundsMemoryAccess)
 	}
-	endianess.PutUint16(vm.curMem(), v)
+	addr := vm.fetchBaseAddr()
+	endianess.PutUint16(vm.memory[addr:], v)
+	vm.traceStore(addr, 2)
 }
 
 func (vm *VM) i64Store32() {
@@ -197,7 +213,9 @@ func (vm *VM) i64Store32() {
 	if !vm.inBounds(3) {
 		panic(ErrOutOfBoundsMemoryAccess)
 	}
-	endianess.PutUint32(vm.curMem(), v)
+	addr := vm.fetchBaseAddr()
+	endianess.PutUint32(vm.memory[addr:], v)
+	vm.traceStore(addr, 4)
 }
 
 func (vm *VM) currentMemory() {
@@ -207,8 +225,9 @@ func (vm *VM) currentMemory() {
 
 func (vm *VM) growMemory() {
 	_ = vm.fetchInt8() // reserved (https://github.com/WebAssembly/design/blob/27ac254c854994103c24834a994be16f74f54186/BinaryEncoding.md#memory-related-operators-described-here)
-	curLen := len(vm.memory) / wasmPageSize
 	n := vm.popInt32()
-	vm.memory = append(vm.memory, make([]byte, n*wasmPageSize)...)
-	vm.pushInt32(int32(curLen))
+	if n > 0 {
+		vm.UseGas(uint64(n) * GasMemoryPage)
+	}
+	vm.pushInt32(vm.GrowMemory(n))
 }
@@ -0,0 +1,66 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package exec_test
+
+import (
+	"testing"
+
+	"github.com/ecoball/go-ecoball/vm/wasmvm/exec"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/wasm"
+)
+
+func memoryVM(t *testing.T, limits wasm.ResizableLimits) *exec.VM {
+	m := &wasm.Module{
+		Memory:                 &wasm.SectionMemories{Entries: []wasm.Memory{{Limits: limits}}},
+		LinearMemoryIndexSpace: [][]byte{make([]byte, limits.Initial*65536)},
+	}
+	vm, err := exec.NewVM(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vm
+}
+
+func TestGrowMemory(t *testing.T) {
+	//the maximum declared by the module
+	vm := memoryVM(t, wasm.ResizableLimits{Flags: 1, Initial: 1, Maximum: 3})
+	if n := vm.GrowMemory(2); n != 1 {
+		t.Fatalf("unexpected previous size: %d", n)
+	}
+	if n := vm.GrowMemory(1); n != -1 {
+		t.Fatal("the memory grows beyond the maximum of module")
+	}
+	if len(vm.Memory()) != 3*65536 {
+		t.Fatalf("unexpected memory size: %d", len(vm.Memory()))
+	}
+
+	//the limit of vm
+	vm = memoryVM(t, wasm.ResizableLimits{Initial: 1})
+	vm.MaxMemoryPages = 2
+	if n := vm.GrowMemory(2); n != -1 {
+		t.Fatal("the memory grows beyond the limit of vm")
+	}
+	if n := vm.GrowMemory(1); n != 1 {
+		t.Fatalf("unexpected previous size: %d", n)
+	}
+
+	//the address space of i32
+	vm = memoryVM(t, wasm.ResizableLimits{Initial: 1})
+	if n := vm.GrowMemory(-1); n != -1 {
+		t.Fatal("the memory grows beyond 4 GiB")
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package exec
+
+import (
+	"reflect"
+
+	"github.com/ecoball/go-ecoball/vm/wasmvm/wasm"
+)
+
+// Tracer receives the events of an execution, it is set by SetTracer.
+// The VM is only valid during the call and must not be executed by the tracer,
+// a tracer may trap the execution by panicking with an error like UseGas.
+type Tracer interface {
+	// CaptureOp is called before an opcode of the compiled code is executed,
+	// the opcodes emitted by the compiler for branches and stack unwinding
+	// are reported with the code of the instruction they are compiled from.
+	CaptureOp(vm *VM, op byte)
+	// CaptureMemoryWrite is called after data is written into the linear
+	// memory at offset, by a store instruction or by a host function.
+	CaptureMemoryWrite(vm *VM, offset uint32, data []byte)
+	// CaptureHostCall is called after the host function at index of the
+	// function index space returns.
+	CaptureHostCall(vm *VM, index int64, args, rets []uint64)
+}
+
+// SetTracer sets the tracer receiving the events of execution, nil disables tracing.
+func (vm *VM) SetTracer(tracer Tracer) {
+	vm.tracer = tracer
+}
+
+// Module returns the module executed by the VM.
+func (vm *VM) Module() *wasm.Module {
+	return vm.module
+}
+
+// CurrentFunction returns the index of the executing function in the function index space.
+func (vm *VM) CurrentFunction() int64 {
+	return vm.ctx.curFunc
+}
+
+// PC returns the address of the executing instruction in the compiled code.
+func (vm *VM) PC() int64 {
+	return vm.ctx.pc
+}
+
+// Offset returns the byte offset in the function body of the executing
+// instruction, or -1 if the address has no instruction of the body.
+func (vm *VM) Offset() int {
+	compiled, ok := vm.funcs[vm.ctx.curFunc].(compiledFunction)
+	if !ok {
+		return -1
+	}
+	offset, ok := compiled.offsets[vm.ctx.pc]
+	if !ok {
+		return -1
+	}
+	return offset
+}
+
+// Stack returns a copy of the operand stack of the executing function, the top is the last element.
+func (vm *VM) Stack() []uint64 {
+	return append([]uint64{}, vm.ctx.stack...)
+}
+
+// Locals returns a copy of the parameters and local variables of the executing function.
+func (vm *VM) Locals() []uint64 {
+	return append([]uint64{}, vm.ctx.locals...)
+}
+
+// TraceMemoryWrite reports the data written into the linear memory at
+// offset by a host function to the tracer.
+func (vm *VM) TraceMemoryWrite(offset uint32, data []byte) {
+	if vm.tracer != nil {
+		vm.tracer.CaptureMemoryWrite(vm, offset, data)
+	}
+}
+
+func (vm *VM) traceStore(addr int, size int) {
+	if vm.tracer != nil {
+		vm.tracer.CaptureMemoryWrite(vm, uint32(addr), vm.memory[addr:addr+size])
+	}
+}
+
+func (vm *VM) traceHostCall(index int64, args, rets []reflect.Value) {
+	vm.tracer.CaptureHostCall(vm, index, rawValues(args), rawValues(rets))
+}
+
+func rawValues(values []reflect.Value) []uint64 {
+	raw := make([]uint64, len(values))
+	for i, v := range values {
+		switch v.Kind() {
+		case reflect.Uint32, reflect.Uint64:
+			raw[i] = v.Uint()
+		case reflect.Int32, reflect.Int64:
+			raw[i] = uint64(v.Int())
+		}
+	}
+	return raw
+}
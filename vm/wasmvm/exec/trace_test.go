@@ -0,0 +1,65 @@
+// Copyright 2018 The go-ecoball Authors
+// This file is part of the go-ecoball.
+//
+// The go-ecoball is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ecoball is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ecoball. If not, see <http://www.gnu.org/licenses/>.
+
+package exec_test
+
+import (
+	"testing"
+
+	"github.com/ecoball/go-ecoball/vm/wasmvm/disasm"
+	"github.com/ecoball/go-ecoball/vm/wasmvm/exec"
+)
+
+type opTracer struct {
+	ops     int
+	offsets map[int]bool
+}
+
+func (t *opTracer) CaptureOp(vm *exec.VM, op byte) {
+	t.ops++
+	t.offsets[vm.Offset()] = true
+}
+
+func (t *opTracer) CaptureMemoryWrite(vm *exec.VM, offset uint32, data []byte) {}
+
+func (t *opTracer) CaptureHostCall(vm *exec.VM, index int64, args, rets []uint64) {}
+
+func TestTracer(t *testing.T) {
+	vm, index := loopVM(t)
+	tracer := &opTracer{offsets: make(map[int]bool)}
+	vm.SetTracer(tracer)
+	if _, err := vm.ExecCode(index); err != nil {
+		t.Fatal(err)
+	}
+	if tracer.ops == 0 {
+		t.Fatal("no opcode is traced")
+	}
+
+	fn := vm.Module().FunctionIndexSpace[index]
+	d, err := disasm.Disassemble(fn, vm.Module())
+	if err != nil {
+		t.Fatal(err)
+	}
+	instrs := make(map[int]bool)
+	for _, instr := range d.Code {
+		instrs[instr.Offset] = true
+	}
+	for offset := range tracer.offsets {
+		if offset != -1 && !instrs[offset] {
+			t.Fatalf("traced offset %d is not an instruction of the function", offset)
+		}
+	}
+}
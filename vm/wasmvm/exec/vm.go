@@ -11,7 +11,6 @@ import (
 	"fmt"
 	"math"
 
-	"github.com/ecoball/go-ecoball/vm/wasmvm/disasm"
 	"github.com/ecoball/go-ecoball/vm/wasmvm/exec/internal/compile"
 	"github.com/ecoball/go-ecoball/vm/wasmvm/wasm"
 	ops "github.com/ecoball/go-ecoball/vm/wasmvm/wasm/operators"
@@ -61,12 +60,22 @@ type VM struct {
 
 	funcTable [256]func()
 
+	metered  bool
+	gasLimit uint64
+	gasUsed  uint64
+
+	tracer Tracer
+
 	// RecoverPanic controls whether the `ExecCode` method
 	// recovers from a panic and returns it as an error
 	// instead.
 	// A panic can occur either when executing an invalid VM
 	// or encountering an invalid instruction, e.g. `unreachable`.
 	RecoverPanic bool
+
+	// MaxMemoryPages limits the pages the linear memory can grow to,
+	// besides the maximum declared by the module. 0 means no limit.
+	MaxMemoryPages uint32
 }
 
 // As per the WebAssembly spec: https://github.com/WebAssembly/design/blob/27ac254c854994103c24834a994be16f74f54186/Semantics.md#linear-memory
@@ -77,89 +86,39 @@ var endianess = binary.LittleEndian
 // NewVM creates a new VM from a given module. If the module defines a
 // start function, it will be executed.
 func NewVM(module *wasm.Module) (*VM, error) {
-	var vm VM
-
-	if module.Memory != nil && len(module.Memory.Entries) != 0 {
-		if len(module.Memory.Entries) > 1 {
-			return nil, ErrMultipleLinearMemories
-		}
-		vm.memory = make([]byte, uint(module.Memory.Entries[0].Limits.Initial)*wasmPageSize)
-		copy(vm.memory, module.LinearMemoryIndexSpace[0])
+	compiled, err := CompileModule(module)
+	if err != nil {
+		return nil, err
 	}
+	return compiled.NewVM(nil)
+}
 
-	vm.funcs = make([]function, len(module.FunctionIndexSpace))
-	vm.globals = make([]uint64, len(module.GlobalIndexSpace))
-	vm.newFuncTable()
-	vm.module = module
-
-	nNatives := 0
-	for i, fn := range module.FunctionIndexSpace {
-		// Skip native methods as they need not be
-		// disassembled; simply add them at the end
-		// of the `funcs` array as is, as specified
-		// in the spec. See the "host functions"
-		// section of:
-		// https://webassembly.github.io/spec/core/exec/modules.html#allocation
-		if fn.IsHost() {
-			vm.funcs[i] = goFunction{
-				typ: fn.Host.Type(),
-				val: fn.Host,
-			}
-			nNatives++
-			continue
-		}
-
-		disassembly, err := disasm.Disassemble(fn, module)
-		if err != nil {
-			return nil, err
-		}
+// Memory returns the linear memory space for the VM.
+func (vm *VM) Memory() []byte {
+	return vm.memory
+}
 
-		totalLocalVars := 0
-		totalLocalVars += len(fn.Sig.ParamTypes)
-		for _, entry := range fn.Body.Locals {
-			totalLocalVars += int(entry.Count)
-		}
-		code, table := compile.Compile(disassembly.Code)
-		vm.funcs[i] = compiledFunction{
-			code:           code,
-			branchTables:   table,
-			maxDepth:       disassembly.MaxDepth,
-			totalLocalVars: totalLocalVars,
-			args:           len(fn.Sig.ParamTypes),
-			returns:        len(fn.Sig.ReturnTypes) != 0,
-		}
+// GrowMemory adds n pages to the linear memory of the VM and returns the
+// previous size in pages. It is not charged against the gas limit.
+// As per the spec, the memory is unchanged and -1 is returned if the new
+// size exceeds the maximum declared by the module or MaxMemoryPages.
+func (vm *VM) GrowMemory(n int32) int32 {
+	curLen := len(vm.memory) / wasmPageSize
+	pages := uint64(curLen) + uint64(uint32(n))
+	if vm.MaxMemoryPages != 0 && pages > uint64(vm.MaxMemoryPages) {
+		return -1
 	}
-
-	for i, global := range module.GlobalIndexSpace {
-		val, err := module.ExecInitExpr(global.Init)
-		if err != nil {
-			return nil, err
-		}
-		switch v := val.(type) {
-		case int32:
-			vm.globals[i] = uint64(v)
-		case int64:
-			vm.globals[i] = uint64(v)
-		case float32:
-			vm.globals[i] = uint64(math.Float32bits(v))
-		case float64:
-			vm.globals[i] = uint64(math.Float64bits(v))
+	if vm.module.Memory != nil && len(vm.module.Memory.Entries) != 0 {
+		limits := vm.module.Memory.Entries[0].Limits
+		if limits.Flags&0x1 != 0 && pages > uint64(limits.Maximum) {
+			return -1
 		}
 	}
-
-	if module.Start != nil {
-		_, err := vm.ExecCode(int64(module.Start.Index))
-		if err != nil {
-			return nil, err
-		}
+	if pages > 65536 { // the 4 GiB addressed by i32
+		return -1
 	}
-
-	return &vm, nil
-}
-
-// Memory returns the linear memory space for the VM.
-func (vm *VM) Memory() []byte {
-	return vm.memory
+	vm.memory = append(vm.memory, make([]byte, int(uint32(n))*wasmPageSize)...)
+	return int32(curLen)
 }
 
 func (vm *VM) pushBool(v bool) {
@@ -322,7 +281,13 @@ func (vm *VM) execCode(compiled compiledFunction) uint64 {
 outer:
 	for int(vm.ctx.pc) < len(vm.ctx.code) {
 		op := vm.ctx.code[vm.ctx.pc]
+		if vm.tracer != nil {
+			vm.tracer.CaptureOp(vm, op)
+		}
 		vm.ctx.pc++
+		if vm.metered {
+			vm.UseGas(gasTable[op])
+		}
 		switch op {
 		case ops.Return:
 			break outer
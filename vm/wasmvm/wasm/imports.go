@@ -88,13 +88,12 @@ func (e InvalidFunctionIndexError) Error() string {
 	return fmt.Sprintf("wasm: Invalid index to function index space: %#x", uint32(e))
 }
 
-func (module *Module) resolveImports(resolve ResolveFunc) error {
+func (module *Module) resolveImports(resolve ResolveFunc, hosts *NativeFuns) error {
 	if module.Import == nil {
 		return nil
 	}
 
 	modules := make(map[string]*Module)
-	native  := GetFuns()
 	var funcs uint32
 	for _, importEntry := range module.Import.Entries {
 		//TODO import/global/memory
@@ -102,7 +101,7 @@ func (module *Module) resolveImports(resolve ResolveFunc) error {
 			switch importEntry.Kind {
 				case ExternalFunction:
 				funcType := module.Types.Entries[importEntry.Type.(FuncImport).Type]
-				host := native.GetValue(importEntry.FieldName)
+				host := hosts.GetValue(importEntry.FieldName)
 				fn := &Function{Sig: &FunctionSig{ParamTypes: funcType.ParamTypes, ReturnTypes: funcType.ReturnTypes}, Body: &FunctionBody{}, Host:host}
 				module.FunctionIndexSpace = append(module.FunctionIndexSpace, *fn)
 				module.Code.Bodies = append(module.Code.Bodies, *fn.Body)
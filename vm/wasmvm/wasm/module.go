@@ -90,6 +90,12 @@ type ResolveFunc func(name string) (*Module, error)
 // ReadModule reads a module from the reader r. resolvePath must take a string
 // and a return a reader to the module pointed to by the string.
 func ReadModule(r io.Reader, resolvePath ResolveFunc) (*Module, error) {
+	return ReadModuleWithHosts(r, resolvePath, nil)
+}
+
+// ReadModuleWithHosts reads a module like ReadModule, the functions imported
+// from env are resolved with hosts.
+func ReadModuleWithHosts(r io.Reader, resolvePath ResolveFunc, hosts *NativeFuns) (*Module, error) {
 	reader := &readpos.ReadPos{
 		R:      r,
 		CurPos: 0,
@@ -121,7 +127,7 @@ func ReadModule(r io.Reader, resolvePath ResolveFunc) (*Module, error) {
 	}
 
 	if m.Import != nil && resolvePath != nil {
-		err := m.resolveImports(resolvePath)
+		err := m.resolveImports(resolvePath, hosts)
 		if err != nil {
 			return nil, err
 		}
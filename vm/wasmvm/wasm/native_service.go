@@ -19,21 +19,16 @@ import (
 	"reflect"
 )
 
-var  Funs  *NativeFuns
-
+//the host functions imported from env by name, the table is filled by its creator and only read after that,
+//every service has its own table so there is no shared state between VMs
 type NativeFuns struct{
 	funmap map[string]reflect.Value
 }
 
-func InitNativeFuns() *NativeFuns{
-	fun := NativeFuns{make(map[string]reflect.Value)}
-	Funs = &fun
-	return Funs
+func NewNativeFuns() *NativeFuns{
+	return &NativeFuns{make(map[string]reflect.Value)}
 }
 
-func GetFuns() *NativeFuns{
-	return Funs
-}
 func (n *NativeFuns) Register(name string, i interface{}) bool{
 	if _, ok := n.funmap[name]; ok {
 		return false
@@ -44,6 +39,9 @@ func (n *NativeFuns) Register(name string, i interface{}) bool{
 }
 
 func (n *NativeFuns) GetValue (name string) reflect.Value{
+	if n == nil {
+		return reflect.Value{}
+	}
 	if value, ok := n.funmap[name]; ok {
 		return value
 	}